@@ -0,0 +1,100 @@
+package banking
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+func TestParseIBAN(t *testing.T) {
+	valid := []string{
+		"CH93 0076 2011 6238 5295 7",
+		"DE89 3704 0044 0532 0130 00",
+		"GB29 NWBK 6016 1331 9268 19",
+		"FR14 2004 1010 0505 0001 3M02 606",
+		"NL91ABNA0417164300",
+		"iban be68 5390 0754 7034",
+	}
+	for _, s := range valid {
+		if err := ValidateIBAN(s); err != nil {
+			t.Errorf("expected %q to be valid, got %v", s, err)
+		}
+	}
+
+	tests := []struct {
+		iban    string
+		wantErr error
+	}{
+		{"", ErrEmptyIBAN},
+		{"XX12 3456", ErrUnsupportedIBAN},
+		{"DE89 3704 0044 0532 0130", ErrInvalidIBANLength},
+		{"GB29 1234 6016 1331 9268 19", ErrInvalidIBANFormat},
+		{"DE88 3704 0044 0532 0130 00", ErrInvalidIBANChecksum},
+	}
+	for _, tt := range tests {
+		if err := ValidateIBAN(tt.iban); !errors.Is(err, tt.wantErr) {
+			t.Errorf("ValidateIBAN(%q): expected %v, got %v", tt.iban, tt.wantErr, err)
+		}
+	}
+}
+
+func TestFormatIBAN(t *testing.T) {
+	if got := FormatIBAN("de89370400440532013000"); got != "DE89 3704 0044 0532 0130 00" {
+		t.Errorf("unexpected format %q", got)
+	}
+	if got := FormatIBAN("not an iban"); got != "not an iban" {
+		t.Errorf("expected invalid input to be returned unchanged, got %q", got)
+	}
+}
+
+func TestQRIBAN(t *testing.T) {
+	if !IsQRIBAN("CH44 3199 9123 0008 8901 2") {
+		t.Error("expected QR-IBAN to be detected")
+	}
+	if IsQRIBAN("CH93 0076 2011 6238 5295 7") {
+		t.Error("expected regular IBAN not to be a QR-IBAN")
+	}
+}
+
+func TestParseBIC(t *testing.T) {
+	bic, err := ParseBIC("ubsw ch zh 80a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bic.CountryCode() != "CH" || bic.BranchCode() != "80A" {
+		t.Errorf("unexpected BIC parts %s/%s", bic.CountryCode(), bic.BranchCode())
+	}
+	for _, s := range []string{"DEUTDEFF", "DEUTDEFF500"} {
+		if err := ValidateBIC(s); err != nil {
+			t.Errorf("expected %q to be valid, got %v", s, err)
+		}
+	}
+	for _, s := range []string{"DEUTDEF", "DEUT12FF", "DEUTDEFF5000"} {
+		if err := ValidateBIC(s); !errors.Is(err, ErrInvalidBIC) {
+			t.Errorf("expected %q to be invalid, got %v", s, err)
+		}
+	}
+}
+
+func TestZeroValues(t *testing.T) {
+	var iban IBAN
+	if iban.CountryCode() != "" || iban.CheckDigits() != "" || iban.BBAN() != "" || iban.IsQRIBAN() {
+		t.Error("expected an empty IBAN to have no parts")
+	}
+	var bic BIC
+	if bic.InstitutionCode() != "" || bic.CountryCode() != "" || bic.LocationCode() != "" || bic.BranchCode() != "" || bic.IsTest() {
+		t.Error("expected an empty BIC to have no parts")
+	}
+}
+
+func TestPartyRule(t *testing.T) {
+	p := invoice.Party{Name: "S", IBAN: "DE88 3704 0044 0532 0130 00"}
+	if err := p.Validate(PartyRule); !errors.Is(err, ErrInvalidIBANChecksum) {
+		t.Errorf("expected ErrInvalidIBANChecksum, got %v", err)
+	}
+	p = invoice.Party{Name: "S", IBAN: "DE89 3704 0044 0532 0130 00", BIC: "COBADEFFXXX"}
+	if err := p.Validate(PartyRule); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package banking
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidBIC is returned for malformed bank identifier codes.
+var ErrInvalidBIC = errors.New("invalid BIC")
+
+// BIC is a validated ISO 9362 Business Identifier Code (SWIFT code).
+type BIC struct {
+	value string
+}
+
+// ParseBIC normalizes and validates a BIC. A BIC consists of a four
+// character institution code, a two letter country code, a two character
+// location code and an optional three character branch code.
+func ParseBIC(s string) (BIC, error) {
+	n := strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	if len(n) != 8 && len(n) != 11 {
+		return BIC{}, fmt.Errorf("%w: %q must have 8 or 11 characters", ErrInvalidBIC, s)
+	}
+	if !isAlphaNumeric(n[:4]) {
+		return BIC{}, fmt.Errorf("%w: %q has an invalid institution code", ErrInvalidBIC, s)
+	}
+	if !isAlpha(n[4:6]) {
+		return BIC{}, fmt.Errorf("%w: %q has an invalid country code", ErrInvalidBIC, s)
+	}
+	if !isAlphaNumeric(n[6:8]) {
+		return BIC{}, fmt.Errorf("%w: %q has an invalid location code", ErrInvalidBIC, s)
+	}
+	if len(n) == 11 && !isAlphaNumeric(n[8:]) {
+		return BIC{}, fmt.Errorf("%w: %q has an invalid branch code", ErrInvalidBIC, s)
+	}
+	return BIC{value: n}, nil
+}
+
+// ValidateBIC returns an error if the BIC is malformed.
+func ValidateBIC(s string) error {
+	_, err := ParseBIC(s)
+	return err
+}
+
+// String returns the BIC.
+func (b BIC) String() string {
+	return b.value
+}
+
+// InstitutionCode returns the four character institution (bank) code.
+func (b BIC) InstitutionCode() string {
+	if b.value == "" {
+		return ""
+	}
+	return b.value[:4]
+}
+
+// CountryCode returns the ISO country code of the BIC.
+func (b BIC) CountryCode() string {
+	if b.value == "" {
+		return ""
+	}
+	return b.value[4:6]
+}
+
+// LocationCode returns the two character location code.
+func (b BIC) LocationCode() string {
+	if b.value == "" {
+		return ""
+	}
+	return b.value[6:8]
+}
+
+// BranchCode returns the branch code, "XXX" for the primary office.
+func (b BIC) BranchCode() string {
+	if b.value == "" {
+		return ""
+	}
+	if len(b.value) == 8 {
+		return "XXX"
+	}
+	return b.value[8:]
+}
+
+// IsTest returns true for test and training BICs, whose location code
+// ends in "0".
+func (b BIC) IsTest() bool {
+	return b.value != "" && b.value[7] == '0'
+}
+
+func isAlphaNumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,326 @@
+// Package banking provides parsing, validation and formatting of bank
+// account identifiers (IBAN) and bank identifier codes (BIC).
+package banking
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// IBAN validation errors.
+var (
+	ErrEmptyIBAN           = errors.New("IBAN is empty")
+	ErrUnsupportedIBAN     = errors.New("unsupported IBAN country")
+	ErrInvalidIBANLength   = errors.New("invalid IBAN length")
+	ErrInvalidIBANFormat   = errors.New("invalid IBAN format")
+	ErrInvalidIBANChecksum = errors.New("invalid IBAN check digits")
+)
+
+// ibanSpec describes a country's IBAN length and BBAN structure in the
+// notation of the SWIFT IBAN registry, e.g. "8!n10!n" (n: digits,
+// a: upper-case letters, c: alphanumeric).
+type ibanSpec struct {
+	length int
+	bban   string
+}
+
+// ibanRegistry maps ISO country codes to their IBAN structure.
+var ibanRegistry = map[string]ibanSpec{
+	"AD": {24, "4!n4!n12!c"},
+	"AE": {23, "3!n16!n"},
+	"AL": {28, "8!n16!c"},
+	"AT": {20, "5!n11!n"},
+	"AZ": {28, "4!a20!c"},
+	"BA": {20, "3!n3!n8!n2!n"},
+	"BE": {16, "3!n7!n2!n"},
+	"BG": {22, "4!a4!n2!n8!c"},
+	"BH": {22, "4!a14!c"},
+	"BI": {27, "5!n5!n11!n2!n"},
+	"BR": {29, "8!n5!n10!n1!a1!c"},
+	"BY": {28, "4!c4!n16!c"},
+	"CH": {21, "5!n12!c"},
+	"CR": {22, "4!n14!n"},
+	"CY": {28, "3!n5!n16!c"},
+	"CZ": {24, "4!n6!n10!n"},
+	"DE": {22, "8!n10!n"},
+	"DJ": {27, "5!n5!n11!n2!n"},
+	"DK": {18, "4!n9!n1!n"},
+	"DO": {28, "4!c20!n"},
+	"EE": {20, "2!n2!n11!n1!n"},
+	"EG": {29, "4!n4!n17!n"},
+	"ES": {24, "4!n4!n1!n1!n10!n"},
+	"FI": {18, "3!n11!n"},
+	"FK": {18, "2!a12!n"},
+	"FO": {18, "4!n9!n1!n"},
+	"FR": {27, "5!n5!n11!c2!n"},
+	"GB": {22, "4!a6!n8!n"},
+	"GE": {22, "2!a16!n"},
+	"GI": {23, "4!a15!c"},
+	"GL": {18, "4!n9!n1!n"},
+	"GR": {27, "3!n4!n16!c"},
+	"GT": {28, "4!c20!c"},
+	"HR": {21, "7!n10!n"},
+	"HU": {28, "3!n4!n1!n15!n1!n"},
+	"IE": {22, "4!a6!n8!n"},
+	"IL": {23, "3!n3!n13!n"},
+	"IQ": {23, "4!a3!n12!n"},
+	"IS": {26, "4!n2!n6!n10!n"},
+	"IT": {27, "1!a5!n5!n12!c"},
+	"JO": {30, "4!a4!n18!c"},
+	"KW": {30, "4!a22!c"},
+	"KZ": {20, "3!n13!c"},
+	"LB": {28, "4!n20!c"},
+	"LC": {32, "4!a24!c"},
+	"LI": {21, "5!n12!c"},
+	"LT": {20, "5!n11!n"},
+	"LU": {20, "3!n13!c"},
+	"LV": {21, "4!a13!c"},
+	"LY": {25, "3!n3!n15!n"},
+	"MC": {27, "5!n5!n11!c2!n"},
+	"MD": {24, "2!c18!c"},
+	"ME": {22, "3!n13!n2!n"},
+	"MK": {19, "3!n10!c2!n"},
+	"MN": {20, "4!n12!n"},
+	"MR": {27, "5!n5!n11!n2!n"},
+	"MT": {31, "4!a5!n18!c"},
+	"MU": {30, "4!a2!n2!n12!n3!n3!a"},
+	"NI": {28, "4!a20!n"},
+	"NL": {18, "4!a10!n"},
+	"NO": {15, "4!n6!n1!n"},
+	"OM": {23, "3!n16!c"},
+	"PK": {24, "4!a16!c"},
+	"PL": {28, "8!n16!n"},
+	"PS": {29, "4!a21!c"},
+	"PT": {25, "4!n4!n11!n2!n"},
+	"QA": {29, "4!a21!c"},
+	"RO": {24, "4!a16!c"},
+	"RS": {22, "3!n13!n2!n"},
+	"RU": {33, "9!n5!n15!c"},
+	"SA": {24, "2!n18!c"},
+	"SC": {31, "4!a2!n2!n16!n3!a"},
+	"SD": {18, "2!n12!n"},
+	"SE": {24, "3!n16!n1!n"},
+	"SI": {19, "5!n8!n2!n"},
+	"SK": {24, "4!n6!n10!n"},
+	"SM": {27, "1!a5!n5!n12!c"},
+	"SO": {23, "4!n3!n12!n"},
+	"ST": {25, "4!n4!n11!n2!n"},
+	"SV": {28, "4!a20!n"},
+	"TL": {23, "3!n14!n2!n"},
+	"TN": {24, "2!n3!n13!n2!n"},
+	"TR": {26, "5!n1!n16!c"},
+	"UA": {29, "6!n19!c"},
+	"VA": {22, "3!n15!n"},
+	"VG": {24, "4!a16!n"},
+	"XK": {20, "4!n10!n2!n"},
+	"YE": {30, "4!a4!n18!c"},
+}
+
+// IBAN is a validated International Bank Account Number.
+type IBAN struct {
+	value string
+}
+
+// NormalizeIBAN upper-cases the IBAN and removes spaces and separators.
+func NormalizeIBAN(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimPrefix(b.String(), "IBAN")
+}
+
+// ParseIBAN normalizes and validates an IBAN, checking the country-specific
+// length and BBAN structure as well as the ISO 7064 mod 97 check digits.
+func ParseIBAN(s string) (IBAN, error) {
+	n := NormalizeIBAN(s)
+	if n == "" {
+		return IBAN{}, ErrEmptyIBAN
+	}
+	if len(n) < 4 || !isAlpha(n[:2]) || !isNumeric(n[2:4]) {
+		return IBAN{}, fmt.Errorf("%w: %q", ErrInvalidIBANFormat, s)
+	}
+	spec, ok := ibanRegistry[n[:2]]
+	if !ok {
+		return IBAN{}, fmt.Errorf("%w: %s", ErrUnsupportedIBAN, n[:2])
+	}
+	if len(n) != spec.length {
+		return IBAN{}, fmt.Errorf("%w: %s IBANs have %d characters, got %d", ErrInvalidIBANLength, n[:2], spec.length, len(n))
+	}
+	if !matchStructure(n[4:], spec.bban) {
+		return IBAN{}, fmt.Errorf("%w: BBAN does not match %s structure %s", ErrInvalidIBANFormat, n[:2], spec.bban)
+	}
+	if ibanMod97(n) != 1 {
+		return IBAN{}, fmt.Errorf("%w: %s", ErrInvalidIBANChecksum, n)
+	}
+	return IBAN{value: n}, nil
+}
+
+// ValidateIBAN returns an error if the IBAN is invalid.
+func ValidateIBAN(s string) error {
+	_, err := ParseIBAN(s)
+	return err
+}
+
+// String returns the IBAN in electronic format without spaces.
+func (i IBAN) String() string {
+	return i.value
+}
+
+// Format returns the IBAN in print format, in groups of four characters.
+func (i IBAN) Format() string {
+	var b strings.Builder
+	for j := 0; j < len(i.value); j += 4 {
+		if j > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(i.value[j:min(j+4, len(i.value))])
+	}
+	return b.String()
+}
+
+// CountryCode returns the ISO country code of the IBAN.
+func (i IBAN) CountryCode() string {
+	if i.value == "" {
+		return ""
+	}
+	return i.value[:2]
+}
+
+// CheckDigits returns the two IBAN check digits.
+func (i IBAN) CheckDigits() string {
+	if i.value == "" {
+		return ""
+	}
+	return i.value[2:4]
+}
+
+// BBAN returns the country-specific Basic Bank Account Number.
+func (i IBAN) BBAN() string {
+	if i.value == "" {
+		return ""
+	}
+	return i.value[4:]
+}
+
+// IsQRIBAN returns true for Swiss and Liechtenstein QR-IBANs, whose
+// institution ID lies in the reserved range 30000-31999. QR-IBANs must be
+// used with a QR reference on Swiss QR bills.
+func (i IBAN) IsQRIBAN() bool {
+	if len(i.value) < 9 {
+		return false
+	}
+	if i.CountryCode() != "CH" && i.CountryCode() != "LI" {
+		return false
+	}
+	iid, err := strconv.Atoi(i.value[4:9])
+	return err == nil && iid >= 30000 && iid <= 31999
+}
+
+// FormatIBAN returns the IBAN in print format, or the input unchanged if
+// it is not a valid IBAN.
+func FormatIBAN(s string) string {
+	i, err := ParseIBAN(s)
+	if err != nil {
+		return s
+	}
+	return i.Format()
+}
+
+// IsQRIBAN returns true if s is a valid Swiss or Liechtenstein QR-IBAN.
+func IsQRIBAN(s string) bool {
+	i, err := ParseIBAN(s)
+	return err == nil && i.IsQRIBAN()
+}
+
+// ibanMod97 computes the ISO 7064 mod 97-10 remainder of the IBAN with its
+// country code and check digits moved to the end and letters expanded to
+// numbers (A=10 ... Z=35).
+func ibanMod97(n string) int {
+	rearranged := n[4:] + n[:4]
+	r := 0
+	for i := 0; i < len(rearranged); i++ {
+		c := rearranged[i]
+		if c >= 'A' && c <= 'Z' {
+			v := int(c-'A') + 10
+			r = (r*100 + v) % 97
+		} else {
+			r = (r*10 + int(c-'0')) % 97
+		}
+	}
+	return r
+}
+
+// matchStructure checks a BBAN against a registry structure such as
+// "4!a6!n8!n".
+func matchStructure(bban, structure string) bool {
+	pos := 0
+	for structure != "" {
+		i := strings.IndexByte(structure, '!')
+		if i < 0 || i+1 >= len(structure) {
+			return false
+		}
+		count, err := strconv.Atoi(structure[:i])
+		if err != nil {
+			return false
+		}
+		kind := structure[i+1]
+		structure = structure[i+2:]
+		if pos+count > len(bban) {
+			return false
+		}
+		part := bban[pos : pos+count]
+		switch kind {
+		case 'n':
+			if !isNumeric(part) {
+				return false
+			}
+		case 'a':
+			if !isAlpha(part) {
+				return false
+			}
+		}
+		pos += count
+	}
+	return pos == len(bban)
+}
+
+func isNumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// PartyRule is an invoice.PartyRule that validates the party's IBAN and
+// BIC when they are set.
+func PartyRule(p invoice.Party) error {
+	if p.IBAN != "" {
+		if err := ValidateIBAN(p.IBAN); err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+	if p.BIC != "" {
+		if err := ValidateBIC(p.BIC); err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
@@ -4,24 +4,44 @@ package currency
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/shopspring/decimal"
+
+	"github.com/wiederin/go-invoicer/invoice"
 )
 
-// Currency represents a currency with its formatting rules.
+//go:generate go run ./internal/gen -i internal/gen/iso4217.csv -o iso4217.go
+
+// Currency represents a currency with its formatting rules. DecimalPlaces
+// holds the ISO 4217 minor units; funds and precious metals without minor
+// units have none. CashIncrement is the smallest amount used in cash
+// payments where it differs from the minor unit, e.g. 0.05 for CHF.
+// Withdrawn is the year and month ("2002-03") a currency was withdrawn
+// from ISO 4217, or empty if it is current.
 type Currency struct {
-	Code           string `json:"code"`
-	Name           string `json:"name"`
-	Symbol         string `json:"symbol"`
-	SymbolPosition string `json:"symbol_position"`
-	DecimalPlaces  int32  `json:"decimal_places"`
-	DecimalSep     string `json:"decimal_sep"`
-	ThousandsSep   string `json:"thousands_sep"`
+	Code           string          `json:"code"`
+	NumericCode    string          `json:"numeric_code,omitempty"`
+	Name           string          `json:"name"`
+	Symbol         string          `json:"symbol"`
+	SymbolPosition string          `json:"symbol_position"`
+	DecimalPlaces  int32           `json:"decimal_places"`
+	DecimalSep     string          `json:"decimal_sep"`
+	ThousandsSep   string          `json:"thousands_sep"`
+	CashIncrement  decimal.Decimal `json:"cash_increment,omitzero"`
+	Withdrawn      string          `json:"withdrawn,omitempty"`
+}
+
+// IsWithdrawn returns true if the currency has been withdrawn from ISO 4217.
+func (c Currency) IsWithdrawn() bool {
+	return c.Withdrawn != ""
 }
 
-// Currencies contains predefined currency definitions.
-var Currencies = map[string]Currency{
+// formats holds formatting rules that differ from the defaults applied to
+// the generated ISO 4217 table.
+var formats = map[string]Currency{
 	"USD": {Code: "USD", Name: "US Dollar", Symbol: "$", SymbolPosition: "before", DecimalPlaces: 2, DecimalSep: ".", ThousandsSep: ","},
 	"EUR": {Code: "EUR", Name: "Euro", Symbol: "€", SymbolPosition: "after", DecimalPlaces: 2, DecimalSep: ",", ThousandsSep: "."},
 	"CHF": {Code: "CHF", Name: "Swiss Franc", Symbol: "CHF", SymbolPosition: "before", DecimalPlaces: 2, DecimalSep: ".", ThousandsSep: "'"},
@@ -34,12 +54,109 @@ var Currencies = map[string]Currency{
 	"BRL": {Code: "BRL", Name: "Brazilian Real", Symbol: "R$", SymbolPosition: "before", DecimalPlaces: 2, DecimalSep: ",", ThousandsSep: "."},
 }
 
+// currencies contains the ISO 4217 currencies, including withdrawn ones,
+// and any registered with Register, keyed by code. It is guarded by mu.
+var currencies = func() map[string]Currency {
+	m := make(map[string]Currency, len(iso4217))
+	for _, c := range iso4217 {
+		if f, ok := formats[c.Code]; ok {
+			f.NumericCode = c.NumericCode
+			f.CashIncrement = c.CashIncrement
+			m[c.Code] = f
+			continue
+		}
+		m[c.Code] = withDefaults(c)
+	}
+	return m
+}()
+
+var mu sync.RWMutex
+
+// The invoice package rounds amounts to the minor units of this table.
+func init() {
+	invoice.SetMinorUnits(func(code string) (int32, bool) {
+		c, ok := Get(code)
+		return c.DecimalPlaces, ok
+	})
+}
+
+// withDefaults fills in empty formatting fields.
+func withDefaults(c Currency) Currency {
+	if c.Symbol == "" {
+		c.Symbol = c.Code
+	}
+	if c.SymbolPosition == "" {
+		c.SymbolPosition = "before"
+	}
+	if c.DecimalSep == "" && c.DecimalPlaces > 0 {
+		c.DecimalSep = "."
+	}
+	if c.ThousandsSep == "" {
+		c.ThousandsSep = ","
+	}
+	return c
+}
+
 // Get returns the currency for a given code.
 func Get(code string) (Currency, bool) {
-	c, ok := Currencies[strings.ToUpper(code)]
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := currencies[strings.ToUpper(code)]
 	return c, ok
 }
 
+// All returns the ISO 4217 currencies, including withdrawn ones, and any
+// registered with Register, sorted by code.
+func All() []Currency {
+	mu.RLock()
+	defer mu.RUnlock()
+	all := make([]Currency, 0, len(currencies))
+	for _, c := range currencies {
+		all = append(all, c)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Code < all[j].Code })
+	return all
+}
+
+// GetByNumericCode returns the currency with the given ISO 4217 numeric
+// code, e.g. "978" for EUR. Current currencies take precedence over
+// withdrawn ones sharing the same numeric code.
+func GetByNumericCode(numeric string) (Currency, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	var found Currency
+	var ok bool
+	for _, c := range currencies {
+		if c.NumericCode != numeric {
+			continue
+		}
+		if !ok || (found.IsWithdrawn() && !c.IsWithdrawn()) {
+			found, ok = c, true
+		}
+	}
+	return found, ok
+}
+
+// Register adds a custom currency or replaces the definition of an existing
+// one. The code must consist of three letters; empty formatting fields
+// default to the symbol before the amount, "." and ",".
+func Register(c Currency) error {
+	code := strings.ToUpper(c.Code)
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return fmt.Errorf("invalid currency code: %q", c.Code)
+	}
+	if c.DecimalPlaces < 0 || c.DecimalPlaces > 8 {
+		return fmt.Errorf("invalid decimal places for %s: %d", code, c.DecimalPlaces)
+	}
+	c.Code = code
+	c = withDefaults(c)
+
+	mu.Lock()
+	defer mu.Unlock()
+	currencies[code] = c
+	return nil
+}
+
 // Formatter formats monetary amounts according to currency rules.
 type Formatter struct {
 	currency Currency
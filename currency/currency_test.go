@@ -0,0 +1,258 @@
+package currency
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+func TestFormatSimpleISO4217(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"USD", "$ 1,234.50"},
+		{"EUR", "1.234,50 €"},
+		{"SEK", "SEK 1,234.50"},
+		{"KWD", "KWD 1,234.500"},
+		{"CLP", "CLP 1,235"},
+	}
+	amount := decimal.RequireFromString("1234.5")
+	for _, tt := range tests {
+		if got := FormatSimple(amount, tt.code); got != tt.want {
+			t.Errorf("FormatSimple(%s) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestGetByNumericCode(t *testing.T) {
+	c, ok := GetByNumericCode("532")
+	if !ok || c.Code != "XCG" {
+		t.Errorf("GetByNumericCode(532) = %s, %v, want XCG", c.Code, ok)
+	}
+	if c, _ := Get("DEM"); !c.IsWithdrawn() {
+		t.Error("DEM should be withdrawn")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if err := Register(Currency{Code: "xts1"}); err == nil {
+		t.Error("expected error for invalid code")
+	}
+	if err := Register(Currency{Code: "zzt", Name: "Test Token", Symbol: "ŧ", DecimalPlaces: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatSimple(decimal.RequireFromString("12.5"), "ZZT"); got != "ŧ 12.5000" {
+		t.Errorf("FormatSimple(ZZT) = %q", got)
+	}
+	all := All()
+	if !slices.IsSortedFunc(all, func(a, b Currency) int { return strings.Compare(a.Code, b.Code) }) {
+		t.Error("All() is not sorted by code")
+	}
+	if !slices.ContainsFunc(all, func(c Currency) bool { return c.Code == "ZZT" }) {
+		t.Error("All() does not include the registered ZZT")
+	}
+	for code, want := range map[string]int32{"ZZT": 4, "JPY": 0, "KWD": 3, "EUR": 2} {
+		if got := invoice.MinorUnits(code); got != want {
+			t.Errorf("invoice.MinorUnits(%s) = %d, want %d", code, got, want)
+		}
+	}
+}
+
+func TestLocaleFormatter(t *testing.T) {
+	tests := []struct {
+		locale, code string
+		amount       string
+		accounting   bool
+		want         string
+	}{
+		{"en-IE", "EUR", "1234.56", false, "€1,234.56"},
+		{"de-DE", "EUR", "1234.56", false, "1.234,56\u00a0€"},
+		{"de-CH", "CHF", "-1234.56", false, "CHF-1’234.56"},
+		{"en-US", "CHF", "1234.56", false, "CHF\u00a01,234.56"},
+		{"en-IN", "INR", "1234567.89", false, "₹12,34,567.89"},
+		{"en-US", "USD", "-1234.56", false, "-$1,234.56"},
+		{"en-US", "USD", "-1234.56", true, "($1,234.56)"},
+		{"nl-NL", "EUR", "-5", false, "€\u00a0-5,00"},
+		{"en-US", "BHD", "1.2345", false, "BHD\u00a01.235"},
+	}
+	for _, tt := range tests {
+		f, err := NewLocaleFormatter(tt.locale, tt.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.accounting {
+			f = f.Accounting()
+		}
+		if got := f.Format(decimal.RequireFromString(tt.amount)); got != tt.want {
+			t.Errorf("%s %s Format(%s) = %q, want %q", tt.locale, tt.code, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input, locale, currency string
+		want                    string
+		wantCode                string
+	}{
+		{"-CHF 1'234.50", "de-CH", "", "-1234.5", "CHF"},
+		{"(1,234.56)", "en-US", "USD", "-1234.56", "USD"},
+		{"1\u202f234,56\u00a0€", "fr-FR", "", "1234.56", "EUR"},
+		{"1.234", "de-DE", "EUR", "1234", "EUR"},
+		{"1,5", "en-US", "USD", "1.5", "USD"},
+		{"KWD 1.234", "en-US", "", "1.234", "KWD"},
+		{"$5", "en-CA", "", "5", "CAD"},
+		{"₹12,34,567.89", "en-IN", "", "1234567.89", "INR"},
+		{"١٬٢٣٤٫٥٠ EGP", "ar-EG", "", "1234.5", "EGP"},
+		{"42-", "en", "EUR", "-42", "EUR"},
+	}
+	for _, tt := range tests {
+		m, err := ParseMoney(tt.input, tt.locale, tt.currency)
+		if err != nil {
+			t.Errorf("ParseMoney(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !m.Amount.Equal(decimal.RequireFromString(tt.want)) || m.Currency != tt.wantCode {
+			t.Errorf("ParseMoney(%q) = %v, want %s %s", tt.input, m, tt.want, tt.wantCode)
+		}
+	}
+}
+
+func TestParseMoneyErrors(t *testing.T) {
+	tests := []struct {
+		input, locale, currency string
+		want                    error
+	}{
+		{"", "en", "USD", ErrEmptyAmount},
+		{"$1.5k", "en-US", "", ErrInvalidAmount},
+		{"1.234", "en-US", "USD", ErrAmbiguousAmount},
+		{"$5", "en-IE", "", ErrAmbiguousAmount},
+		{"kr 100", "en", "", ErrAmbiguousAmount},
+		{"-(5)", "en", "USD", ErrAmbiguousAmount},
+		{"1,2345,678", "en", "USD", ErrInvalidAmount},
+		{"5 XYZ", "en", "", ErrUnknownCurrency},
+		{"5", "en", "", ErrMissingCurrency},
+	}
+	for _, tt := range tests {
+		_, err := ParseMoney(tt.input, tt.locale, tt.currency)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("ParseMoney(%q) error = %v, want %v", tt.input, err, tt.want)
+		}
+	}
+}
+
+const ecbSample = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2025-03-28">
+			<Cube currency="USD" rate="1.0807"/>
+			<Cube currency="CHF" rate="0.9531"/>
+		</Cube>
+		<Cube time="2025-03-31">
+			<Cube currency="USD" rate="1.0815"/>
+			<Cube currency="CHF" rate="0.9533"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECBRates(t *testing.T) {
+	rates, err := LoadECBRates(strings.NewReader(ecbSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saturday falls back to the last publication day.
+	sat := time.Date(2025, 3, 29, 0, 0, 0, 0, time.UTC)
+	m, rate, err := Convert(invoice.NewMoney(1000, "EUR"), "CHF", sat, rates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.String() != "953.10 CHF" || !rate.Date.Equal(time.Date(2025, 3, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Convert = %v at %v", m, rate)
+	}
+
+	rate, err = rates.Rate("USD", "CHF", time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decimal.RequireFromString("0.8814609339"); !rate.Rate.Equal(want) {
+		t.Errorf("USD/CHF = %s, want %s", rate.Rate, want)
+	}
+
+	if _, err := rates.Rate("EUR", "CHF", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("expected ErrRateNotFound, got %v", err)
+	}
+}
+
+func TestStaticRates(t *testing.T) {
+	rates := NewStaticRates("contract").
+		Set("EUR", "CHF", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), decimal.RequireFromString("0.94")).
+		Set("EUR", "CHF", time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), decimal.RequireFromString("0.93"))
+
+	rate, err := rates.Rate("EUR", "CHF", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil || !rate.Rate.Equal(decimal.RequireFromString("0.94")) {
+		t.Errorf("Rate = %v, %v", rate, err)
+	}
+	m, _, err := Convert(invoice.NewMoney(93, "CHF"), "EUR", time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), rates)
+	if err != nil || m.String() != "100.00 EUR" {
+		t.Errorf("Convert = %v, %v", m, err)
+	}
+}
+
+func TestCashIncrement(t *testing.T) {
+	tests := []struct {
+		currency, country string
+		want              string
+	}{
+		{"CHF", "CH", "0.05"},
+		{"EUR", "NL", "0.05"},
+		{"EUR", "DE", "0"},
+		{"SEK", "SE", "1"},
+		{"DKK", "", "0.5"},
+		{"USD", "US", "0"},
+	}
+	for _, tt := range tests {
+		if got := CashIncrement(tt.currency, tt.country); !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("CashIncrement(%s, %s) = %s, want %s", tt.currency, tt.country, got, tt.want)
+		}
+	}
+}
+
+func TestSpell(t *testing.T) {
+	tests := []struct {
+		amount, currency, locale string
+		want                     string
+	}{
+		{"1234.50", "EUR", "en", "one thousand two hundred thirty-four euros and fifty cents"},
+		{"1", "USD", "en", "one dollar"},
+		{"123456.78", "INR", "en-IN", "one lakh twenty-three thousand four hundred fifty-six rupees and seventy-eight paise only"},
+		{"1234.50", "EUR", "de", "eintausendzweihundertvierunddreißig Euro und fünfzig Cent"},
+		{"1", "INR", "de", "eine Rupie"},
+		{"80", "EUR", "fr", "quatre-vingts euros"},
+		{"2000000", "EUR", "fr", "deux millions d'euros"},
+		{"181", "CHF", "fr-CH", "cent huitante et un francs"},
+		{"123", "EUR", "it", "centoventitré euro"},
+		{"21", "GBP", "it", "ventuna sterline"},
+		{"21.05", "EUR", "es", "veintiún euros con cinco céntimos"},
+		{"200", "GBP", "es", "doscientas libras"},
+		{"-5.5", "KWD", "en", "minus five Kuwaiti Dinar and 500/1000"},
+	}
+	for _, tt := range tests {
+		got, err := Spell(decimal.RequireFromString(tt.amount), tt.currency, tt.locale)
+		if err != nil || got != tt.want {
+			t.Errorf("Spell(%s, %s, %s) = %q, %v, want %q", tt.amount, tt.currency, tt.locale, got, err, tt.want)
+		}
+	}
+
+	if _, err := Spell(decimal.NewFromInt(1), "EUR", "zh"); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("Spell(zh) error = %v, want ErrUnsupportedLanguage", err)
+	}
+}
@@ -0,0 +1,219 @@
+package currency
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// ErrRateNotFound is returned when no exchange rate is available for a
+// currency pair on or before the requested date.
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// ExchangeRateProvider provides exchange rates for a currency pair. Rate
+// returns the most recent rate published on or before the given date.
+type ExchangeRateProvider interface {
+	Rate(from, to string, date time.Time) (invoice.ExchangeRate, error)
+}
+
+// Convert converts an amount to another currency using a rate from the
+// provider and rounds it to the target currency's decimal places. It
+// returns the rate used so it can be stated on the invoice.
+func Convert(m invoice.Money, to string, date time.Time, p ExchangeRateProvider) (invoice.Money, invoice.ExchangeRate, error) {
+	rate, err := p.Rate(m.Currency, to, date)
+	if err != nil {
+		return invoice.Money{}, invoice.ExchangeRate{}, err
+	}
+	converted, err := rate.Convert(m)
+	if err != nil {
+		return invoice.Money{}, invoice.ExchangeRate{}, err
+	}
+	places := int32(2)
+	if c, ok := Get(to); ok {
+		places = c.DecimalPlaces
+	}
+	return converted.Round(places), rate, nil
+}
+
+// datedRate is a rate valid from a date until the next published rate.
+type datedRate struct {
+	date time.Time
+	rate decimal.Decimal
+}
+
+// rateSeries holds the rates of a currency pair sorted by date.
+type rateSeries []datedRate
+
+func (s rateSeries) at(date time.Time) (datedRate, bool) {
+	i := sort.Search(len(s), func(i int) bool { return s[i].date.After(date) })
+	if i == 0 {
+		return datedRate{}, false
+	}
+	return s[i-1], true
+}
+
+func (s rateSeries) insert(r datedRate) rateSeries {
+	i := sort.Search(len(s), func(i int) bool { return !s[i].date.Before(r.date) })
+	if i < len(s) && s[i].date.Equal(r.date) {
+		s[i] = r
+		return s
+	}
+	s = append(s, datedRate{})
+	copy(s[i+1:], s[i:])
+	s[i] = r
+	return s
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// StaticRates is an ExchangeRateProvider backed by a table of rates, e.g.
+// rates agreed in a contract or published by a tax authority. Inverse
+// rates are derived when only the opposite direction is known.
+type StaticRates struct {
+	mu     sync.RWMutex
+	source string
+	rates  map[string]rateSeries
+}
+
+// NewStaticRates creates an empty rate table. The source is recorded on
+// the returned rates, e.g. "ESTV" or "contract".
+func NewStaticRates(source string) *StaticRates {
+	return &StaticRates{source: source, rates: make(map[string]rateSeries)}
+}
+
+// Set adds the rate converting one unit of from into to, valid from the
+// given date.
+func (s *StaticRates) Set(from, to string, date time.Time, rate decimal.Decimal) *StaticRates {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToUpper(from) + "/" + strings.ToUpper(to)
+	s.rates[key] = s.rates[key].insert(datedRate{date: day(date), rate: rate})
+	return s
+}
+
+// Rate returns the rate for the currency pair on or before the date.
+func (s *StaticRates) Rate(from, to string, date time.Time) (invoice.ExchangeRate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return invoice.ExchangeRate{From: from, To: to, Rate: decimal.NewFromInt(1), Date: day(date), Source: s.source}, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if r, ok := s.rates[from+"/"+to].at(day(date)); ok {
+		return invoice.ExchangeRate{From: from, To: to, Rate: r.rate, Date: r.date, Source: s.source}, nil
+	}
+	if r, ok := s.rates[to+"/"+from].at(day(date)); ok && r.rate.IsPositive() {
+		rate := invoice.ExchangeRate{From: to, To: from, Rate: r.rate, Date: r.date, Source: s.source}
+		return rate.Inverse()
+	}
+	return invoice.ExchangeRate{}, fmt.Errorf("%w: %s/%s on %s", ErrRateNotFound, from, to, date.Format("2006-01-02"))
+}
+
+// ECBRates is an ExchangeRateProvider backed by the euro foreign exchange
+// reference rates of the European Central Bank, as published in the XML
+// files eurofxref-daily.xml, eurofxref-hist-90d.xml and eurofxref-hist.xml.
+// Rates between two non-euro currencies are derived via the euro.
+type ECBRates struct {
+	days  []time.Time
+	rates map[time.Time]map[string]decimal.Decimal
+}
+
+type ecbEnvelope struct {
+	Cubes []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// LoadECBRates reads ECB reference rates in XML format.
+func LoadECBRates(r io.Reader) (*ECBRates, error) {
+	var env ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("failed to parse ECB rates: %w", err)
+	}
+	e := &ECBRates{rates: make(map[time.Time]map[string]decimal.Decimal)}
+	for _, cube := range env.Cubes {
+		date, err := time.Parse("2006-01-02", cube.Time)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ECB rates: invalid date %q", cube.Time)
+		}
+		rates := make(map[string]decimal.Decimal, len(cube.Rates))
+		for _, rate := range cube.Rates {
+			d, err := decimal.NewFromString(rate.Rate)
+			if err != nil || !d.IsPositive() {
+				return nil, fmt.Errorf("failed to parse ECB rates: invalid %s rate %q on %s", rate.Currency, rate.Rate, cube.Time)
+			}
+			rates[rate.Currency] = d
+		}
+		if _, ok := e.rates[date]; !ok {
+			e.days = append(e.days, date)
+		}
+		e.rates[date] = rates
+	}
+	if len(e.days) == 0 {
+		return nil, errors.New("failed to parse ECB rates: no rates found")
+	}
+	sort.Slice(e.days, func(i, j int) bool { return e.days[i].Before(e.days[j]) })
+	return e, nil
+}
+
+// LoadECBRatesFile reads ECB reference rates from an XML file.
+func LoadECBRatesFile(path string) (*ECBRates, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadECBRates(f)
+}
+
+// Rate returns the reference rate for the currency pair published on the
+// date, or on the last publication day before it.
+func (e *ECBRates) Rate(from, to string, date time.Time) (invoice.ExchangeRate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	notFound := fmt.Errorf("%w: %s/%s on %s", ErrRateNotFound, from, to, date.Format("2006-01-02"))
+
+	i := sort.Search(len(e.days), func(i int) bool { return e.days[i].After(day(date)) })
+	if i == 0 {
+		return invoice.ExchangeRate{}, notFound
+	}
+	published := e.days[i-1]
+	rates := e.rates[published]
+	perEUR := func(code string) (decimal.Decimal, bool) {
+		if code == "EUR" {
+			return decimal.NewFromInt(1), true
+		}
+		r, ok := rates[code]
+		return r, ok
+	}
+	fromRate, ok := perEUR(from)
+	if !ok {
+		return invoice.ExchangeRate{}, notFound
+	}
+	toRate, ok := perEUR(to)
+	if !ok {
+		return invoice.ExchangeRate{}, notFound
+	}
+	return invoice.ExchangeRate{
+		From:   from,
+		To:     to,
+		Rate:   toRate.DivRound(fromRate, 10),
+		Date:   published,
+		Source: "ECB",
+	}, nil
+}
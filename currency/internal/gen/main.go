@@ -0,0 +1,121 @@
+// Command gen generates the ISO 4217 currency table of package currency from
+// iso4217.csv. Symbols and cash rounding increments are taken from the CLDR
+// data in golang.org/x/text; symbols fall back to the currency code.
+//
+// Usage (from the currency package directory):
+//
+//	go run ./internal/gen -i internal/gen/iso4217.csv -o iso4217.go
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strconv"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+type entry struct {
+	code, numeric, name, symbol, withdrawn string
+	minorUnits                             int
+	cashScale, cashIncrement               int
+}
+
+func main() {
+	in := flag.String("i", "internal/gen/iso4217.csv", "input CSV file")
+	out := flag.String("o", "iso4217.go", "output Go file")
+	flag.Parse()
+
+	entries, err := read(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(entries)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func read(path string) ([]entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%s: no currencies", path)
+	}
+
+	p := message.NewPrinter(language.English)
+	seen := make(map[string]bool)
+	var entries []entry
+	for i, r := range records[1:] {
+		if len(r) != 5 {
+			return nil, fmt.Errorf("%s:%d: expected 5 fields, got %d", path, i+2, len(r))
+		}
+		e := entry{code: r[0], numeric: r[1], name: r[3], withdrawn: r[4]}
+		if len(e.code) != 3 || len(e.numeric) != 3 {
+			return nil, fmt.Errorf("%s:%d: invalid code %q or numeric code %q", path, i+2, e.code, e.numeric)
+		}
+		if seen[e.code] {
+			return nil, fmt.Errorf("%s:%d: duplicate code %s", path, i+2, e.code)
+		}
+		seen[e.code] = true
+		if r[2] != "N.A." {
+			if e.minorUnits, err = strconv.Atoi(r[2]); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid minor units %q", path, i+2, r[2])
+			}
+		}
+		e.symbol = e.code
+		if u, err := currency.ParseISO(e.code); err == nil {
+			e.symbol = p.Sprint(currency.Symbol(u))
+			// Only record cash rounding that is coarser than both the
+			// standard rounding and the ISO minor unit.
+			scale, increment := currency.Cash.Rounding(u)
+			stdScale, stdIncrement := currency.Standard.Rounding(u)
+			coarser := scale < e.minorUnits || (scale == e.minorUnits && increment > 1)
+			if coarser && (scale != stdScale || increment != stdIncrement) {
+				e.cashScale, e.cashIncrement = scale, increment
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func generate(entries []entry) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by go run ./internal/gen; DO NOT EDIT.\n\n")
+	b.WriteString("package currency\n\n")
+	b.WriteString("import \"github.com/shopspring/decimal\"\n\n")
+	b.WriteString("// iso4217 lists the ISO 4217 currencies, including withdrawn ones.\n")
+	b.WriteString("var iso4217 = []Currency{\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\t{Code: %q, NumericCode: %q, Name: %q, Symbol: %q, DecimalPlaces: %d",
+			e.code, e.numeric, e.name, e.symbol, e.minorUnits)
+		if e.cashIncrement > 0 {
+			fmt.Fprintf(&b, ", CashIncrement: decimal.New(%d, %d)", e.cashIncrement, -e.cashScale)
+		}
+		if e.withdrawn != "" {
+			fmt.Fprintf(&b, ", Withdrawn: %q", e.withdrawn)
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("}\n")
+	return format.Source(b.Bytes())
+}
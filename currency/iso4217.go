@@ -0,0 +1,232 @@
+// Code generated by go run ./internal/gen; DO NOT EDIT.
+
+package currency
+
+import "github.com/shopspring/decimal"
+
+// iso4217 lists the ISO 4217 currencies, including withdrawn ones.
+var iso4217 = []Currency{
+	{Code: "AED", NumericCode: "784", Name: "UAE Dirham", Symbol: "AED", DecimalPlaces: 2},
+	{Code: "AFN", NumericCode: "971", Name: "Afghani", Symbol: "AFN", DecimalPlaces: 2},
+	{Code: "ALL", NumericCode: "008", Name: "Lek", Symbol: "ALL", DecimalPlaces: 2},
+	{Code: "AMD", NumericCode: "051", Name: "Armenian Dram", Symbol: "AMD", DecimalPlaces: 2},
+	{Code: "AOA", NumericCode: "973", Name: "Kwanza", Symbol: "AOA", DecimalPlaces: 2},
+	{Code: "ARS", NumericCode: "032", Name: "Argentine Peso", Symbol: "ARS", DecimalPlaces: 2},
+	{Code: "AUD", NumericCode: "036", Name: "Australian Dollar", Symbol: "A$", DecimalPlaces: 2},
+	{Code: "AWG", NumericCode: "533", Name: "Aruban Florin", Symbol: "AWG", DecimalPlaces: 2},
+	{Code: "AZN", NumericCode: "944", Name: "Azerbaijan Manat", Symbol: "AZN", DecimalPlaces: 2},
+	{Code: "BAM", NumericCode: "977", Name: "Convertible Mark", Symbol: "BAM", DecimalPlaces: 2},
+	{Code: "BBD", NumericCode: "052", Name: "Barbados Dollar", Symbol: "BBD", DecimalPlaces: 2},
+	{Code: "BDT", NumericCode: "050", Name: "Taka", Symbol: "BDT", DecimalPlaces: 2},
+	{Code: "BHD", NumericCode: "048", Name: "Bahraini Dinar", Symbol: "BHD", DecimalPlaces: 3},
+	{Code: "BIF", NumericCode: "108", Name: "Burundi Franc", Symbol: "BIF", DecimalPlaces: 0},
+	{Code: "BMD", NumericCode: "060", Name: "Bermudian Dollar", Symbol: "BMD", DecimalPlaces: 2},
+	{Code: "BND", NumericCode: "096", Name: "Brunei Dollar", Symbol: "BND", DecimalPlaces: 2},
+	{Code: "BOB", NumericCode: "068", Name: "Boliviano", Symbol: "BOB", DecimalPlaces: 2},
+	{Code: "BOV", NumericCode: "984", Name: "Mvdol", Symbol: "BOV", DecimalPlaces: 2},
+	{Code: "BRL", NumericCode: "986", Name: "Brazilian Real", Symbol: "R$", DecimalPlaces: 2},
+	{Code: "BSD", NumericCode: "044", Name: "Bahamian Dollar", Symbol: "BSD", DecimalPlaces: 2},
+	{Code: "BTN", NumericCode: "064", Name: "Ngultrum", Symbol: "BTN", DecimalPlaces: 2},
+	{Code: "BWP", NumericCode: "072", Name: "Pula", Symbol: "BWP", DecimalPlaces: 2},
+	{Code: "BYN", NumericCode: "933", Name: "Belarusian Ruble", Symbol: "BYN", DecimalPlaces: 2},
+	{Code: "BZD", NumericCode: "084", Name: "Belize Dollar", Symbol: "BZD", DecimalPlaces: 2},
+	{Code: "CAD", NumericCode: "124", Name: "Canadian Dollar", Symbol: "CA$", DecimalPlaces: 2, CashIncrement: decimal.New(5, -2)},
+	{Code: "CDF", NumericCode: "976", Name: "Congolese Franc", Symbol: "CDF", DecimalPlaces: 2},
+	{Code: "CHE", NumericCode: "947", Name: "WIR Euro", Symbol: "CHE", DecimalPlaces: 2},
+	{Code: "CHF", NumericCode: "756", Name: "Swiss Franc", Symbol: "CHF", DecimalPlaces: 2, CashIncrement: decimal.New(5, -2)},
+	{Code: "CHW", NumericCode: "948", Name: "WIR Franc", Symbol: "CHW", DecimalPlaces: 2},
+	{Code: "CLF", NumericCode: "990", Name: "Unidad de Fomento", Symbol: "CLF", DecimalPlaces: 4},
+	{Code: "CLP", NumericCode: "152", Name: "Chilean Peso", Symbol: "CLP", DecimalPlaces: 0},
+	{Code: "CNY", NumericCode: "156", Name: "Yuan Renminbi", Symbol: "CN¥", DecimalPlaces: 2},
+	{Code: "COP", NumericCode: "170", Name: "Colombian Peso", Symbol: "COP", DecimalPlaces: 2},
+	{Code: "COU", NumericCode: "970", Name: "Unidad de Valor Real", Symbol: "COU", DecimalPlaces: 2},
+	{Code: "CRC", NumericCode: "188", Name: "Costa Rican Colon", Symbol: "CRC", DecimalPlaces: 2, CashIncrement: decimal.New(1, 0)},
+	{Code: "CUC", NumericCode: "931", Name: "Peso Convertible", Symbol: "CUC", DecimalPlaces: 2},
+	{Code: "CUP", NumericCode: "192", Name: "Cuban Peso", Symbol: "CUP", DecimalPlaces: 2},
+	{Code: "CVE", NumericCode: "132", Name: "Cabo Verde Escudo", Symbol: "CVE", DecimalPlaces: 2},
+	{Code: "CZK", NumericCode: "203", Name: "Czech Koruna", Symbol: "CZK", DecimalPlaces: 2, CashIncrement: decimal.New(1, 0)},
+	{Code: "DJF", NumericCode: "262", Name: "Djibouti Franc", Symbol: "DJF", DecimalPlaces: 0},
+	{Code: "DKK", NumericCode: "208", Name: "Danish Krone", Symbol: "DKK", DecimalPlaces: 2, CashIncrement: decimal.New(50, -2)},
+	{Code: "DOP", NumericCode: "214", Name: "Dominican Peso", Symbol: "DOP", DecimalPlaces: 2},
+	{Code: "DZD", NumericCode: "012", Name: "Algerian Dinar", Symbol: "DZD", DecimalPlaces: 2},
+	{Code: "EGP", NumericCode: "818", Name: "Egyptian Pound", Symbol: "EGP", DecimalPlaces: 2},
+	{Code: "ERN", NumericCode: "232", Name: "Nakfa", Symbol: "ERN", DecimalPlaces: 2},
+	{Code: "ETB", NumericCode: "230", Name: "Ethiopian Birr", Symbol: "ETB", DecimalPlaces: 2},
+	{Code: "EUR", NumericCode: "978", Name: "Euro", Symbol: "€", DecimalPlaces: 2},
+	{Code: "FJD", NumericCode: "242", Name: "Fiji Dollar", Symbol: "FJD", DecimalPlaces: 2},
+	{Code: "FKP", NumericCode: "238", Name: "Falkland Islands Pound", Symbol: "FKP", DecimalPlaces: 2},
+	{Code: "GBP", NumericCode: "826", Name: "Pound Sterling", Symbol: "£", DecimalPlaces: 2},
+	{Code: "GEL", NumericCode: "981", Name: "Lari", Symbol: "GEL", DecimalPlaces: 2},
+	{Code: "GHS", NumericCode: "936", Name: "Ghana Cedi", Symbol: "GHS", DecimalPlaces: 2},
+	{Code: "GIP", NumericCode: "292", Name: "Gibraltar Pound", Symbol: "GIP", DecimalPlaces: 2},
+	{Code: "GMD", NumericCode: "270", Name: "Dalasi", Symbol: "GMD", DecimalPlaces: 2},
+	{Code: "GNF", NumericCode: "324", Name: "Guinean Franc", Symbol: "GNF", DecimalPlaces: 0},
+	{Code: "GTQ", NumericCode: "320", Name: "Quetzal", Symbol: "GTQ", DecimalPlaces: 2},
+	{Code: "GYD", NumericCode: "328", Name: "Guyana Dollar", Symbol: "GYD", DecimalPlaces: 2},
+	{Code: "HKD", NumericCode: "344", Name: "Hong Kong Dollar", Symbol: "HK$", DecimalPlaces: 2},
+	{Code: "HNL", NumericCode: "340", Name: "Lempira", Symbol: "HNL", DecimalPlaces: 2},
+	{Code: "HTG", NumericCode: "332", Name: "Gourde", Symbol: "HTG", DecimalPlaces: 2},
+	{Code: "HUF", NumericCode: "348", Name: "Forint", Symbol: "HUF", DecimalPlaces: 2, CashIncrement: decimal.New(1, 0)},
+	{Code: "IDR", NumericCode: "360", Name: "Rupiah", Symbol: "IDR", DecimalPlaces: 2},
+	{Code: "ILS", NumericCode: "376", Name: "New Israeli Sheqel", Symbol: "₪", DecimalPlaces: 2},
+	{Code: "INR", NumericCode: "356", Name: "Indian Rupee", Symbol: "₹", DecimalPlaces: 2},
+	{Code: "IQD", NumericCode: "368", Name: "Iraqi Dinar", Symbol: "IQD", DecimalPlaces: 3},
+	{Code: "IRR", NumericCode: "364", Name: "Iranian Rial", Symbol: "IRR", DecimalPlaces: 2},
+	{Code: "ISK", NumericCode: "352", Name: "Iceland Krona", Symbol: "ISK", DecimalPlaces: 0},
+	{Code: "JMD", NumericCode: "388", Name: "Jamaican Dollar", Symbol: "JMD", DecimalPlaces: 2},
+	{Code: "JOD", NumericCode: "400", Name: "Jordanian Dinar", Symbol: "JOD", DecimalPlaces: 3},
+	{Code: "JPY", NumericCode: "392", Name: "Yen", Symbol: "¥", DecimalPlaces: 0},
+	{Code: "KES", NumericCode: "404", Name: "Kenyan Shilling", Symbol: "KES", DecimalPlaces: 2},
+	{Code: "KGS", NumericCode: "417", Name: "Som", Symbol: "KGS", DecimalPlaces: 2},
+	{Code: "KHR", NumericCode: "116", Name: "Riel", Symbol: "KHR", DecimalPlaces: 2},
+	{Code: "KMF", NumericCode: "174", Name: "Comorian Franc", Symbol: "KMF", DecimalPlaces: 0},
+	{Code: "KPW", NumericCode: "408", Name: "North Korean Won", Symbol: "KPW", DecimalPlaces: 2},
+	{Code: "KRW", NumericCode: "410", Name: "Won", Symbol: "₩", DecimalPlaces: 0},
+	{Code: "KWD", NumericCode: "414", Name: "Kuwaiti Dinar", Symbol: "KWD", DecimalPlaces: 3},
+	{Code: "KYD", NumericCode: "136", Name: "Cayman Islands Dollar", Symbol: "KYD", DecimalPlaces: 2},
+	{Code: "KZT", NumericCode: "398", Name: "Tenge", Symbol: "KZT", DecimalPlaces: 2},
+	{Code: "LAK", NumericCode: "418", Name: "Lao Kip", Symbol: "LAK", DecimalPlaces: 2},
+	{Code: "LBP", NumericCode: "422", Name: "Lebanese Pound", Symbol: "LBP", DecimalPlaces: 2},
+	{Code: "LKR", NumericCode: "144", Name: "Sri Lanka Rupee", Symbol: "LKR", DecimalPlaces: 2},
+	{Code: "LRD", NumericCode: "430", Name: "Liberian Dollar", Symbol: "LRD", DecimalPlaces: 2},
+	{Code: "LSL", NumericCode: "426", Name: "Loti", Symbol: "LSL", DecimalPlaces: 2},
+	{Code: "LYD", NumericCode: "434", Name: "Libyan Dinar", Symbol: "LYD", DecimalPlaces: 3},
+	{Code: "MAD", NumericCode: "504", Name: "Moroccan Dirham", Symbol: "MAD", DecimalPlaces: 2},
+	{Code: "MDL", NumericCode: "498", Name: "Moldovan Leu", Symbol: "MDL", DecimalPlaces: 2},
+	{Code: "MGA", NumericCode: "969", Name: "Malagasy Ariary", Symbol: "MGA", DecimalPlaces: 2},
+	{Code: "MKD", NumericCode: "807", Name: "Denar", Symbol: "MKD", DecimalPlaces: 2},
+	{Code: "MMK", NumericCode: "104", Name: "Kyat", Symbol: "MMK", DecimalPlaces: 2},
+	{Code: "MNT", NumericCode: "496", Name: "Tugrik", Symbol: "MNT", DecimalPlaces: 2},
+	{Code: "MOP", NumericCode: "446", Name: "Pataca", Symbol: "MOP", DecimalPlaces: 2},
+	{Code: "MRU", NumericCode: "929", Name: "Ouguiya", Symbol: "MRU", DecimalPlaces: 2},
+	{Code: "MUR", NumericCode: "480", Name: "Mauritius Rupee", Symbol: "MUR", DecimalPlaces: 2},
+	{Code: "MVR", NumericCode: "462", Name: "Rufiyaa", Symbol: "MVR", DecimalPlaces: 2},
+	{Code: "MWK", NumericCode: "454", Name: "Malawi Kwacha", Symbol: "MWK", DecimalPlaces: 2},
+	{Code: "MXN", NumericCode: "484", Name: "Mexican Peso", Symbol: "MX$", DecimalPlaces: 2},
+	{Code: "MXV", NumericCode: "979", Name: "Mexican Unidad de Inversion (UDI)", Symbol: "MXV", DecimalPlaces: 2},
+	{Code: "MYR", NumericCode: "458", Name: "Malaysian Ringgit", Symbol: "MYR", DecimalPlaces: 2},
+	{Code: "MZN", NumericCode: "943", Name: "Mozambique Metical", Symbol: "MZN", DecimalPlaces: 2},
+	{Code: "NAD", NumericCode: "516", Name: "Namibia Dollar", Symbol: "NAD", DecimalPlaces: 2},
+	{Code: "NGN", NumericCode: "566", Name: "Naira", Symbol: "NGN", DecimalPlaces: 2},
+	{Code: "NIO", NumericCode: "558", Name: "Cordoba Oro", Symbol: "NIO", DecimalPlaces: 2},
+	{Code: "NOK", NumericCode: "578", Name: "Norwegian Krone", Symbol: "NOK", DecimalPlaces: 2, CashIncrement: decimal.New(1, 0)},
+	{Code: "NPR", NumericCode: "524", Name: "Nepalese Rupee", Symbol: "NPR", DecimalPlaces: 2},
+	{Code: "NZD", NumericCode: "554", Name: "New Zealand Dollar", Symbol: "NZ$", DecimalPlaces: 2},
+	{Code: "OMR", NumericCode: "512", Name: "Rial Omani", Symbol: "OMR", DecimalPlaces: 3},
+	{Code: "PAB", NumericCode: "590", Name: "Balboa", Symbol: "PAB", DecimalPlaces: 2},
+	{Code: "PEN", NumericCode: "604", Name: "Sol", Symbol: "PEN", DecimalPlaces: 2},
+	{Code: "PGK", NumericCode: "598", Name: "Kina", Symbol: "PGK", DecimalPlaces: 2},
+	{Code: "PHP", NumericCode: "608", Name: "Philippine Peso", Symbol: "PHP", DecimalPlaces: 2},
+	{Code: "PKR", NumericCode: "586", Name: "Pakistan Rupee", Symbol: "PKR", DecimalPlaces: 2},
+	{Code: "PLN", NumericCode: "985", Name: "Zloty", Symbol: "PLN", DecimalPlaces: 2},
+	{Code: "PYG", NumericCode: "600", Name: "Guarani", Symbol: "PYG", DecimalPlaces: 0},
+	{Code: "QAR", NumericCode: "634", Name: "Qatari Rial", Symbol: "QAR", DecimalPlaces: 2},
+	{Code: "RON", NumericCode: "946", Name: "Romanian Leu", Symbol: "RON", DecimalPlaces: 2},
+	{Code: "RSD", NumericCode: "941", Name: "Serbian Dinar", Symbol: "RSD", DecimalPlaces: 2},
+	{Code: "RUB", NumericCode: "643", Name: "Russian Ruble", Symbol: "RUB", DecimalPlaces: 2},
+	{Code: "RWF", NumericCode: "646", Name: "Rwanda Franc", Symbol: "RWF", DecimalPlaces: 0},
+	{Code: "SAR", NumericCode: "682", Name: "Saudi Riyal", Symbol: "SAR", DecimalPlaces: 2},
+	{Code: "SBD", NumericCode: "090", Name: "Solomon Islands Dollar", Symbol: "SBD", DecimalPlaces: 2},
+	{Code: "SCR", NumericCode: "690", Name: "Seychelles Rupee", Symbol: "SCR", DecimalPlaces: 2},
+	{Code: "SDG", NumericCode: "938", Name: "Sudanese Pound", Symbol: "SDG", DecimalPlaces: 2},
+	{Code: "SEK", NumericCode: "752", Name: "Swedish Krona", Symbol: "SEK", DecimalPlaces: 2, CashIncrement: decimal.New(1, 0)},
+	{Code: "SGD", NumericCode: "702", Name: "Singapore Dollar", Symbol: "SGD", DecimalPlaces: 2},
+	{Code: "SHP", NumericCode: "654", Name: "Saint Helena Pound", Symbol: "SHP", DecimalPlaces: 2},
+	{Code: "SLE", NumericCode: "925", Name: "Leone", Symbol: "SLE", DecimalPlaces: 2},
+	{Code: "SOS", NumericCode: "706", Name: "Somali Shilling", Symbol: "SOS", DecimalPlaces: 2},
+	{Code: "SRD", NumericCode: "968", Name: "Surinam Dollar", Symbol: "SRD", DecimalPlaces: 2},
+	{Code: "SSP", NumericCode: "728", Name: "South Sudanese Pound", Symbol: "SSP", DecimalPlaces: 2},
+	{Code: "STN", NumericCode: "930", Name: "Dobra", Symbol: "STN", DecimalPlaces: 2},
+	{Code: "SVC", NumericCode: "222", Name: "El Salvador Colon", Symbol: "SVC", DecimalPlaces: 2},
+	{Code: "SYP", NumericCode: "760", Name: "Syrian Pound", Symbol: "SYP", DecimalPlaces: 2},
+	{Code: "SZL", NumericCode: "748", Name: "Lilangeni", Symbol: "SZL", DecimalPlaces: 2},
+	{Code: "THB", NumericCode: "764", Name: "Baht", Symbol: "THB", DecimalPlaces: 2},
+	{Code: "TJS", NumericCode: "972", Name: "Somoni", Symbol: "TJS", DecimalPlaces: 2},
+	{Code: "TMT", NumericCode: "934", Name: "Turkmenistan New Manat", Symbol: "TMT", DecimalPlaces: 2},
+	{Code: "TND", NumericCode: "788", Name: "Tunisian Dinar", Symbol: "TND", DecimalPlaces: 3},
+	{Code: "TOP", NumericCode: "776", Name: "Pa'anga", Symbol: "TOP", DecimalPlaces: 2},
+	{Code: "TRY", NumericCode: "949", Name: "Turkish Lira", Symbol: "TRY", DecimalPlaces: 2},
+	{Code: "TTD", NumericCode: "780", Name: "Trinidad and Tobago Dollar", Symbol: "TTD", DecimalPlaces: 2},
+	{Code: "TWD", NumericCode: "901", Name: "New Taiwan Dollar", Symbol: "NT$", DecimalPlaces: 2, CashIncrement: decimal.New(1, 0)},
+	{Code: "TZS", NumericCode: "834", Name: "Tanzanian Shilling", Symbol: "TZS", DecimalPlaces: 2},
+	{Code: "UAH", NumericCode: "980", Name: "Hryvnia", Symbol: "UAH", DecimalPlaces: 2},
+	{Code: "UGX", NumericCode: "800", Name: "Uganda Shilling", Symbol: "UGX", DecimalPlaces: 0},
+	{Code: "USD", NumericCode: "840", Name: "US Dollar", Symbol: "$", DecimalPlaces: 2},
+	{Code: "USN", NumericCode: "997", Name: "US Dollar (Next day)", Symbol: "USN", DecimalPlaces: 2},
+	{Code: "UYI", NumericCode: "940", Name: "Uruguay Peso en Unidades Indexadas (UI)", Symbol: "UYI", DecimalPlaces: 0},
+	{Code: "UYU", NumericCode: "858", Name: "Peso Uruguayo", Symbol: "UYU", DecimalPlaces: 2},
+	{Code: "UYW", NumericCode: "927", Name: "Unidad Previsional", Symbol: "UYW", DecimalPlaces: 4},
+	{Code: "UZS", NumericCode: "860", Name: "Uzbekistan Sum", Symbol: "UZS", DecimalPlaces: 2},
+	{Code: "VED", NumericCode: "926", Name: "Bolívar Soberano", Symbol: "VED", DecimalPlaces: 2},
+	{Code: "VES", NumericCode: "928", Name: "Bolívar Soberano", Symbol: "VES", DecimalPlaces: 2},
+	{Code: "VND", NumericCode: "704", Name: "Dong", Symbol: "₫", DecimalPlaces: 0},
+	{Code: "VUV", NumericCode: "548", Name: "Vatu", Symbol: "VUV", DecimalPlaces: 0},
+	{Code: "WST", NumericCode: "882", Name: "Tala", Symbol: "WST", DecimalPlaces: 2},
+	{Code: "XAF", NumericCode: "950", Name: "CFA Franc BEAC", Symbol: "FCFA", DecimalPlaces: 0},
+	{Code: "XAG", NumericCode: "961", Name: "Silver", Symbol: "XAG", DecimalPlaces: 0},
+	{Code: "XAU", NumericCode: "959", Name: "Gold", Symbol: "XAU", DecimalPlaces: 0},
+	{Code: "XBA", NumericCode: "955", Name: "Bond Markets Unit European Composite Unit (EURCO)", Symbol: "XBA", DecimalPlaces: 0},
+	{Code: "XBB", NumericCode: "956", Name: "Bond Markets Unit European Monetary Unit (E.M.U.-6)", Symbol: "XBB", DecimalPlaces: 0},
+	{Code: "XBC", NumericCode: "957", Name: "Bond Markets Unit European Unit of Account 9 (E.U.A.-9)", Symbol: "XBC", DecimalPlaces: 0},
+	{Code: "XBD", NumericCode: "958", Name: "Bond Markets Unit European Unit of Account 17 (E.U.A.-17)", Symbol: "XBD", DecimalPlaces: 0},
+	{Code: "XCD", NumericCode: "951", Name: "East Caribbean Dollar", Symbol: "EC$", DecimalPlaces: 2},
+	{Code: "XCG", NumericCode: "532", Name: "Caribbean Guilder", Symbol: "XCG", DecimalPlaces: 2},
+	{Code: "XDR", NumericCode: "960", Name: "SDR (Special Drawing Right)", Symbol: "XDR", DecimalPlaces: 0},
+	{Code: "XOF", NumericCode: "952", Name: "CFA Franc BCEAO", Symbol: "CFA", DecimalPlaces: 0},
+	{Code: "XPD", NumericCode: "964", Name: "Palladium", Symbol: "XPD", DecimalPlaces: 0},
+	{Code: "XPF", NumericCode: "953", Name: "CFP Franc", Symbol: "CFPF", DecimalPlaces: 0},
+	{Code: "XPT", NumericCode: "962", Name: "Platinum", Symbol: "XPT", DecimalPlaces: 0},
+	{Code: "XSU", NumericCode: "994", Name: "Sucre", Symbol: "XSU", DecimalPlaces: 0},
+	{Code: "XTS", NumericCode: "963", Name: "Codes specifically reserved for testing purposes", Symbol: "XTS", DecimalPlaces: 0},
+	{Code: "XUA", NumericCode: "965", Name: "ADB Unit of Account", Symbol: "XUA", DecimalPlaces: 0},
+	{Code: "XXX", NumericCode: "999", Name: "The codes assigned for transactions where no currency is involved", Symbol: "XXX", DecimalPlaces: 0},
+	{Code: "YER", NumericCode: "886", Name: "Yemeni Rial", Symbol: "YER", DecimalPlaces: 2},
+	{Code: "ZAR", NumericCode: "710", Name: "Rand", Symbol: "ZAR", DecimalPlaces: 2},
+	{Code: "ZMW", NumericCode: "967", Name: "Zambian Kwacha", Symbol: "ZMW", DecimalPlaces: 2},
+	{Code: "ZWG", NumericCode: "924", Name: "Zimbabwe Gold", Symbol: "ZWG", DecimalPlaces: 2},
+	{Code: "ADP", NumericCode: "020", Name: "Andorran Peseta", Symbol: "ADP", DecimalPlaces: 0, Withdrawn: "2003-07"},
+	{Code: "ANG", NumericCode: "532", Name: "Netherlands Antillean Guilder", Symbol: "ANG", DecimalPlaces: 2, Withdrawn: "2025-03"},
+	{Code: "ATS", NumericCode: "040", Name: "Schilling", Symbol: "ATS", DecimalPlaces: 2, Withdrawn: "2002-03"},
+	{Code: "AZM", NumericCode: "031", Name: "Azerbaijanian Manat", Symbol: "AZM", DecimalPlaces: 2, Withdrawn: "2005-12"},
+	{Code: "BEF", NumericCode: "056", Name: "Belgian Franc", Symbol: "BEF", DecimalPlaces: 0, Withdrawn: "2002-03"},
+	{Code: "BGN", NumericCode: "975", Name: "Bulgarian Lev", Symbol: "BGN", DecimalPlaces: 2, Withdrawn: "2026-01"},
+	{Code: "BYR", NumericCode: "974", Name: "Belarusian Ruble", Symbol: "BYR", DecimalPlaces: 0, Withdrawn: "2017-01"},
+	{Code: "CSD", NumericCode: "891", Name: "Serbian Dinar", Symbol: "CSD", DecimalPlaces: 2, Withdrawn: "2006-10"},
+	{Code: "CYP", NumericCode: "196", Name: "Cyprus Pound", Symbol: "CYP", DecimalPlaces: 2, Withdrawn: "2008-01"},
+	{Code: "DEM", NumericCode: "276", Name: "Deutsche Mark", Symbol: "DEM", DecimalPlaces: 2, Withdrawn: "2002-03"},
+	{Code: "EEK", NumericCode: "233", Name: "Kroon", Symbol: "EEK", DecimalPlaces: 2, Withdrawn: "2011-01"},
+	{Code: "ESP", NumericCode: "724", Name: "Spanish Peseta", Symbol: "ESP", DecimalPlaces: 0, Withdrawn: "2002-03"},
+	{Code: "FIM", NumericCode: "246", Name: "Markka", Symbol: "FIM", DecimalPlaces: 2, Withdrawn: "2002-03"},
+	{Code: "FRF", NumericCode: "250", Name: "French Franc", Symbol: "FRF", DecimalPlaces: 2, Withdrawn: "2002-03"},
+	{Code: "GHC", NumericCode: "288", Name: "Cedi", Symbol: "GHC", DecimalPlaces: 2, Withdrawn: "2008-01"},
+	{Code: "GRD", NumericCode: "300", Name: "Drachma", Symbol: "GRD", DecimalPlaces: 0, Withdrawn: "2002-03"},
+	{Code: "HRK", NumericCode: "191", Name: "Kuna", Symbol: "HRK", DecimalPlaces: 2, Withdrawn: "2023-01"},
+	{Code: "IEP", NumericCode: "372", Name: "Irish Pound", Symbol: "IEP", DecimalPlaces: 2, Withdrawn: "2002-03"},
+	{Code: "ITL", NumericCode: "380", Name: "Italian Lira", Symbol: "ITL", DecimalPlaces: 0, Withdrawn: "2002-03"},
+	{Code: "LTL", NumericCode: "440", Name: "Lithuanian Litas", Symbol: "LTL", DecimalPlaces: 2, Withdrawn: "2015-01"},
+	{Code: "LUF", NumericCode: "442", Name: "Luxembourg Franc", Symbol: "LUF", DecimalPlaces: 0, Withdrawn: "2002-03"},
+	{Code: "LVL", NumericCode: "428", Name: "Latvian Lats", Symbol: "LVL", DecimalPlaces: 2, Withdrawn: "2014-01"},
+	{Code: "MGF", NumericCode: "450", Name: "Malagasy Franc", Symbol: "MGF", DecimalPlaces: 0, Withdrawn: "2004-12"},
+	{Code: "MRO", NumericCode: "478", Name: "Ouguiya", Symbol: "MRO", DecimalPlaces: 2, Withdrawn: "2017-12"},
+	{Code: "MTL", NumericCode: "470", Name: "Maltese Lira", Symbol: "MTL", DecimalPlaces: 2, Withdrawn: "2008-01"},
+	{Code: "MZM", NumericCode: "508", Name: "Mozambique Metical", Symbol: "MZM", DecimalPlaces: 2, Withdrawn: "2006-06"},
+	{Code: "NLG", NumericCode: "528", Name: "Netherlands Guilder", Symbol: "NLG", DecimalPlaces: 2, Withdrawn: "2002-03"},
+	{Code: "PTE", NumericCode: "620", Name: "Portuguese Escudo", Symbol: "PTE", DecimalPlaces: 0, Withdrawn: "2002-03"},
+	{Code: "ROL", NumericCode: "642", Name: "Leu", Symbol: "ROL", DecimalPlaces: 2, Withdrawn: "2005-06"},
+	{Code: "SDD", NumericCode: "736", Name: "Sudanese Dinar", Symbol: "SDD", DecimalPlaces: 2, Withdrawn: "2007-07"},
+	{Code: "SIT", NumericCode: "705", Name: "Tolar", Symbol: "SIT", DecimalPlaces: 2, Withdrawn: "2007-01"},
+	{Code: "SKK", NumericCode: "703", Name: "Slovak Koruna", Symbol: "SKK", DecimalPlaces: 2, Withdrawn: "2009-01"},
+	{Code: "SLL", NumericCode: "694", Name: "Leone", Symbol: "SLL", DecimalPlaces: 2, Withdrawn: "2023-12"},
+	{Code: "SRG", NumericCode: "740", Name: "Surinam Guilder", Symbol: "SRG", DecimalPlaces: 2, Withdrawn: "2004-01"},
+	{Code: "STD", NumericCode: "678", Name: "Dobra", Symbol: "STD", DecimalPlaces: 2, Withdrawn: "2017-12"},
+	{Code: "TMM", NumericCode: "795", Name: "Turkmenistan Manat", Symbol: "TMM", DecimalPlaces: 2, Withdrawn: "2009-01"},
+	{Code: "TRL", NumericCode: "792", Name: "Old Turkish Lira", Symbol: "TRL", DecimalPlaces: 0, Withdrawn: "2005-01"},
+	{Code: "VEB", NumericCode: "862", Name: "Bolivar", Symbol: "VEB", DecimalPlaces: 2, Withdrawn: "2008-01"},
+	{Code: "VEF", NumericCode: "937", Name: "Bolivar", Symbol: "VEF", DecimalPlaces: 2, Withdrawn: "2018-08"},
+	{Code: "XEU", NumericCode: "954", Name: "European Currency Unit (E.C.U)", Symbol: "XEU", DecimalPlaces: 0, Withdrawn: "1999-01"},
+	{Code: "ZMK", NumericCode: "894", Name: "Zambian Kwacha", Symbol: "ZMK", DecimalPlaces: 2, Withdrawn: "2012-12"},
+	{Code: "ZWD", NumericCode: "716", Name: "Zimbabwe Dollar", Symbol: "ZWD", DecimalPlaces: 2, Withdrawn: "2008-08"},
+	{Code: "ZWL", NumericCode: "932", Name: "Zimbabwe Dollar", Symbol: "ZWL", DecimalPlaces: 2, Withdrawn: "2024-09"},
+	{Code: "ZWN", NumericCode: "942", Name: "Zimbabwe Dollar (new)", Symbol: "ZWN", DecimalPlaces: 2, Withdrawn: "2008-08"},
+	{Code: "ZWR", NumericCode: "935", Name: "Zimbabwe Dollar", Symbol: "ZWR", DecimalPlaces: 2, Withdrawn: "2009-06"},
+}
@@ -0,0 +1,297 @@
+package currency
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+	xcurrency "golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// localePattern holds the CLDR currency patterns of a locale, where "¤"
+// stands for the currency symbol and "n" for the formatted number. A
+// pattern may contain a negative subpattern after ";"; without one the
+// minus sign is prefixed to the positive pattern. Spaces are rendered as
+// no-break spaces.
+type localePattern struct {
+	standard   string
+	accounting string
+}
+
+// localePatterns lists the currency patterns by locale. Locales are looked
+// up by language and region, then by language, and fall back to "en".
+var localePatterns = map[string]localePattern{
+	"en":     {"¤n", "¤n;(¤n)"},
+	"de":     {"n ¤", "n ¤"},
+	"de-AT":  {"¤ n", "¤ n"},
+	"de-CH":  {"¤ n;¤-n", "¤ n;¤-n"},
+	"de-LI":  {"¤ n;¤-n", "¤ n;¤-n"},
+	"fr":     {"n ¤", "n ¤;(n ¤)"},
+	"it":     {"n ¤", "n ¤"},
+	"it-CH":  {"¤ n;¤-n", "¤ n;¤-n"},
+	"es":     {"n ¤", "n ¤"},
+	"es-MX":  {"¤n", "¤n"},
+	"es-US":  {"¤n", "¤n"},
+	"es-419": {"¤n", "¤n"},
+	"pt":     {"¤ n", "¤ n"},
+	"pt-PT":  {"n ¤", "n ¤;(n ¤)"},
+	"nl":     {"¤ n;¤ -n", "¤ n;(¤ n)"},
+	"ja":     {"¤n", "¤n;(¤n)"},
+	"zh":     {"¤n", "¤n;(¤n)"},
+	"ko":     {"¤n", "¤n;(¤n)"},
+	"hi":     {"¤n", "¤n"},
+	"tr":     {"¤n", "¤n;(¤n)"},
+	"sv":     {"n ¤", "n ¤"},
+	"nb":     {"n ¤", "n ¤;(n ¤)"},
+	"no":     {"n ¤", "n ¤;(n ¤)"},
+	"da":     {"n ¤", "n ¤"},
+	"fi":     {"n ¤", "n ¤"},
+	"pl":     {"n ¤", "n ¤;(n ¤)"},
+	"cs":     {"n ¤", "n ¤"},
+	"sk":     {"n ¤", "n ¤;(n ¤)"},
+	"hu":     {"n ¤", "n ¤"},
+	"ro":     {"n ¤", "n ¤;(n ¤)"},
+	"bg":     {"n ¤", "n ¤"},
+	"hr":     {"n ¤", "n ¤"},
+	"sl":     {"n ¤", "n ¤;(n ¤)"},
+	"lt":     {"n ¤", "n ¤"},
+	"lv":     {"n ¤", "n ¤"},
+	"et":     {"n ¤", "n ¤;(n ¤)"},
+	"el":     {"n ¤", "n ¤"},
+	"ru":     {"n ¤", "n ¤"},
+	"uk":     {"n ¤", "n ¤"},
+}
+
+// numberSymbols holds the number formatting data of a locale, derived from
+// the CLDR data in golang.org/x/text.
+type numberSymbols struct {
+	minus     string
+	decimal   string
+	group     string
+	primary   int
+	secondary int
+	zero      rune
+}
+
+var numberSymbolsCache sync.Map
+
+// symbolsFor formats a probe number with the locale's printer and derives
+// the minus sign, separators, grouping sizes and digits from the output.
+func symbolsFor(tag language.Tag) numberSymbols {
+	if s, ok := numberSymbolsCache.Load(tag); ok {
+		return s.(numberSymbols)
+	}
+	probe := message.NewPrinter(tag).Sprint(number.Decimal(-12345678.5, number.Scale(1)))
+	s := numberSymbols{minus: "-", decimal: ".", group: ",", primary: 3, secondary: 3, zero: '0'}
+
+	var runs []int
+	var seps []string
+	var sep strings.Builder
+	digits := 0
+	for i, r := range probe {
+		if unicode.IsDigit(r) {
+			if len(runs) == 0 && digits == 0 {
+				if i > 0 {
+					s.minus = probe[:i]
+				}
+				s.zero = r - 1
+			}
+			if sep.Len() > 0 {
+				runs = append(runs, digits)
+				seps = append(seps, sep.String())
+				sep.Reset()
+				digits = 0
+			}
+			digits++
+		} else if len(runs) > 0 || digits > 0 {
+			sep.WriteRune(r)
+		}
+	}
+	switch {
+	case len(runs) >= 3:
+		s.decimal = seps[len(seps)-1]
+		s.group = seps[0]
+		s.primary = runs[len(runs)-1]
+		s.secondary = runs[len(runs)-2]
+	case len(runs) == 1:
+		s.decimal = seps[0]
+		s.group = ""
+		s.primary, s.secondary = 0, 0
+	}
+	numberSymbolsCache.Store(tag, s)
+	return s
+}
+
+// LocaleFormatter formats monetary amounts for a locale and currency. The
+// separators, digit grouping and minus sign follow the locale's CLDR data
+// and the currency determines the symbol and number of decimal places.
+type LocaleFormatter struct {
+	currency   Currency
+	symbol     string
+	symbols    numberSymbols
+	pattern    localePattern
+	accounting bool
+}
+
+// NewLocaleFormatter creates a formatter for a BCP 47 locale such as
+// "en-IE", "de-CH" or "en-IN" and a currency code.
+func NewLocaleFormatter(locale, currencyCode string) (*LocaleFormatter, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+	c, ok := Get(currencyCode)
+	if !ok {
+		return nil, fmt.Errorf("unknown currency: %s", currencyCode)
+	}
+	f := &LocaleFormatter{
+		currency: c,
+		symbol:   c.Symbol,
+		symbols:  symbolsFor(tag),
+		pattern:  patternFor(tag),
+	}
+	if u, err := xcurrency.ParseISO(c.Code); err == nil {
+		f.symbol = message.NewPrinter(tag).Sprint(xcurrency.Symbol(u))
+	}
+	return f, nil
+}
+
+func patternFor(tag language.Tag) localePattern {
+	base, _ := tag.Base()
+	region, _ := tag.Region()
+	for _, key := range []string{base.String() + "-" + region.String(), base.String()} {
+		if p, ok := localePatterns[key]; ok {
+			return p
+		}
+	}
+	return localePatterns["en"]
+}
+
+// Accounting returns a copy of the formatter that uses the locale's
+// accounting style, e.g. "(€1,234.56)" for negative amounts in English.
+func (f *LocaleFormatter) Accounting() *LocaleFormatter {
+	c := *f
+	c.accounting = true
+	return &c
+}
+
+// Format formats a decimal amount according to the locale's rules.
+func (f *LocaleFormatter) Format(amount decimal.Decimal) string {
+	rounded := amount.Round(f.currency.DecimalPlaces)
+	isNegative := rounded.IsNegative()
+
+	pattern := f.pattern.standard
+	if f.accounting {
+		pattern = f.pattern.accounting
+	}
+	positive, negative, hasNegative := strings.Cut(pattern, ";")
+	if !isNegative {
+		return f.apply(positive, rounded)
+	}
+	if hasNegative {
+		return f.apply(negative, rounded.Abs())
+	}
+	return f.symbols.minus + f.apply(positive, rounded.Abs())
+}
+
+// FormatNumber formats an amount without currency symbol.
+func (f *LocaleFormatter) FormatNumber(amount decimal.Decimal) string {
+	rounded := amount.Round(f.currency.DecimalPlaces)
+	if rounded.IsNegative() {
+		return f.symbols.minus + f.number(rounded.Abs())
+	}
+	return f.number(rounded)
+}
+
+// apply substitutes the symbol and number into a pattern. A no-break space
+// separates the symbol from the number when the adjacent character of the
+// symbol is a letter, as in "CHF 1,234.56".
+func (f *LocaleFormatter) apply(pattern string, amount decimal.Decimal) string {
+	var b strings.Builder
+	runes := []rune(pattern)
+	for i, r := range runes {
+		switch r {
+		case '¤':
+			if i > 0 && runes[i-1] == 'n' && startsWithLetter(f.symbol) {
+				b.WriteRune('\u00a0')
+			}
+			b.WriteString(f.symbol)
+			if i+1 < len(runes) && runes[i+1] == 'n' && endsWithLetter(f.symbol) {
+				b.WriteRune('\u00a0')
+			}
+		case 'n':
+			b.WriteString(f.number(amount))
+		case ' ':
+			b.WriteRune('\u00a0')
+		case '-':
+			b.WriteString(f.symbols.minus)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// number formats a non-negative amount with the locale's separators,
+// grouping and digits.
+func (f *LocaleFormatter) number(amount decimal.Decimal) string {
+	str := amount.StringFixed(f.currency.DecimalPlaces)
+	intPart, fracPart, _ := strings.Cut(str, ".")
+
+	var groups []string
+	if f.symbols.primary > 0 && len(intPart) > f.symbols.primary {
+		groups = append(groups, intPart[len(intPart)-f.symbols.primary:])
+		intPart = intPart[:len(intPart)-f.symbols.primary]
+	}
+	for f.symbols.secondary > 0 && len(intPart) > f.symbols.secondary {
+		groups = append(groups, intPart[len(intPart)-f.symbols.secondary:])
+		intPart = intPart[:len(intPart)-f.symbols.secondary]
+	}
+	var b strings.Builder
+	b.WriteString(f.digits(intPart))
+	for i := len(groups) - 1; i >= 0; i-- {
+		b.WriteString(f.symbols.group)
+		b.WriteString(f.digits(groups[i]))
+	}
+	if fracPart != "" {
+		b.WriteString(f.symbols.decimal)
+		b.WriteString(f.digits(fracPart))
+	}
+	return b.String()
+}
+
+// digits maps ASCII digits to the locale's digits.
+func (f *LocaleFormatter) digits(s string) string {
+	if f.symbols.zero == '0' {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		return f.symbols.zero + (r - '0')
+	}, s)
+}
+
+func startsWithLetter(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r)
+}
+
+func endsWithLetter(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return unicode.IsLetter(r)
+}
+
+// FormatLocale formats an amount for the given currency code and locale.
+// It falls back to FormatSimple if the locale or currency is unknown.
+func FormatLocale(amount decimal.Decimal, currencyCode, locale string) string {
+	f, err := NewLocaleFormatter(locale, currencyCode)
+	if err != nil {
+		return FormatSimple(amount, currencyCode)
+	}
+	return f.Format(amount)
+}
@@ -0,0 +1,426 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+	xcurrency "golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// Parse errors. Returned errors wrap one of these with an explanation.
+var (
+	ErrEmptyAmount     = errors.New("amount is empty")
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrAmbiguousAmount = errors.New("ambiguous amount")
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrMissingCurrency = errors.New("currency is missing")
+)
+
+// magnitudeSuffixes are shorthand suffixes that are rejected rather than
+// silently misread.
+var magnitudeSuffixes = map[string]bool{
+	"K": true, "M": true, "MN": true, "MM": true, "B": true, "BN": true,
+}
+
+// Parser parses formatted monetary amounts such as "-CHF 1'234.50",
+// "(1,234.56)" or "1 234,56 €" into Money values, using the grouping and
+// decimal conventions of a locale to resolve inputs like "1.234".
+type Parser struct {
+	tag             language.Tag
+	symbols         numberSymbols
+	defaultCurrency string
+}
+
+// NewParser creates a parser for a BCP 47 locale. The default currency is
+// used when the input has no currency code or symbol, and to resolve
+// symbols shared by several currencies such as "$"; it may be empty.
+func NewParser(locale, defaultCurrency string) (*Parser, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+	if defaultCurrency != "" {
+		c, ok := Get(defaultCurrency)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownCurrency, defaultCurrency)
+		}
+		defaultCurrency = c.Code
+	}
+	return &Parser{tag: tag, symbols: symbolsFor(tag), defaultCurrency: defaultCurrency}, nil
+}
+
+// ParseMoney parses an amount using the conventions of the given locale.
+func ParseMoney(s, locale, defaultCurrency string) (invoice.Money, error) {
+	p, err := NewParser(locale, defaultCurrency)
+	if err != nil {
+		return invoice.Money{}, err
+	}
+	return p.Parse(s)
+}
+
+// Parse parses a formatted amount. A leading or trailing minus sign or
+// enclosing parentheses mark negative amounts. The currency may be given
+// as an ISO code or a symbol before or after the number.
+func (p *Parser) Parse(s string) (invoice.Money, error) {
+	input := s
+	s = p.normalize(s)
+	if s == "" {
+		return invoice.Money{}, ErrEmptyAmount
+	}
+
+	negative := false
+	if opening, closing := strings.Count(s, "("), strings.Count(s, ")"); opening > 0 || closing > 0 {
+		i, j := strings.Index(s, "("), strings.Index(s, ")")
+		if opening != 1 || closing != 1 || j < i ||
+			strings.IndexFunc(s[:i], p.isDigit) >= 0 || strings.IndexFunc(s[j:], p.isDigit) >= 0 {
+			return invoice.Money{}, fmt.Errorf("%w: unbalanced parentheses in %q", ErrInvalidAmount, input)
+		}
+		negative = true
+		s = s[:i] + " " + s[i+1:j] + " " + s[j+1:]
+	}
+
+	first := strings.IndexFunc(s, p.isDigit)
+	last := strings.LastIndexFunc(s, p.isDigit)
+	if first < 0 {
+		return invoice.Money{}, fmt.Errorf("%w: no digits in %q", ErrInvalidAmount, input)
+	}
+	// A leading decimal separator belongs to the number, as in ".50".
+	if first > 0 && (s[first-1] == '.' || s[first-1] == ',') {
+		first--
+	}
+	_, size := utf8.DecodeRuneInString(s[last:])
+	prefix, core, suffix := s[:first], s[first:last+size], s[last+size:]
+
+	affix, sign, err := stripSign(prefix, suffix)
+	if err != nil {
+		return invoice.Money{}, fmt.Errorf("%w: %s in %q", ErrInvalidAmount, err, input)
+	}
+	if sign != 0 && negative {
+		return invoice.Money{}, fmt.Errorf("%w: both sign and parentheses in %q", ErrAmbiguousAmount, input)
+	}
+	if sign < 0 {
+		negative = true
+	}
+
+	code, err := p.currencyOf(affix, suffix)
+	if err != nil {
+		return invoice.Money{}, fmt.Errorf("%w in %q", err, input)
+	}
+	c, _ := Get(code)
+
+	digits, err := p.parseNumber(core, c)
+	if err != nil {
+		return invoice.Money{}, fmt.Errorf("%w in %q", err, input)
+	}
+	amount, err := decimal.NewFromString(digits)
+	if err != nil {
+		return invoice.Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+	}
+	if negative {
+		amount = amount.Neg()
+	}
+	return invoice.Money{Amount: amount, Currency: code}, nil
+}
+
+// normalize maps space and minus variants to ASCII and drops bidi marks.
+func (p *Parser) normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '\u00a0', '\u202f', '\u2009', '\u2007':
+			return ' '
+		case '\u2212', '\u2012', '\u2013', '\ufe63', '\uff0d':
+			return '-'
+		case '\u200e', '\u200f', '\u061c':
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+func (p *Parser) isDigit(r rune) bool {
+	_, ok := p.digitValue(r)
+	return ok
+}
+
+// digitZeros lists the zero digits of the numbering systems accepted in
+// addition to the locale's own digits: Latin, Arabic-Indic, Extended
+// Arabic-Indic, Devanagari and fullwidth.
+var digitZeros = []rune{'0', '\u0660', '\u06f0', '\u0966', '\uff10'}
+
+// digitValue returns the value of a decimal digit.
+func (p *Parser) digitValue(r rune) (rune, bool) {
+	for _, zero := range append(digitZeros, p.symbols.zero) {
+		if r >= zero && r <= zero+9 {
+			return r - zero, true
+		}
+	}
+	return 0, false
+}
+
+// stripSign removes a single "+" or "-" from the text around the number
+// and returns the remaining text with spaces removed.
+func stripSign(prefix, suffix string) (string, int, error) {
+	affix := strings.ReplaceAll(prefix+" "+suffix, " ", "")
+	minus := strings.Count(affix, "-")
+	plus := strings.Count(affix, "+")
+	switch {
+	case minus+plus > 1:
+		return "", 0, errors.New("more than one sign")
+	case minus == 1:
+		return strings.Replace(affix, "-", "", 1), -1, nil
+	case plus == 1:
+		return strings.Replace(affix, "+", "", 1), 1, nil
+	}
+	return affix, 0, nil
+}
+
+// currencyOf resolves the currency code or symbol around the number.
+func (p *Parser) currencyOf(affix, suffix string) (string, error) {
+	if affix == "" {
+		if p.defaultCurrency == "" {
+			return "", ErrMissingCurrency
+		}
+		return p.defaultCurrency, nil
+	}
+	upper := strings.ToUpper(affix)
+	if magnitudeSuffixes[upper] || magnitudeSuffixes[strings.ToUpper(strings.TrimSpace(suffix))] {
+		return "", fmt.Errorf("%w: magnitude suffixes such as %q are not supported", ErrInvalidAmount, strings.TrimSpace(suffix))
+	}
+	if len(upper) == 3 && isLetters(upper) {
+		if c, ok := Get(upper); ok {
+			return c.Code, nil
+		}
+		return "", fmt.Errorf("%w: %s", ErrUnknownCurrency, affix)
+	}
+
+	candidates := p.symbolCandidates(affix)
+	switch {
+	case len(candidates) == 0:
+		if isLetters(upper) {
+			for suf := range magnitudeSuffixes {
+				if strings.HasSuffix(upper, suf) {
+					return "", fmt.Errorf("%w: unexpected %q; magnitude suffixes are not supported", ErrInvalidAmount, affix)
+				}
+			}
+		}
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, affix)
+	case len(candidates) == 1:
+		return candidates[0], nil
+	}
+	for _, code := range candidates {
+		if code == p.defaultCurrency {
+			return code, nil
+		}
+	}
+	if u, conf := xcurrency.FromTag(p.tag); conf != language.No {
+		for _, code := range candidates {
+			if code == u.String() {
+				return code, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: symbol %q may denote %s; use an ISO currency code",
+		ErrAmbiguousAmount, affix, strings.Join(candidates, ", "))
+}
+
+// symbolCandidates returns the current currencies whose symbol, locale
+// symbol or narrow symbol matches s.
+func (p *Parser) symbolCandidates(s string) []string {
+	printer := message.NewPrinter(p.tag)
+	seen := make(map[string]bool)
+	mu.RLock()
+	defer mu.RUnlock()
+	for code, c := range currencies {
+		if c.IsWithdrawn() {
+			continue
+		}
+		if c.Symbol == s {
+			seen[code] = true
+			continue
+		}
+		if u, err := xcurrency.ParseISO(code); err == nil {
+			if printer.Sprint(xcurrency.Symbol(u)) == s || printer.Sprint(xcurrency.NarrowSymbol(u)) == s {
+				seen[code] = true
+			}
+		}
+	}
+	codes := make([]string, 0, len(seen))
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// separator is a decimal or grouping separator found in an amount, with
+// the number of digits preceding it.
+type separator struct {
+	r  rune
+	at int
+}
+
+// Separator kinds other than '.' and ',', which may be either.
+const (
+	groupSep   = '\''
+	decimalSep = 'd'
+)
+
+// parseNumber converts the digits and separators of an amount into a plain
+// decimal string, deciding which separator is the decimal mark.
+func (p *Parser) parseNumber(core string, c Currency) (string, error) {
+	var digits strings.Builder
+	var seps []separator
+	n := 0
+	for _, r := range core {
+		switch {
+		case p.isDigit(r):
+			v, _ := p.digitValue(r)
+			digits.WriteRune('0' + v)
+			n++
+		case r == '.' || r == ',':
+			seps = append(seps, separator{r, n})
+		case string(r) == p.symbols.decimal, r == '\u066b':
+			seps = append(seps, separator{decimalSep, n})
+		case string(r) == p.symbols.group, r == '\u066c', r == '\'', r == '\u2019', r == ' ':
+			seps = append(seps, separator{groupSep, n})
+		default:
+			return "", fmt.Errorf("%w: unexpected character %q", ErrInvalidAmount, r)
+		}
+	}
+
+	decimalAt, err := p.decimalIndex(seps, n, c)
+	if err != nil {
+		return "", err
+	}
+	str := digits.String()
+	intDigits, fracDigits, groups := str, "", seps
+	if decimalAt >= 0 {
+		at := seps[decimalAt].at
+		intDigits, fracDigits, groups = str[:at], str[at:], seps[:decimalAt]
+		if fracDigits == "" {
+			return "", fmt.Errorf("%w: no digits after the decimal separator", ErrInvalidAmount)
+		}
+	}
+	if err := p.checkGrouping(groups, len(intDigits)); err != nil {
+		return "", err
+	}
+	if intDigits == "" {
+		intDigits = "0"
+	}
+	if fracDigits == "" {
+		return intDigits, nil
+	}
+	return intDigits + "." + fracDigits, nil
+}
+
+// decimalIndex returns the index of the decimal separator in seps, or -1
+// if all separators group digits. With both '.' and ',' present the last
+// one is the decimal mark; a repeated separator always groups digits.
+func (p *Parser) decimalIndex(seps []separator, digits int, c Currency) (int, error) {
+	if len(seps) == 0 {
+		return -1, nil
+	}
+	last := len(seps) - 1
+	for i, sep := range seps {
+		if sep.r == decimalSep && i != last {
+			return 0, fmt.Errorf("%w: decimal separator followed by more separators", ErrInvalidAmount)
+		}
+	}
+	r := seps[last].r
+	switch r {
+	case decimalSep:
+		return last, nil
+	case groupSep:
+		return -1, nil
+	}
+
+	count, other, grouped := 0, false, false
+	for _, sep := range seps {
+		switch sep.r {
+		case r:
+			count++
+		case groupSep:
+			grouped = true
+		default:
+			other = true
+		}
+	}
+	switch {
+	case count > 1:
+		return -1, nil
+	case other, grouped, digits-seps[last].at != 3:
+		return last, nil
+	}
+
+	// A single '.' or ',' followed by three digits, as in "1,234", is read
+	// according to the locale.
+	s := string(r)
+	switch {
+	case s == p.symbols.decimal && c.DecimalPlaces >= 3:
+		return last, nil
+	case s == p.symbols.group:
+		return -1, nil
+	case s == p.symbols.decimal:
+		return 0, fmt.Errorf("%w: %q may be a decimal or grouping separator and %s has %d decimal places",
+			ErrAmbiguousAmount, s, c.Code, c.DecimalPlaces)
+	}
+	return 0, fmt.Errorf("%w: %q is neither the decimal nor the grouping separator in %s",
+		ErrAmbiguousAmount, s, p.tag)
+}
+
+// checkGrouping verifies that grouping separators are used consistently and
+// split the integer digits into groups of three, or of the locale's
+// secondary grouping size before the last group, as in "12,34,567".
+func (p *Parser) checkGrouping(groups []separator, intLen int) error {
+	if len(groups) == 0 {
+		return nil
+	}
+	for _, g := range groups[1:] {
+		if g.r != groups[0].r {
+			return fmt.Errorf("%w: mixed grouping separators", ErrInvalidAmount)
+		}
+	}
+	bounds := make([]int, 0, len(groups)+1)
+	for _, g := range groups {
+		bounds = append(bounds, g.at)
+	}
+	bounds = append(bounds, intLen)
+
+	if bounds[0] == 0 || intLen-bounds[len(bounds)-2] != 3 {
+		return fmt.Errorf("%w: invalid digit grouping", ErrInvalidAmount)
+	}
+	valid := func(size int) bool {
+		if bounds[0] > size {
+			return false
+		}
+		for i := 1; i < len(bounds)-1; i++ {
+			if bounds[i]-bounds[i-1] != size {
+				return false
+			}
+		}
+		return true
+	}
+	if valid(3) || (p.symbols.secondary != 3 && valid(p.symbols.secondary)) {
+		return nil
+	}
+	return fmt.Errorf("%w: invalid digit grouping", ErrInvalidAmount)
+}
@@ -0,0 +1,35 @@
+package currency
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// countryCashIncrements lists national cash rounding rules for euro
+// countries that round cash totals to 0.05 although 1 and 2 cent coins
+// remain legal tender.
+var countryCashIncrements = map[string]decimal.Decimal{
+	"BE": decimal.New(5, -2),
+	"FI": decimal.New(5, -2),
+	"IE": decimal.New(5, -2),
+	"IT": decimal.New(5, -2),
+	"NL": decimal.New(5, -2),
+	"SK": decimal.New(5, -2),
+}
+
+// CashIncrement returns the increment cash totals are rounded to for a
+// currency in a country, e.g. 0.05 for CHF or for EUR in the Netherlands
+// and 1 for SEK. It returns zero if amounts are not rounded beyond the
+// currency's minor unit. The country code may be empty.
+func CashIncrement(currencyCode, countryCode string) decimal.Decimal {
+	code := strings.ToUpper(currencyCode)
+	if code == "EUR" {
+		return countryCashIncrements[strings.ToUpper(countryCode)]
+	}
+	c, ok := Get(code)
+	if !ok {
+		return decimal.Zero
+	}
+	return c.CashIncrement
+}
@@ -0,0 +1,724 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/language"
+)
+
+// ErrUnsupportedLanguage is returned by Spell for locales whose language
+// has no number words.
+var ErrUnsupportedLanguage = errors.New("unsupported language for amount in words")
+
+// maxSpellable is the largest integer amount Spell writes out.
+const maxSpellable = 999_999_999_999_999
+
+// unitName holds the singular and plural name of a currency unit and
+// whether it is grammatically feminine.
+type unitName struct {
+	one, other string
+	feminine   bool
+}
+
+func (u unitName) form(n int64) string {
+	if n == 1 {
+		return u.one
+	}
+	return u.other
+}
+
+// unitNames holds the major and minor unit names of a currency. A zero
+// minor unit name writes the minor amount as a fraction, e.g. "50/100".
+type unitNames struct {
+	major, minor unitName
+}
+
+// speller writes numbers and amounts in one language.
+type speller struct {
+	// number spells a non-negative integer; feminine selects the feminine
+	// form of "one" where the language distinguishes it.
+	number func(n int64, feminine bool) string
+	// of is inserted between exact millions and the unit name, e.g. "de".
+	of    func(n int64, unit string) string
+	and   string
+	minus string
+	only  string
+	units map[string]unitNames
+}
+
+// Spell writes an amount in words in the language of a BCP 47 locale, as
+// printed on cheques and required on invoices in some countries, e.g.
+// "one thousand two hundred thirty-four euros and fifty cents". Supported
+// languages are English, German, French, Italian and Spanish; "en-IN" and
+// "hi" use English words with the Indian lakh and crore scale. Unit names
+// not known for a language fall back to the currency table.
+func Spell(amount decimal.Decimal, currencyCode, locale string) (string, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+	c, ok := Get(currencyCode)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCurrency, currencyCode)
+	}
+	sp, err := spellerFor(tag)
+	if err != nil {
+		return "", err
+	}
+
+	rounded := amount.Round(c.DecimalPlaces)
+	negative := rounded.IsNegative()
+	rounded = rounded.Abs()
+	major := rounded.Truncate(0)
+	if major.GreaterThan(decimal.NewFromInt(maxSpellable)) {
+		return "", fmt.Errorf("amount %s is too large to write in words", amount.String())
+	}
+	n := major.IntPart()
+	minor := rounded.Sub(major).Shift(c.DecimalPlaces).IntPart()
+
+	names, ok := sp.units[c.Code]
+	if !ok {
+		names = fallbackUnits(c, sp)
+	}
+
+	var b strings.Builder
+	if negative {
+		b.WriteString(sp.minus + " ")
+	}
+	b.WriteString(sp.number(n, names.major.feminine))
+	b.WriteString(sp.of(n, names.major.form(n)))
+	b.WriteString(names.major.form(n))
+	if minor > 0 {
+		b.WriteString(" " + sp.and + " ")
+		if names.minor.one == "" {
+			fmt.Fprintf(&b, "%d/%s", minor, decimal.New(1, c.DecimalPlaces).String())
+		} else {
+			b.WriteString(sp.number(minor, names.minor.feminine) + " " + names.minor.form(minor))
+		}
+	}
+	if sp.only != "" {
+		b.WriteString(" " + sp.only)
+	}
+	return b.String(), nil
+}
+
+// fallbackUnits uses the ISO name for English and the currency code for
+// other languages, with minor amounts as fractions.
+func fallbackUnits(c Currency, sp *speller) unitNames {
+	name := c.Code
+	if sp.and == "and" {
+		name = c.Name
+	}
+	return unitNames{major: unitName{one: name, other: name}}
+}
+
+func spellerFor(tag language.Tag) (*speller, error) {
+	base, _ := tag.Base()
+	region, _ := tag.Region()
+	switch base.String() {
+	case "en":
+		if region.String() == "IN" {
+			return indianEnglish, nil
+		}
+		return english, nil
+	case "hi":
+		return indianEnglish, nil
+	case "de":
+		return german, nil
+	case "fr":
+		switch region.String() {
+		case "CH":
+			return swissFrench, nil
+		case "BE":
+			return belgianFrench, nil
+		}
+		return french, nil
+	case "it":
+		return italian, nil
+	case "es":
+		return spanish, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, tag)
+}
+
+func noOf(int64, string) string { return " " }
+
+// joinWords joins non-empty parts with sep.
+func joinWords(sep string, parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+// English
+
+var englishOnes = []string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+	"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen",
+	"seventeen", "eighteen", "nineteen",
+}
+
+var englishTens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+
+func englishUnder1000(n int64) string {
+	var parts []string
+	if h := n / 100; h > 0 {
+		parts = append(parts, englishOnes[h]+" hundred")
+	}
+	switch r := n % 100; {
+	case r == 0:
+	case r < 20:
+		parts = append(parts, englishOnes[r])
+	case r%10 == 0:
+		parts = append(parts, englishTens[r/10])
+	default:
+		parts = append(parts, englishTens[r/10]+"-"+englishOnes[r%10])
+	}
+	return strings.Join(parts, " ")
+}
+
+func englishNumber(n int64, _ bool) string {
+	if n == 0 {
+		return "zero"
+	}
+	scales := []struct {
+		value int64
+		name  string
+	}{
+		{1_000_000_000_000, "trillion"},
+		{1_000_000_000, "billion"},
+		{1_000_000, "million"},
+		{1_000, "thousand"},
+	}
+	var parts []string
+	for _, s := range scales {
+		if q := n / s.value; q > 0 {
+			parts = append(parts, englishUnder1000(q)+" "+s.name)
+			n %= s.value
+		}
+	}
+	return joinWords(" ", append(parts, englishUnder1000(n))...)
+}
+
+// indianNumber uses the lakh (1,00,000) and crore (1,00,00,000) scale;
+// amounts of a hundred crore and more are counted in crores.
+func indianNumber(n int64, _ bool) string {
+	if n == 0 {
+		return "zero"
+	}
+	var parts []string
+	if q := n / 10_000_000; q > 0 {
+		parts = append(parts, indianNumber(q, false)+" crore")
+		n %= 10_000_000
+	}
+	if q := n / 100_000; q > 0 {
+		parts = append(parts, englishUnder1000(q)+" lakh")
+		n %= 100_000
+	}
+	if q := n / 1_000; q > 0 {
+		parts = append(parts, englishUnder1000(q)+" thousand")
+		n %= 1_000
+	}
+	return joinWords(" ", append(parts, englishUnder1000(n))...)
+}
+
+var englishUnits = map[string]unitNames{
+	"USD": {unitName{one: "dollar", other: "dollars"}, unitName{one: "cent", other: "cents"}},
+	"CAD": {unitName{one: "dollar", other: "dollars"}, unitName{one: "cent", other: "cents"}},
+	"AUD": {unitName{one: "dollar", other: "dollars"}, unitName{one: "cent", other: "cents"}},
+	"NZD": {unitName{one: "dollar", other: "dollars"}, unitName{one: "cent", other: "cents"}},
+	"SGD": {unitName{one: "dollar", other: "dollars"}, unitName{one: "cent", other: "cents"}},
+	"HKD": {unitName{one: "dollar", other: "dollars"}, unitName{one: "cent", other: "cents"}},
+	"EUR": {unitName{one: "euro", other: "euros"}, unitName{one: "cent", other: "cents"}},
+	"GBP": {unitName{one: "pound", other: "pounds"}, unitName{one: "penny", other: "pence"}},
+	"CHF": {unitName{one: "franc", other: "francs"}, unitName{one: "centime", other: "centimes"}},
+	"INR": {unitName{one: "rupee", other: "rupees"}, unitName{one: "paisa", other: "paise"}},
+	"JPY": {major: unitName{one: "yen", other: "yen"}},
+	"CNY": {unitName{one: "yuan", other: "yuan"}, unitName{one: "fen", other: "fen"}},
+	"MXN": {unitName{one: "peso", other: "pesos"}, unitName{one: "centavo", other: "centavos"}},
+	"BRL": {unitName{one: "real", other: "reais"}, unitName{one: "centavo", other: "centavos"}},
+	"ZAR": {unitName{one: "rand", other: "rand"}, unitName{one: "cent", other: "cents"}},
+}
+
+var english = &speller{number: englishNumber, of: noOf, and: "and", minus: "minus", units: englishUnits}
+
+var indianEnglish = &speller{number: indianNumber, of: noOf, and: "and", minus: "minus", only: "only", units: englishUnits}
+
+// German
+
+var germanOnes = []string{
+	"null", "eins", "zwei", "drei", "vier", "fünf", "sechs", "sieben", "acht", "neun",
+	"zehn", "elf", "zwölf", "dreizehn", "vierzehn", "fünfzehn", "sechzehn",
+	"siebzehn", "achtzehn", "neunzehn",
+}
+
+var germanTens = []string{"", "", "zwanzig", "dreißig", "vierzig", "fünfzig", "sechzig", "siebzig", "achtzig", "neunzig"}
+
+// germanUnder1000 writes n as one word. A final one is written "eins"
+// unless it is followed by another word, as in "einundzwanzigtausend".
+func germanUnder1000(n int64, final bool) string {
+	var b strings.Builder
+	if h := n / 100; h > 0 {
+		if h == 1 {
+			b.WriteString("ein")
+		} else {
+			b.WriteString(germanOnes[h])
+		}
+		b.WriteString("hundert")
+	}
+	switch r := n % 100; {
+	case r == 0:
+	case r == 1 && !final:
+		b.WriteString("ein")
+	case r < 20:
+		b.WriteString(germanOnes[r])
+	default:
+		if u := r % 10; u > 0 {
+			if u == 1 {
+				b.WriteString("ein")
+			} else {
+				b.WriteString(germanOnes[u])
+			}
+			b.WriteString("und")
+		}
+		b.WriteString(germanTens[r/10])
+	}
+	return b.String()
+}
+
+func germanNumber(n int64, feminine bool) string {
+	if n == 0 {
+		return "null"
+	}
+	if n == 1 {
+		if feminine {
+			return "eine"
+		}
+		return "ein"
+	}
+	scales := []struct {
+		value      int64
+		one, other string
+	}{
+		{1_000_000_000_000, "eine Billion", "Billionen"},
+		{1_000_000_000, "eine Milliarde", "Milliarden"},
+		{1_000_000, "eine Million", "Millionen"},
+	}
+	var parts []string
+	for _, s := range scales {
+		if q := n / s.value; q > 0 {
+			if q == 1 {
+				parts = append(parts, s.one)
+			} else {
+				parts = append(parts, germanUnder1000(q, true)+" "+s.other)
+			}
+			n %= s.value
+		}
+	}
+	var rest string
+	if q := n / 1000; q > 0 {
+		rest = germanUnder1000(q, false) + "tausend"
+	}
+	rest += germanUnder1000(n%1000, true)
+	return joinWords(" ", append(parts, rest)...)
+}
+
+var german = &speller{
+	number: germanNumber,
+	of:     noOf,
+	and:    "und",
+	minus:  "minus",
+	units: map[string]unitNames{
+		"EUR": {unitName{one: "Euro", other: "Euro"}, unitName{one: "Cent", other: "Cent"}},
+		"CHF": {unitName{one: "Franken", other: "Franken"}, unitName{one: "Rappen", other: "Rappen"}},
+		"USD": {unitName{one: "Dollar", other: "Dollar"}, unitName{one: "Cent", other: "Cent"}},
+		"GBP": {unitName{one: "Pfund", other: "Pfund"}, unitName{one: "Penny", other: "Pence"}},
+		"INR": {unitName{one: "Rupie", other: "Rupien", feminine: true}, unitName{one: "Paisa", other: "Paise"}},
+		"JPY": {major: unitName{one: "Yen", other: "Yen"}},
+	},
+}
+
+// French
+
+var frenchOnes = []string{
+	"zéro", "un", "deux", "trois", "quatre", "cinq", "six", "sept", "huit", "neuf",
+	"dix", "onze", "douze", "treize", "quatorze", "quinze", "seize",
+	"dix-sept", "dix-huit", "dix-neuf",
+}
+
+// frenchVariant holds the regional words for 70, 80 and 90; an empty word
+// means the vigesimal form (soixante-dix, quatre-vingts, quatre-vingt-dix).
+type frenchVariant struct {
+	seventy, eighty, ninety string
+}
+
+func (v frenchVariant) under100(n int64, final bool) string {
+	tens := []string{"", "", "vingt", "trente", "quarante", "cinquante", "soixante", v.seventy, v.eighty, v.ninety}
+	t, u := n/10, n%10
+	switch {
+	case n < 20:
+		return frenchOnes[n]
+	case (t == 7 && v.seventy == "") || (t == 9 && v.ninety == ""):
+		prefix := "soixante"
+		if t == 9 {
+			prefix = "quatre-vingt"
+		}
+		if t == 7 && u == 1 {
+			return prefix + " et onze"
+		}
+		return prefix + "-" + frenchOnes[10+u]
+	case t == 8 && v.eighty == "":
+		if u == 0 {
+			if final {
+				return "quatre-vingts"
+			}
+			return "quatre-vingt"
+		}
+		return "quatre-vingt-" + frenchOnes[u]
+	case u == 0:
+		return tens[t]
+	case u == 1:
+		return tens[t] + " et un"
+	default:
+		return tens[t] + "-" + frenchOnes[u]
+	}
+}
+
+// under1000 writes n; final selects the plural of "cent" and "vingt" when
+// nothing but a noun follows.
+func (v frenchVariant) under1000(n int64, final bool) string {
+	h, r := n/100, n%100
+	var parts []string
+	switch {
+	case h == 1:
+		parts = append(parts, "cent")
+	case h > 1 && r == 0 && final:
+		parts = append(parts, frenchOnes[h]+" cents")
+	case h > 1:
+		parts = append(parts, frenchOnes[h]+" cent")
+	}
+	if r > 0 {
+		parts = append(parts, v.under100(r, final))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (v frenchVariant) number(n int64, feminine bool) string {
+	if n == 0 {
+		return "zéro"
+	}
+	scales := []struct {
+		value int64
+		name  string
+	}{
+		{1_000_000_000_000, "billion"},
+		{1_000_000_000, "milliard"},
+		{1_000_000, "million"},
+	}
+	var parts []string
+	for _, s := range scales {
+		if q := n / s.value; q > 0 {
+			name := s.name
+			if q > 1 {
+				name += "s"
+			}
+			parts = append(parts, v.under1000(q, true)+" "+name)
+			n %= s.value
+		}
+	}
+	if q := n / 1000; q == 1 {
+		parts = append(parts, "mille")
+	} else if q > 1 {
+		parts = append(parts, v.under1000(q, false)+" mille")
+	}
+	parts = append(parts, v.under1000(n%1000, true))
+	s := joinWords(" ", parts...)
+	if feminine && strings.HasSuffix(s, "un") {
+		s += "e"
+	}
+	return s
+}
+
+// frenchOf inserts "de" between exact millions and the unit name, as in
+// "deux millions d'euros".
+func frenchOf(n int64, unit string) string {
+	if n == 0 || n%1_000_000 != 0 {
+		return " "
+	}
+	if strings.ContainsRune("aeiouyéh", []rune(unit)[0]) {
+		return " d'"
+	}
+	return " de "
+}
+
+var frenchUnits = map[string]unitNames{
+	"EUR": {unitName{one: "euro", other: "euros"}, unitName{one: "centime", other: "centimes"}},
+	"CHF": {unitName{one: "franc", other: "francs"}, unitName{one: "centime", other: "centimes"}},
+	"USD": {unitName{one: "dollar", other: "dollars"}, unitName{one: "cent", other: "cents"}},
+	"CAD": {unitName{one: "dollar", other: "dollars"}, unitName{one: "cent", other: "cents"}},
+	"GBP": {unitName{one: "livre", other: "livres", feminine: true}, unitName{one: "penny", other: "pence"}},
+	"XOF": {major: unitName{one: "franc CFA", other: "francs CFA"}},
+	"XAF": {major: unitName{one: "franc CFA", other: "francs CFA"}},
+	"INR": {unitName{one: "roupie", other: "roupies", feminine: true}, unitName{one: "paisa", other: "paise"}},
+	"JPY": {major: unitName{one: "yen", other: "yens"}},
+}
+
+func newFrench(v frenchVariant) *speller {
+	return &speller{number: v.number, of: frenchOf, and: "et", minus: "moins", units: frenchUnits}
+}
+
+var (
+	french        = newFrench(frenchVariant{})
+	swissFrench   = newFrench(frenchVariant{seventy: "septante", eighty: "huitante", ninety: "nonante"})
+	belgianFrench = newFrench(frenchVariant{seventy: "septante", ninety: "nonante"})
+)
+
+// Italian
+
+var italianOnes = []string{
+	"zero", "uno", "due", "tre", "quattro", "cinque", "sei", "sette", "otto", "nove",
+	"dieci", "undici", "dodici", "tredici", "quattordici", "quindici", "sedici",
+	"diciassette", "diciotto", "diciannove",
+}
+
+var italianTens = []string{"", "", "venti", "trenta", "quaranta", "cinquanta", "sessanta", "settanta", "ottanta", "novanta"}
+
+func italianUnder1000(n int64) string {
+	var b strings.Builder
+	h, r := n/100, n%100
+	if h > 0 {
+		if h > 1 {
+			b.WriteString(italianOnes[h])
+		}
+		// cento elides its vowel before otto and ottanta: centotto.
+		if r == 8 || r/10 == 8 {
+			b.WriteString("cent")
+		} else {
+			b.WriteString("cento")
+		}
+	}
+	switch {
+	case r == 0:
+	case r < 20:
+		b.WriteString(italianOnes[r])
+	default:
+		tens := italianTens[r/10]
+		u := r % 10
+		if u == 1 || u == 8 {
+			tens = tens[:len(tens)-1]
+		}
+		b.WriteString(tens)
+		if u == 3 {
+			b.WriteString("tré")
+		} else if u > 0 {
+			b.WriteString(italianOnes[u])
+		}
+	}
+	return b.String()
+}
+
+func italianNumber(n int64, feminine bool) string {
+	if n == 0 {
+		return "zero"
+	}
+	scales := []struct {
+		value      int64
+		one, other string
+	}{
+		{1_000_000_000_000, "mille miliardi", "mila miliardi"},
+		{1_000_000_000, "un miliardo", "miliardi"},
+		{1_000_000, "un milione", "milioni"},
+	}
+	var parts []string
+	for _, s := range scales {
+		if q := n / s.value; q > 0 {
+			switch {
+			case q == 1:
+				parts = append(parts, s.one)
+			case s.value == 1_000_000_000_000:
+				parts = append(parts, italianUnder1000(q)+s.other)
+			default:
+				parts = append(parts, italianUnder1000(q)+" "+s.other)
+			}
+			n %= s.value
+		}
+	}
+	var rest string
+	if q := n / 1000; q == 1 {
+		rest = "mille"
+	} else if q > 1 {
+		rest = italianUnder1000(q) + "mila"
+	}
+	rest += italianUnder1000(n % 1000)
+	if strings.HasSuffix(rest, "tre") && rest != "tre" {
+		rest = strings.TrimSuffix(rest, "tre") + "tré"
+	}
+	s := joinWords(" ", append(parts, rest)...)
+	// A final "uno" agrees with the noun: un euro, ventun euro, una
+	// sterlina; after cento it is kept whole: centouno euro.
+	if strings.HasSuffix(s, "uno") {
+		s = strings.TrimSuffix(s, "o")
+		switch {
+		case feminine:
+			s += "a"
+		case strings.HasSuffix(s, "centou"):
+			s += "o"
+		}
+	}
+	return s
+}
+
+// italianOf inserts "di" between exact millions and the unit name.
+func italianOf(n int64, unit string) string {
+	if n == 0 || n%1_000_000 != 0 {
+		return " "
+	}
+	return " di "
+}
+
+var italian = &speller{
+	number: italianNumber,
+	of:     italianOf,
+	and:    "e",
+	minus:  "meno",
+	units: map[string]unitNames{
+		"EUR": {unitName{one: "euro", other: "euro"}, unitName{one: "centesimo", other: "centesimi"}},
+		"CHF": {unitName{one: "franco", other: "franchi"}, unitName{one: "centesimo", other: "centesimi"}},
+		"USD": {unitName{one: "dollaro", other: "dollari"}, unitName{one: "centesimo", other: "centesimi"}},
+		"GBP": {unitName{one: "sterlina", other: "sterline", feminine: true}, unitName{one: "penny", other: "pence"}},
+		"INR": {unitName{one: "rupia", other: "rupie", feminine: true}, unitName{one: "paisa", other: "paise"}},
+		"JPY": {major: unitName{one: "yen", other: "yen"}},
+	},
+}
+
+// Spanish
+
+var spanishOnes = []string{
+	"cero", "uno", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve",
+	"diez", "once", "doce", "trece", "catorce", "quince", "dieciséis", "diecisiete",
+	"dieciocho", "diecinueve", "veinte", "veintiuno", "veintidós", "veintitrés",
+	"veinticuatro", "veinticinco", "veintiséis", "veintisiete", "veintiocho", "veintinueve",
+}
+
+var spanishTens = []string{"", "", "", "treinta", "cuarenta", "cincuenta", "sesenta", "setenta", "ochenta", "noventa"}
+
+var spanishHundreds = []string{
+	"", "ciento", "doscientos", "trescientos", "cuatrocientos", "quinientos",
+	"seiscientos", "setecientos", "ochocientos", "novecientos",
+}
+
+// spanishUnder1000 writes n with feminine hundreds if requested.
+func spanishUnder1000(n int64, feminine bool) string {
+	h, r := n/100, n%100
+	var parts []string
+	switch {
+	case n == 100:
+		return "cien"
+	case h == 1:
+		parts = append(parts, "ciento")
+	case h > 1:
+		hundreds := spanishHundreds[h]
+		if feminine {
+			hundreds = strings.TrimSuffix(hundreds, "os") + "as"
+		}
+		parts = append(parts, hundreds)
+	}
+	switch {
+	case r == 0:
+	case r < 30:
+		parts = append(parts, spanishOnes[r])
+	case r%10 == 0:
+		parts = append(parts, spanishTens[r/10])
+	default:
+		parts = append(parts, spanishTens[r/10]+" y "+spanishOnes[r%10])
+	}
+	return strings.Join(parts, " ")
+}
+
+// spanishApocope shortens a final "uno" before a noun: un, veintiún, or
+// makes it feminine: una, veintiuna.
+func spanishApocope(s string, feminine bool) string {
+	switch {
+	case !strings.HasSuffix(s, "uno"):
+		return s
+	case feminine:
+		return strings.TrimSuffix(s, "o") + "a"
+	case strings.HasSuffix(s, "veintiuno"):
+		return strings.TrimSuffix(s, "veintiuno") + "veintiún"
+	default:
+		return strings.TrimSuffix(s, "o")
+	}
+}
+
+// spanishUnderMillion writes n below one million.
+func spanishUnderMillion(n int64, feminine bool) string {
+	var thousands string
+	if q := n / 1000; q == 1 {
+		thousands = "mil"
+	} else if q > 1 {
+		thousands = spanishApocope(spanishUnder1000(q, feminine), feminine) + " mil"
+	}
+	return joinWords(" ", thousands, spanishUnder1000(n%1000, feminine))
+}
+
+func spanishNumber(n int64, feminine bool) string {
+	if n == 0 {
+		return "cero"
+	}
+	var parts []string
+	if q := n / 1_000_000_000_000; q > 0 {
+		if q == 1 {
+			parts = append(parts, "un billón")
+		} else {
+			parts = append(parts, spanishApocope(spanishUnderMillion(q, false), false)+" billones")
+		}
+		n %= 1_000_000_000_000
+	}
+	if q := n / 1_000_000; q > 0 {
+		if q == 1 {
+			parts = append(parts, "un millón")
+		} else {
+			parts = append(parts, spanishApocope(spanishUnderMillion(q, false), false)+" millones")
+		}
+		n %= 1_000_000
+	}
+	parts = append(parts, spanishUnderMillion(n, feminine))
+	return spanishApocope(joinWords(" ", parts...), feminine)
+}
+
+// spanishOf inserts "de" between exact millions and the unit name.
+func spanishOf(n int64, unit string) string {
+	if n == 0 || n%1_000_000 != 0 {
+		return " "
+	}
+	return " de "
+}
+
+var spanish = &speller{
+	number: spanishNumber,
+	of:     spanishOf,
+	and:    "con",
+	minus:  "menos",
+	units: map[string]unitNames{
+		"EUR": {unitName{one: "euro", other: "euros"}, unitName{one: "céntimo", other: "céntimos"}},
+		"USD": {unitName{one: "dólar", other: "dólares"}, unitName{one: "centavo", other: "centavos"}},
+		"MXN": {unitName{one: "peso", other: "pesos"}, unitName{one: "centavo", other: "centavos"}},
+		"ARS": {unitName{one: "peso", other: "pesos"}, unitName{one: "centavo", other: "centavos"}},
+		"CLP": {major: unitName{one: "peso", other: "pesos"}},
+		"COP": {unitName{one: "peso", other: "pesos"}, unitName{one: "centavo", other: "centavos"}},
+		"UYU": {unitName{one: "peso", other: "pesos"}, unitName{one: "centésimo", other: "centésimos"}},
+		"PEN": {unitName{one: "sol", other: "soles"}, unitName{one: "céntimo", other: "céntimos"}},
+		"BRL": {unitName{one: "real", other: "reales"}, unitName{one: "centavo", other: "centavos"}},
+		"GBP": {unitName{one: "libra", other: "libras", feminine: true}, unitName{one: "penique", other: "peniques"}},
+		"CHF": {unitName{one: "franco", other: "francos"}, unitName{one: "céntimo", other: "céntimos"}},
+		"INR": {unitName{one: "rupia", other: "rupias", feminine: true}, unitName{one: "paisa", other: "paisas"}},
+		"JPY": {major: unitName{one: "yen", other: "yenes"}},
+	},
+}
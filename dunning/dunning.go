@@ -0,0 +1,315 @@
+// Package dunning calculates late-payment interest on overdue invoices and
+// produces payment reminders and dunning notices according to a policy.
+package dunning
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// Dunning errors.
+var (
+	ErrNotOverdue    = errors.New("invoice is not overdue")
+	ErrPaid          = errors.New("invoice is paid in full")
+	ErrInvalidPolicy = errors.New("invalid dunning policy")
+)
+
+// Payment is a payment received against an invoice.
+type Payment struct {
+	Date      time.Time     `json:"date"`
+	Amount    invoice.Money `json:"amount"`
+	Reference string        `json:"reference,omitempty"`
+}
+
+// BaseRate is a reference interest rate in percent valid from a date until
+// the next rate, e.g. the ECB main refinancing rate on 1 January and
+// 1 July used by the EU Late Payment Directive.
+type BaseRate struct {
+	From time.Time       `json:"from"`
+	Rate decimal.Decimal `json:"rate"`
+}
+
+// Level is a dunning level reached a number of days after the grace
+// period, such as a friendly reminder followed by formal notices.
+type Level struct {
+	Name string `json:"name"`
+	// AfterDays is the number of days overdue, after the grace period,
+	// from which the level applies.
+	AfterDays int `json:"after_days"`
+	// Fee is charged when the level is reached, in FeeCurrency or, if
+	// that is empty, the invoice currency. A fee in another currency than
+	// the invoice's is not charged. Fees of all reached levels are added
+	// up.
+	Fee         decimal.Decimal `json:"fee,omitzero"`
+	FeeCurrency string          `json:"fee_currency,omitempty"`
+	Text        string          `json:"text,omitempty"`
+}
+
+// Policy defines when notices are sent and what interest and fees are
+// charged on overdue invoices.
+type Policy struct {
+	// GraceDays delays the first notice after the due date. Interest
+	// accrues from the day after the due date regardless.
+	GraceDays int `json:"grace_days"`
+	// PaymentDays is the time given to pay a notice.
+	PaymentDays int     `json:"payment_days"`
+	Levels      []Level `json:"levels"`
+	// InterestRate is the annual interest rate in percent, or the margin
+	// added to the base rate when BaseRates are set. Without either, the
+	// late interest of the invoice's payment terms is used.
+	InterestRate decimal.Decimal `json:"interest_rate,omitzero"`
+	BaseRates    []BaseRate      `json:"base_rates,omitempty"`
+	// DayCount is the number of days in an interest year, 365 by default.
+	DayCount int `json:"day_count,omitempty"`
+}
+
+// EULatePaymentPolicy returns a policy following the EU Late Payment
+// Directive (2011/7/EU) for business-to-business invoices: interest at the
+// base rate plus 8 percentage points and a fixed compensation of 40 EUR for
+// recovery costs from the first formal notice. The compensation is only
+// charged on invoices in euros; for other currencies, set the Fee and
+// FeeCurrency of the level to the equivalent national amount.
+func EULatePaymentPolicy(baseRates ...BaseRate) Policy {
+	return Policy{
+		PaymentDays:  10,
+		InterestRate: decimal.NewFromInt(8),
+		BaseRates:    baseRates,
+		DayCount:     365,
+		Levels: []Level{
+			{
+				Name: "Payment Reminder",
+				Text: "Our records show that the invoice below has not yet been paid. If you have already paid, please disregard this reminder.",
+			},
+			{
+				Name:        "First Notice",
+				AfterDays:   14,
+				Fee:         decimal.NewFromInt(40),
+				FeeCurrency: "EUR",
+				Text:        "Despite our reminder, the invoice below remains unpaid. We charge statutory interest and compensation for recovery costs.",
+			},
+			{
+				Name:      "Final Notice",
+				AfterDays: 28,
+				Text:      "The invoice below is still unpaid. Unless we receive payment by the date stated, we will hand the matter over for collection without further notice.",
+			},
+		},
+	}
+}
+
+// Validate checks that the policy has levels in ascending order and
+// non-negative rates, fees and periods.
+func (p Policy) Validate() error {
+	if len(p.Levels) == 0 {
+		return fmt.Errorf("%w: at least one level is required", ErrInvalidPolicy)
+	}
+	if p.GraceDays < 0 || p.PaymentDays < 0 || p.DayCount < 0 {
+		return fmt.Errorf("%w: days cannot be negative", ErrInvalidPolicy)
+	}
+	if p.InterestRate.IsNegative() {
+		return fmt.Errorf("%w: interest rate cannot be negative", ErrInvalidPolicy)
+	}
+	for i, level := range p.Levels {
+		if level.Fee.IsNegative() {
+			return fmt.Errorf("%w: level %q has a negative fee", ErrInvalidPolicy, level.Name)
+		}
+		if i > 0 && level.AfterDays <= p.Levels[i-1].AfterDays {
+			return fmt.Errorf("%w: levels must be in ascending order of days", ErrInvalidPolicy)
+		}
+	}
+	return nil
+}
+
+// InterestPeriod is a run of days with the same outstanding principal and
+// interest rate.
+type InterestPeriod struct {
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Days      int             `json:"days"`
+	Principal invoice.Money   `json:"principal"`
+	Rate      decimal.Decimal `json:"rate"`
+	Interest  invoice.Money   `json:"interest"`
+}
+
+// Notice is a payment reminder or dunning notice for an overdue invoice.
+type Notice struct {
+	Invoice *invoice.Invoice `json:"invoice"`
+	// Level is the 1-based index of the policy level reached.
+	Level       int              `json:"level"`
+	Name        string           `json:"name"`
+	Text        string           `json:"text,omitempty"`
+	Date        time.Time        `json:"date"`
+	PayBy       time.Time        `json:"pay_by"`
+	DaysOverdue int              `json:"days_overdue"`
+	Amount      invoice.Money    `json:"amount"`
+	Payments    []Payment        `json:"payments,omitempty"`
+	Outstanding invoice.Money    `json:"outstanding"`
+	Interest    []InterestPeriod `json:"interest,omitempty"`
+	Fees        invoice.Money    `json:"fees"`
+}
+
+// TotalPaid returns the sum of payments received.
+func (n *Notice) TotalPaid() invoice.Money {
+	total := invoice.Money{Amount: decimal.Zero, Currency: n.Amount.Currency}
+	for _, p := range n.Payments {
+		total, _ = total.Add(p.Amount)
+	}
+	return total
+}
+
+// TotalInterest returns the interest accrued over all periods.
+func (n *Notice) TotalInterest() invoice.Money {
+	total := invoice.Money{Amount: decimal.Zero, Currency: n.Amount.Currency}
+	for _, period := range n.Interest {
+		total, _ = total.Add(period.Interest)
+	}
+	return total
+}
+
+// TotalDue returns the outstanding amount plus interest and fees.
+func (n *Notice) TotalDue() invoice.Money {
+	return invoice.MustSum(n.Outstanding, n.TotalInterest(), n.Fees)
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(day(to).Sub(day(from)).Hours() / 24)
+}
+
+// Notice produces the notice for an invoice on the given date, taking
+// into account payments received up to that date. It returns ErrPaid if
+// nothing is outstanding and ErrNotOverdue before the grace period ends.
+func (p Policy) Notice(inv *invoice.Invoice, payments []Payment, date time.Time) (*Notice, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	amount := inv.TotalPayable().Round(2)
+	var received []Payment
+	for _, payment := range payments {
+		if payment.Amount.Currency != inv.Currency {
+			return nil, fmt.Errorf("%w: payment in %s", invoice.ErrCurrencyMismatch, payment.Amount.Currency)
+		}
+		if !day(payment.Date).After(day(date)) {
+			received = append(received, payment)
+		}
+	}
+	sort.SliceStable(received, func(i, j int) bool { return received[i].Date.Before(received[j].Date) })
+
+	n := &Notice{
+		Invoice:     inv,
+		Date:        day(date),
+		PayBy:       day(date).AddDate(0, 0, p.PaymentDays),
+		DaysOverdue: daysBetween(inv.DueDate, date),
+		Amount:      amount,
+		Payments:    received,
+	}
+	n.Outstanding, _ = amount.Sub(n.TotalPaid())
+	if !n.Outstanding.Amount.IsPositive() {
+		return nil, ErrPaid
+	}
+	if n.DaysOverdue <= p.GraceDays {
+		return nil, fmt.Errorf("%w: due %s", ErrNotOverdue, inv.DueDate.Format("2006-01-02"))
+	}
+
+	n.Level = 1
+	for i, level := range p.Levels {
+		if n.DaysOverdue-p.GraceDays >= level.AfterDays {
+			n.Level = i + 1
+		}
+	}
+	n.Name = p.Levels[n.Level-1].Name
+	n.Text = p.Levels[n.Level-1].Text
+
+	fees := decimal.Zero
+	for _, level := range p.Levels[:n.Level] {
+		if level.FeeCurrency == "" || level.FeeCurrency == inv.Currency {
+			fees = fees.Add(level.Fee)
+		}
+	}
+	if p.usesTermsInterest() && inv.PaymentTerms != nil && inv.PaymentTerms.LateFee.Amount.IsPositive() {
+		fees = fees.Add(inv.PaymentTerms.LateFee.Amount)
+	}
+	n.Fees = invoice.Money{Amount: fees, Currency: inv.Currency}
+	n.Interest = p.interest(inv, amount, received, date)
+	return n, nil
+}
+
+// usesTermsInterest returns true if the policy takes interest from the
+// invoice's payment terms.
+func (p Policy) usesTermsInterest() bool {
+	return p.InterestRate.IsZero() && len(p.BaseRates) == 0
+}
+
+// rate returns the annual interest rate applying on a day. BaseRates must
+// be sorted by date.
+func (p Policy) rate(inv *invoice.Invoice, d time.Time) decimal.Decimal {
+	if p.usesTermsInterest() {
+		if inv.PaymentTerms == nil {
+			return decimal.Zero
+		}
+		return inv.PaymentTerms.LateInterest
+	}
+	rate := p.InterestRate
+	base := decimal.Zero
+	for _, b := range p.BaseRates {
+		if day(b.From).After(d) {
+			break
+		}
+		base = b.Rate
+	}
+	// A negative base rate does not reduce the statutory margin.
+	if base.IsPositive() {
+		rate = rate.Add(base)
+	}
+	return rate
+}
+
+// interest calculates simple interest day by day from the day after the
+// due date up to and including the notice date, grouping days with the
+// same principal and rate into periods. A payment stops interest on its
+// amount from the day it is received.
+func (p Policy) interest(inv *invoice.Invoice, amount invoice.Money, payments []Payment, date time.Time) []InterestPeriod {
+	dayCount := p.DayCount
+	if dayCount == 0 {
+		dayCount = 365
+	}
+	p.BaseRates = append([]BaseRate(nil), p.BaseRates...)
+	sort.SliceStable(p.BaseRates, func(i, j int) bool { return p.BaseRates[i].From.Before(p.BaseRates[j].From) })
+
+	var periods []InterestPeriod
+	principal := amount
+	next := 0
+	for d := day(inv.DueDate).AddDate(0, 0, 1); !d.After(day(date)); d = d.AddDate(0, 0, 1) {
+		for next < len(payments) && !day(payments[next].Date).After(d) {
+			principal, _ = principal.Sub(payments[next].Amount)
+			next++
+		}
+		rate := p.rate(inv, d)
+		if !principal.Amount.IsPositive() || !rate.IsPositive() {
+			continue
+		}
+		if last := len(periods) - 1; last >= 0 && periods[last].To.AddDate(0, 0, 1).Equal(d) &&
+			periods[last].Principal.Amount.Equal(principal.Amount) && periods[last].Rate.Equal(rate) {
+			periods[last].To = d
+			periods[last].Days++
+			continue
+		}
+		periods = append(periods, InterestPeriod{From: d, To: d, Days: 1, Principal: principal, Rate: rate})
+	}
+	for i := range periods {
+		period := &periods[i]
+		factor := period.Rate.Div(decimal.NewFromInt(100)).
+			Mul(decimal.NewFromInt(int64(period.Days))).
+			Div(decimal.NewFromInt(int64(dayCount)))
+		period.Interest = period.Principal.Mul(factor).Round(2)
+	}
+	return periods
+}
@@ -0,0 +1,104 @@
+package dunning
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func testInvoice(t *testing.T) *invoice.Invoice {
+	t.Helper()
+	inv, err := invoice.New().
+		Number("INV-001").
+		IssueDate(date(2025, 1, 1)).
+		DueDate(date(2025, 1, 31)).
+		Currency("EUR").
+		Supplier(invoice.Party{Name: "Supplier"}).
+		Customer(invoice.Party{Name: "Customer"}).
+		AddItem(invoice.NewLineItem("Service", 1, invoice.NewMoney(1000, "EUR"), 0)).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return inv
+}
+
+func TestNotice(t *testing.T) {
+	inv := testInvoice(t)
+	policy := EULatePaymentPolicy(
+		BaseRate{From: date(2025, 7, 1), Rate: decimal.RequireFromString("2.15")},
+		BaseRate{From: date(2025, 1, 1), Rate: decimal.RequireFromString("3.15")},
+	)
+	payments := []Payment{{Date: date(2025, 2, 10), Amount: invoice.NewMoney(400, "EUR")}}
+
+	n, err := policy.Notice(inv, payments, date(2025, 3, 5))
+	if err != nil {
+		t.Fatalf("Notice: %v", err)
+	}
+	if n.Level != 3 || n.Name != "Final Notice" || n.DaysOverdue != 33 {
+		t.Errorf("level = %d %q after %d days", n.Level, n.Name, n.DaysOverdue)
+	}
+	if len(n.Interest) != 2 {
+		t.Fatalf("expected 2 interest periods, got %d", len(n.Interest))
+	}
+	first, second := n.Interest[0], n.Interest[1]
+	if first.Days != 9 || first.Interest.String() != "2.75 EUR" || !first.Rate.Equal(decimal.RequireFromString("11.15")) {
+		t.Errorf("first period = %+v", first)
+	}
+	if second.Days != 24 || second.Principal.String() != "600.00 EUR" || second.Interest.String() != "4.40 EUR" {
+		t.Errorf("second period = %+v", second)
+	}
+	if n.Fees.String() != "40.00 EUR" || n.TotalDue().String() != "647.15 EUR" {
+		t.Errorf("fees = %s, total due = %s", n.Fees, n.TotalDue())
+	}
+
+	if n, err := policy.Notice(inv, nil, date(2025, 2, 3)); err != nil || n.Level != 1 || n.Fees.Amount.IsPositive() {
+		t.Errorf("reminder = %+v, %v", n, err)
+	}
+	francs, err := invoice.New().
+		Number("INV-002").
+		IssueDate(date(2025, 1, 1)).
+		DueDate(date(2025, 1, 31)).
+		Currency("CHF").
+		Supplier(invoice.Party{Name: "Supplier"}).
+		Customer(invoice.Party{Name: "Customer"}).
+		AddItem(invoice.NewLineItem("Service", 1, invoice.NewMoney(1000, "CHF"), 0)).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, err := policy.Notice(francs, nil, date(2025, 3, 5)); err != nil || n.Level != 3 || !n.Fees.Amount.IsZero() {
+		t.Errorf("notice for a CHF invoice = %+v, %v", n, err)
+	}
+	if _, err := policy.Notice(inv, nil, date(2025, 1, 31)); !errors.Is(err, ErrNotOverdue) {
+		t.Errorf("expected ErrNotOverdue, got %v", err)
+	}
+	paid := []Payment{{Date: date(2025, 2, 1), Amount: invoice.NewMoney(1000, "EUR")}}
+	if _, err := policy.Notice(inv, paid, date(2025, 3, 1)); !errors.Is(err, ErrPaid) {
+		t.Errorf("expected ErrPaid, got %v", err)
+	}
+}
+
+func TestNoticeTermsInterest(t *testing.T) {
+	inv := testInvoice(t)
+	terms := invoice.NetTerms(30).WithLateInterest(10).WithLateFee(invoice.NewMoney(15, "EUR"))
+	inv.PaymentTerms = &terms
+
+	policy := Policy{Levels: []Level{{Name: "Reminder"}}}
+	n, err := policy.Notice(inv, nil, date(2025, 3, 2))
+	if err != nil {
+		t.Fatalf("Notice: %v", err)
+	}
+	// 1000.00 at 10% for 30 days: 8.22
+	if n.TotalInterest().String() != "8.22 EUR" || n.Fees.String() != "15.00 EUR" {
+		t.Errorf("interest = %s, fees = %s", n.TotalInterest(), n.Fees)
+	}
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"embed"
 	"fmt"
+	"io/fs"
 	"os"
 	"time"
 
+	"github.com/wiederin/go-invoicer/currency"
 	"github.com/wiederin/go-invoicer/invoice"
 	"github.com/wiederin/go-invoicer/render"
 	"github.com/wiederin/go-invoicer/template"
@@ -14,6 +16,12 @@ import (
 //go:embed templates/*
 var templates embed.FS
 
+// fonts holds a subset of DejaVu Sans Condensed covering Latin, Greek and
+// Cyrillic; see fonts/LICENSE-DejaVu.txt.
+//
+//go:embed fonts/*.ttf
+var fonts embed.FS
+
 func main() {
 	inv, err := invoice.New().
 		Number("INV-2025-002").
@@ -21,6 +29,7 @@ func main() {
 		DueDate(time.Now().AddDate(0, 0, 30)).
 		Currency("CHF").
 		CountryCode("CH").
+		RoundingIncrement(currency.CashIncrement("CHF", "CH")).
 		Supplier(invoice.Party{
 			Name: "Swiss Tech GmbH",
 			Address: invoice.Address{
@@ -31,6 +40,7 @@ func main() {
 			},
 			VATID: "CHE-123.456.789",
 			IBAN:  "CH93 0076 2011 6238 5295 7",
+			BIC:   "UBSWCHZH80A",
 		}).
 		Customer(invoice.Party{
 			Name: "German Corp AG",
@@ -48,6 +58,12 @@ func main() {
 			invoice.NewMoney(150.00, "CHF"),
 			8.1,
 		)).
+		AddItem(invoice.NewLineItem(
+			"Localisation: Polski, Ελληνικά, Русский",
+			1,
+			invoice.NewMoney(900.00, "CHF"),
+			8.1,
+		)).
 		AddItem(invoice.NewLineItem(
 			"Server Infrastructure",
 			1,
@@ -67,7 +83,7 @@ func main() {
 		template.NewEmbedSource(templates),
 	)
 
-	engine := render.NewEngine(tmplManager)
+	engine := render.NewEngine(tmplManager).WithOptions(fontOptions())
 	pdf, err := engine.RenderInvoice(inv, "templates/invoice.html")
 	if err != nil {
 		fmt.Printf("Error rendering PDF: %v\n", err)
@@ -81,3 +97,23 @@ func main() {
 
 	fmt.Println("Invoice saved to invoice_swiss.pdf")
 }
+
+// fontOptions registers DejaVu Sans Condensed, which ships with the
+// example, so that the Polish, Greek and Cyrillic text prints. The template
+// asks for Arial, one of the PDF core fonts, which only cover Western
+// European languages; characters it lacks fall back to DejaVu Sans.
+func fontOptions() render.Options {
+	dir, err := fs.Sub(fonts, "fonts")
+	if err != nil {
+		panic(err)
+	}
+	opts := render.DefaultOptions()
+	opts.Fonts = []render.Font{{
+		Family:  "DejaVu Sans",
+		Regular: "DejaVuSansCondensed.ttf",
+		Bold:    "DejaVuSansCondensed-Bold.ttf",
+		FS:      dir,
+	}}
+	opts.FallbackFonts = map[string][]string{"": {"DejaVu Sans"}}
+	return opts
+}
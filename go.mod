@@ -7,6 +7,9 @@ toolchain go1.24.10
 require (
 	github.com/go-pdf/fpdf v0.9.0
 	github.com/shopspring/decimal v1.3.1
+	golang.org/x/net v0.47.0
+	golang.org/x/text v0.31.0
+	software.sslmate.com/src/go-pkcs12 v0.5.0
 )
 
-require golang.org/x/text v0.31.0
+require golang.org/x/crypto v0.44.0 // indirect
@@ -0,0 +1,160 @@
+package pdfupdate
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var objectRefPattern = regexp.MustCompile(`^(\d+)\s+(\d+)\s+R`)
+
+// Resolve returns the object an entry refers to, or the entry itself if it
+// is not a reference or the object cannot be read.
+func (u *Update) Resolve(v string) string {
+	if n, ok := Ref(v); ok {
+		if obj, err := u.Object(n); err == nil {
+			return obj
+		}
+	}
+	return v
+}
+
+// Ref returns the object number of an indirect reference.
+func Ref(v string) (int, bool) {
+	m := objectRefPattern.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	return n, err == nil
+}
+
+// Dict returns the top-level entries of a dictionary, keyed by their names
+// without the slash.
+func Dict(s string) map[string]string {
+	s = strings.TrimSpace(s)
+	entries := make(map[string]string)
+	if !strings.HasPrefix(s, "<<") {
+		return entries
+	}
+	s = s[2:]
+	for {
+		s = strings.TrimLeft(s, " \t\r\n\f")
+		if s == "" || strings.HasPrefix(s, ">>") || s[0] != '/' {
+			return entries
+		}
+		key := Token(s)
+		s = strings.TrimLeft(s[len(key):], " \t\r\n\f")
+		value := Token(s)
+		entries[key[1:]] = value
+		s = s[len(value):]
+	}
+}
+
+// Array returns the items of an array.
+func Array(s string) []string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") {
+		return nil
+	}
+	s = s[1:]
+	var items []string
+	for {
+		s = strings.TrimLeft(s, " \t\r\n\f")
+		if s == "" || s[0] == ']' {
+			return items
+		}
+		item := Token(s)
+		if item == "" {
+			return items
+		}
+		items = append(items, item)
+		s = s[len(item):]
+	}
+}
+
+// Token returns the object at the start of s: a dictionary, array, string,
+// name, indirect reference or other token.
+func Token(s string) string {
+	if m := objectRefPattern.FindString(s); m != "" {
+		return m
+	}
+	if s == "" {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(s, "<<"), s[0] == '[':
+		depth := 0
+		for i := 0; i < len(s); i++ {
+			switch {
+			case strings.HasPrefix(s[i:], "<<"), s[i] == '[':
+				depth++
+				if s[i] == '<' {
+					i++
+				}
+			case strings.HasPrefix(s[i:], ">>"), s[i] == ']':
+				depth--
+				if s[i] == '>' {
+					i++
+				}
+				if depth == 0 {
+					return s[:i+1]
+				}
+			case s[i] == '(':
+				i += len(Token(s[i:])) - 1
+			}
+		}
+		return s
+	case s[0] == '(':
+		depth := 0
+		for i := 0; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				if depth--; depth == 0 {
+					return s[:i+1]
+				}
+			}
+		}
+		return s
+	case s[0] == '<':
+		if end := strings.IndexByte(s, '>'); end >= 0 {
+			return s[:end+1]
+		}
+		return s
+	}
+	i := 1
+	for i < len(s) && isRegular(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+// ReplaceRefs returns text with every indirect reference replaced by the
+// result of repl for its object number, and the offsets in the result at
+// which the replacements start.
+func ReplaceRefs(text string, repl func(n int) string) (string, []int) {
+	var out strings.Builder
+	var offsets []int
+	for i := 0; i < len(text); i++ {
+		if text[i] >= '0' && text[i] <= '9' && (i == 0 || !isRegular(text[i-1])) {
+			if m := objectRefPattern.FindStringSubmatch(text[i:]); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				offsets = append(offsets, out.Len())
+				out.WriteString(repl(n))
+				i += len(m[0]) - 1
+				continue
+			}
+		}
+		out.WriteByte(text[i])
+	}
+	return out.String(), offsets
+}
+
+// isRegular reports whether a byte is neither white space nor a delimiter.
+func isRegular(c byte) bool {
+	return !strings.ContainsRune(" \t\r\n\f\x00()<>[]{}/%", rune(c))
+}
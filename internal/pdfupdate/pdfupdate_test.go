@@ -0,0 +1,160 @@
+package pdfupdate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testPDF returns a PDF of the objects, numbered from 1, whose catalog is
+// the first.
+func testPDF(objects ...string) []byte {
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<<\n/Size %d\n/Root 1 0 R\n>>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return []byte(b.String())
+}
+
+func TestObjects(t *testing.T) {
+	dict := Dict("<< /Type /Font /Widths [500 600] /Name (a (b) c) /Flags 4/Font 12 0 R /Sub << /A 1 >> /Hex <0A> >>")
+	want := map[string]string{
+		"Type":   "/Font",
+		"Widths": "[500 600]",
+		"Name":   "(a (b) c)",
+		"Flags":  "4",
+		"Font":   "12 0 R",
+		"Sub":    "<< /A 1 >>",
+		"Hex":    "<0A>",
+	}
+	if !reflect.DeepEqual(dict, want) {
+		t.Errorf("Dict = %v, want %v", dict, want)
+	}
+	if got := Array("[1 0 R /Name (x]) [2 3] << /K 4 >>]"); !reflect.DeepEqual(got, []string{"1 0 R", "/Name", "(x])", "[2 3]", "<< /K 4 >>"}) {
+		t.Errorf("Array = %q", got)
+	}
+	if got := Dict("[1 2]"); len(got) != 0 {
+		t.Errorf("Dict of an array = %v", got)
+	}
+	if n, ok := Ref(" 7 0 R "); !ok || n != 7 {
+		t.Errorf("Ref = %d, %v", n, ok)
+	}
+	if _, ok := Ref("/Name"); ok {
+		t.Error("Ref of a name succeeded")
+	}
+
+	text, offsets := ReplaceRefs("<< /A 3 0 R /B [10 0 R] /C 30 >>", func(n int) string { return "#" + strconv.Itoa(n) })
+	if text != "<< /A #3 /B [#10] /C 30 >>" || !reflect.DeepEqual(offsets, []int{6, 13}) {
+		t.Errorf("ReplaceRefs = %q, %v", text, offsets)
+	}
+
+	u, err := Parse(testPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := u.Resolve("2 0 R"); got != "<< /Type /Pages /Kids [] /Count 0 >>" {
+		t.Errorf("Resolve = %q", got)
+	}
+	if got := u.Resolve("9 0 R"); got != "9 0 R" {
+		t.Errorf("Resolve of a missing object = %q", got)
+	}
+}
+
+func TestPages(t *testing.T) {
+	u, err := Parse(testPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 3 >>",
+		"<< /Type /Pages /Parent 2 0 R /Kids 6 0 R /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 3 0 R >>",
+		"[7 0 R 5 0 R]",
+		"<</Type /Page\n/Parent 3 0 R>>",
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pages, err := u.Pages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(pages, []int{7, 5, 4}) {
+		t.Errorf("Pages = %v, want [7 5 4]", pages)
+	}
+
+	for name, pdf := range map[string][]byte{
+		"not a page": testPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [3 0 R] >>", "<< /Type /Font >>"),
+		"cycle":      testPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [2 0 R] >>"),
+		"no pages":   testPDF("<< /Type /Catalog >>"),
+	} {
+		u, err := Parse(pdf)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if _, err := u.Pages(); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: expected ErrMalformed, got %v", name, err)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	pdf := testPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		"<< /Length 5 >>\nstream\nq Q\n\nendstream",
+	)
+	u, err := Parse(pdf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dict, data, err := u.Stream(4)
+	if err != nil || dict != "<< /Length 5 >>" || string(data) != "q Q\n\n" {
+		t.Errorf("Stream = %q, %q, %v", dict, data, err)
+	}
+	if _, err := u.Object(9); !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected ErrMalformed for a missing object, got %v", err)
+	}
+
+	n := u.Reserve()
+	u.Set(n, "<< /Title "+Text("Übersicht")+" >>")
+	catalog, err := u.Extend(u.Root, fmt.Sprintf(" /Outlines %d 0 R", n))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u.Set(u.Root, catalog)
+	updated := u.Bytes()
+	if !strings.HasPrefix(string(updated), string(pdf)) {
+		t.Error("expected the update to be appended to the original file")
+	}
+
+	u, err = Parse(updated)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	catalog, err = u.Object(u.Root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	outlines, ok := Ref(Dict(catalog)["Outlines"])
+	if !ok || outlines != n {
+		t.Fatalf("expected /Outlines %d 0 R in the updated catalog %q", n, catalog)
+	}
+	if got := Dict(u.Resolve(Dict(catalog)["Outlines"]))["Title"]; got != "<FEFF00DC00620065007200730069006300680074>" {
+		t.Errorf("Title = %q", got)
+	}
+	if pages, err := u.Pages(); err != nil || !reflect.DeepEqual(pages, []int{3}) {
+		t.Errorf("Pages = %v, %v", pages, err)
+	}
+}
@@ -0,0 +1,320 @@
+// Package pdfupdate appends incremental updates to PDF files, which replace
+// or add objects without rewriting the file. It reads the cross-reference
+// tables written by fpdf and by this package, not cross-reference streams,
+// and splits objects into their dictionary entries and array items.
+package pdfupdate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrMalformed is returned if a PDF cannot be read.
+var ErrMalformed = errors.New("malformed PDF")
+
+var (
+	startxrefPattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	idPattern        = regexp.MustCompile(`/ID\s*\[[^\]]*\]`)
+	lengthPattern    = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+)
+
+// Update is an incremental update of a PDF.
+type Update struct {
+	// Root and Info are the object numbers of the catalog and of the
+	// information dictionary, which is 0 if there is none.
+	Root int
+	Info int
+
+	data    []byte
+	offsets map[int]int
+	size    int
+	prev    int
+	id      string
+	objects map[int]string
+}
+
+// Parse reads the cross-reference sections and the trailer of a PDF.
+func Parse(data []byte) (*Update, error) {
+	m := startxrefPattern.FindSubmatch(data)
+	if m == nil {
+		return nil, fmt.Errorf("%w: no startxref", ErrMalformed)
+	}
+	u := &Update{data: data, offsets: make(map[int]int), objects: make(map[int]string)}
+	u.prev, _ = strconv.Atoi(string(m[1]))
+
+	// Earlier sections are read through the /Prev entries of the trailers;
+	// objects of later sections replace those of earlier ones.
+	seen := make(map[int]bool)
+	for off, latest := u.prev, true; ; latest = false {
+		if off >= len(data) || seen[off] {
+			return nil, fmt.Errorf("%w: bad cross-reference offset %d", ErrMalformed, off)
+		}
+		seen[off] = true
+		section, trailer, ok := bytes.Cut(data[off:], []byte("trailer"))
+		if !ok {
+			return nil, fmt.Errorf("%w: no trailer", ErrMalformed)
+		}
+		trailer, _, _ = bytes.Cut(trailer, []byte("startxref"))
+		if err := u.readSection(string(section)); err != nil {
+			return nil, err
+		}
+		if latest {
+			for _, key := range []struct {
+				name     string
+				v        *int
+				required bool
+			}{{"/Size", &u.size, true}, {"/Root", &u.Root, true}, {"/Info", &u.Info, false}} {
+				n, ok := trailerInt(trailer, key.name)
+				if !ok && key.required {
+					return nil, fmt.Errorf("%w: no %s in trailer", ErrMalformed, key.name)
+				}
+				*key.v = n
+			}
+			u.id = string(idPattern.Find(trailer))
+		}
+		if off, ok = trailerInt(trailer, "/Prev"); !ok {
+			return u, nil
+		}
+	}
+}
+
+// readSection reads a cross-reference section, keeping the offsets of
+// objects already read from a later section.
+func (u *Update) readSection(section string) error {
+	lines := strings.Split(strings.TrimSpace(section), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "xref" {
+		return fmt.Errorf("%w: no cross-reference table", ErrMalformed)
+	}
+	for i := 1; i < len(lines); {
+		f := strings.Fields(lines[i])
+		if len(f) != 2 {
+			return fmt.Errorf("%w: bad xref subsection %q", ErrMalformed, lines[i])
+		}
+		first, _ := strconv.Atoi(f[0])
+		count, _ := strconv.Atoi(f[1])
+		for j := range count {
+			if i+1+j >= len(lines) {
+				return fmt.Errorf("%w: short xref subsection", ErrMalformed)
+			}
+			if _, ok := u.offsets[first+j]; ok {
+				continue
+			}
+			entry := strings.Fields(lines[i+1+j])
+			if len(entry) == 3 && entry[2] == "n" {
+				u.offsets[first+j], _ = strconv.Atoi(entry[0])
+			} else {
+				u.offsets[first+j] = -1
+			}
+		}
+		i += 1 + count
+	}
+	return nil
+}
+
+// trailerInt returns the integer value of a trailer entry.
+func trailerInt(trailer []byte, name string) (int, bool) {
+	_, rest, ok := bytes.Cut(trailer, []byte(name+" "))
+	if !ok {
+		_, rest, ok = bytes.Cut(trailer, []byte(name+"\n"))
+	}
+	if !ok {
+		return 0, false
+	}
+	f := strings.Fields(string(rest))
+	if len(f) == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(f[0])
+	return n, err == nil
+}
+
+// Object returns the content of an object of the PDF, which must not be a
+// stream.
+func (u *Update) Object(n int) (string, error) {
+	if body, ok := u.objects[n]; ok {
+		return body, nil
+	}
+	off, ok := u.offsets[n]
+	if !ok || off < 0 || off >= len(u.data) {
+		return "", fmt.Errorf("%w: no object %d", ErrMalformed, n)
+	}
+	_, body, ok := bytes.Cut(u.data[off:], []byte(" obj"))
+	if !ok {
+		return "", fmt.Errorf("%w: bad object %d", ErrMalformed, n)
+	}
+	body, _, ok = bytes.Cut(body, []byte("endobj"))
+	if !ok {
+		return "", fmt.Errorf("%w: bad object %d", ErrMalformed, n)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// Stream returns the dictionary and the encoded data of a stream object.
+// For other objects, it returns their content and no data.
+func (u *Update) Stream(n int) (string, []byte, error) {
+	off, ok := u.offsets[n]
+	if _, set := u.objects[n]; set || !ok || off < 0 || off >= len(u.data) {
+		body, err := u.Object(n)
+		return body, nil, err
+	}
+	_, body, ok := bytes.Cut(u.data[off:], []byte(" obj"))
+	if !ok {
+		return "", nil, fmt.Errorf("%w: bad object %d", ErrMalformed, n)
+	}
+	end := bytes.Index(body, []byte("endobj"))
+	start := bytes.Index(body, []byte("stream"))
+	if start < 0 || end >= 0 && end < start {
+		body, err := u.Object(n)
+		return body, nil, err
+	}
+	dict := strings.TrimSpace(string(body[:start]))
+	m := lengthPattern.FindStringSubmatch(dict)
+	if m == nil {
+		return "", nil, fmt.Errorf("%w: stream %d has no length", ErrMalformed, n)
+	}
+	length, _ := strconv.Atoi(m[1])
+	if m[2] != "" {
+		v, err := u.Object(length)
+		if err != nil {
+			return "", nil, err
+		}
+		if length, err = strconv.Atoi(v); err != nil {
+			return "", nil, fmt.Errorf("%w: bad length of stream %d", ErrMalformed, n)
+		}
+	}
+	data := body[start+len("stream"):]
+	data = bytes.TrimPrefix(data, []byte("\r"))
+	data = bytes.TrimPrefix(data, []byte("\n"))
+	if length < 0 || length > len(data) {
+		return "", nil, fmt.Errorf("%w: bad length of stream %d", ErrMalformed, n)
+	}
+	return dict, data[:length], nil
+}
+
+// Extend returns the content of a dictionary object with entries added.
+func (u *Update) Extend(n int, entries string) (string, error) {
+	body, err := u.Object(n)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(body, "<<") {
+		return "", fmt.Errorf("%w: object %d is not a dictionary", ErrMalformed, n)
+	}
+	return "<<" + entries + "\n" + strings.TrimPrefix(body, "<<"), nil
+}
+
+// Pages returns the object numbers of the pages of the PDF in order,
+// descending the page tree.
+func (u *Update) Pages() ([]int, error) {
+	catalog, err := u.Object(u.Root)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := Ref(Dict(catalog)["Pages"])
+	if !ok {
+		return nil, fmt.Errorf("%w: no pages in catalog", ErrMalformed)
+	}
+	var pages []int
+	seen := make(map[int]bool)
+	var walk func(n int) error
+	walk = func(n int) error {
+		if seen[n] {
+			return fmt.Errorf("%w: page tree node %d is visited twice", ErrMalformed, n)
+		}
+		seen[n] = true
+		obj, err := u.Object(n)
+		if err != nil {
+			return err
+		}
+		node := Dict(obj)
+		if kids, ok := node["Kids"]; ok {
+			for _, kid := range Array(u.Resolve(kids)) {
+				k, ok := Ref(kid)
+				if !ok {
+					return fmt.Errorf("%w: bad kid %q of page tree node %d", ErrMalformed, kid, n)
+				}
+				if err := walk(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if node["Type"] != "/Page" {
+			return fmt.Errorf("%w: object %d is not a page", ErrMalformed, n)
+		}
+		pages = append(pages, n)
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// Reserve returns the number of a new object.
+func (u *Update) Reserve() int {
+	u.size++
+	return u.size - 1
+}
+
+// Set sets the content of a new or replaced object.
+func (u *Update) Set(n int, body string) {
+	u.objects[n] = body
+}
+
+// Bytes returns the PDF with the objects, cross-reference section and
+// trailer of the update appended.
+func (u *Update) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(u.data)
+	numbers := make([]int, 0, len(u.objects))
+	for n := range u.objects {
+		numbers = append(numbers, n)
+	}
+	slices.Sort(numbers)
+	offsets := make([]int, len(numbers))
+	for i, n := range numbers {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", n, u.objects[n])
+	}
+
+	xref := buf.Len()
+	buf.WriteString("xref\n")
+	for i := 0; i < len(numbers); {
+		j := i + 1
+		for j < len(numbers) && numbers[j] == numbers[j-1]+1 {
+			j++
+		}
+		fmt.Fprintf(&buf, "%d %d\n", numbers[i], j-i)
+		for _, off := range offsets[i:j] {
+			fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+		}
+		i = j
+	}
+	fmt.Fprintf(&buf, "trailer\n<<\n/Size %d\n/Root %d 0 R\n", u.size, u.Root)
+	if u.Info != 0 {
+		fmt.Fprintf(&buf, "/Info %d 0 R\n", u.Info)
+	}
+	if u.id != "" {
+		fmt.Fprintf(&buf, "%s\n", u.id)
+	}
+	fmt.Fprintf(&buf, "/Prev %d\n>>\nstartxref\n%d\n%%%%EOF\n", u.prev, xref)
+	return buf.Bytes()
+}
+
+// Text encodes text as a PDF text string in UTF-16.
+func Text(text string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, c := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&b, "%04X", c)
+	}
+	b.WriteString(">")
+	return b.String()
+}
@@ -4,17 +4,30 @@ import "errors"
 
 // Validation errors returned by invoice and line item validation.
 var (
-	ErrMissingInvoiceNumber = errors.New("invoice number is required")
-	ErrMissingIssueDate     = errors.New("issue date is required")
-	ErrMissingDueDate       = errors.New("due date is required")
-	ErrDueDateBeforeIssue   = errors.New("due date cannot be before issue date")
-	ErrMissingSupplier      = errors.New("supplier is required")
-	ErrMissingCustomer      = errors.New("customer is required")
-	ErrMissingPartyName     = errors.New("party name is required")
-	ErrNoLineItems          = errors.New("at least one line item is required")
-	ErrMissingDescription   = errors.New("line item description is required")
-	ErrInvalidQuantity      = errors.New("quantity must be positive")
-	ErrInvalidUnitPrice     = errors.New("unit price cannot be negative")
-	ErrInvalidTaxRate       = errors.New("tax rate cannot be negative")
-	ErrCurrencyMismatch     = errors.New("all amounts must use the same currency")
+	ErrMissingInvoiceNumber     = errors.New("invoice number is required")
+	ErrMissingIssueDate         = errors.New("issue date is required")
+	ErrMissingDueDate           = errors.New("due date is required")
+	ErrDueDateBeforeIssue       = errors.New("due date cannot be before issue date")
+	ErrMissingSupplier          = errors.New("supplier is required")
+	ErrMissingCustomer          = errors.New("customer is required")
+	ErrMissingPartyName         = errors.New("party name is required")
+	ErrNoLineItems              = errors.New("at least one line item is required")
+	ErrMissingDescription       = errors.New("line item description is required")
+	ErrInvalidQuantity          = errors.New("quantity must be positive")
+	ErrInvalidUnitPrice         = errors.New("unit price cannot be negative")
+	ErrInvalidTaxRate           = errors.New("tax rate cannot be negative")
+	ErrCurrencyMismatch         = errors.New("all amounts must use the same currency")
+	ErrInvalidWithholding       = errors.New("withholding rate must be between 0 and 100")
+	ErrInvalidRoundingIncrement = errors.New("rounding increment cannot be negative")
+	ErrMissingExchangeRate      = errors.New("exchange rate is required for the reporting currency")
+	ErrInvalidExchangeRate      = errors.New("exchange rate must convert the invoice currency to the reporting currency at a positive rate")
+	ErrInvalidSchedule          = errors.New("invalid payment schedule")
+	ErrInstalmentBeforeIssue    = errors.New("instalment due date must be after the issue date")
+	ErrInvalidPaymentTerms      = errors.New("invalid payment terms")
+)
+
+// Errors returned by Money arithmetic.
+var (
+	ErrInvalidRatios  = errors.New("allocation ratios must be non-negative and not all zero")
+	ErrDivisionByZero = errors.New("division by zero")
 )
@@ -0,0 +1,96 @@
+package invoice
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate converts amounts from one currency to another at a rate
+// published on a given date, e.g. 1 EUR = 0.9412 CHF.
+type ExchangeRate struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Rate   decimal.Decimal `json:"rate"`
+	Date   time.Time       `json:"date"`
+	Source string          `json:"source,omitempty"`
+}
+
+// Convert converts an amount in the rate's source currency. The result is
+// not rounded.
+func (r ExchangeRate) Convert(m Money) (Money, error) {
+	if m.Currency != r.From {
+		return Money{}, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.Currency, r.From)
+	}
+	return Money{Amount: m.Amount.Mul(r.Rate), Currency: r.To}, nil
+}
+
+// Inverse returns the rate converting in the opposite direction. It fails
+// for rates that are not valid, such as a zero rate.
+func (r ExchangeRate) Inverse() (ExchangeRate, error) {
+	if err := r.Validate(); err != nil {
+		return ExchangeRate{}, err
+	}
+	return ExchangeRate{From: r.To, To: r.From, Rate: decimal.NewFromInt(1).Div(r.Rate), Date: r.Date, Source: r.Source}, nil
+}
+
+// String returns the rate as "1 EUR = 0.9412 CHF (2025-03-31)".
+func (r ExchangeRate) String() string {
+	s := fmt.Sprintf("1 %s = %s %s", r.From, r.Rate.String(), r.To)
+	if !r.Date.IsZero() {
+		s += " (" + r.Date.Format("2006-01-02") + ")"
+	}
+	return s
+}
+
+// Validate checks that the rate is positive and names both currencies.
+func (r ExchangeRate) Validate() error {
+	if r.From == "" || r.To == "" || !r.Rate.IsPositive() {
+		return ErrInvalidExchangeRate
+	}
+	return nil
+}
+
+// HasReportingCurrency returns true if tax amounts must also be shown in a
+// reporting currency other than the invoice currency.
+func (inv *Invoice) HasReportingCurrency() bool {
+	return inv.ReportingCurrency != "" && inv.ReportingCurrency != inv.Currency
+}
+
+// ToReporting converts an amount in the invoice currency to the reporting
+// currency, rounded to the minor unit of that currency. Without a
+// reporting currency the amount is returned unchanged.
+func (inv *Invoice) ToReporting(m Money) Money {
+	if !inv.HasReportingCurrency() || inv.ExchangeRate == nil {
+		return m
+	}
+	converted, err := inv.ExchangeRate.Convert(m)
+	if err != nil {
+		return m
+	}
+	return converted.RoundToMinorUnit()
+}
+
+// TotalTaxReporting returns the total tax in the reporting currency, as the
+// sum of the converted per-rate tax amounts.
+func (inv *Invoice) TotalTaxReporting() Money {
+	total := Money{Amount: decimal.Zero, Currency: inv.ReportingCurrency}
+	if !inv.HasReportingCurrency() {
+		return inv.TotalTax()
+	}
+	for _, amount := range inv.TaxBreakdownReporting() {
+		total, _ = total.Add(amount)
+	}
+	return total
+}
+
+// TaxBreakdownReporting returns a map of tax rates to their total amounts
+// in the reporting currency.
+func (inv *Invoice) TaxBreakdownReporting() map[string]Money {
+	breakdown := inv.TaxBreakdown()
+	for rate, amount := range breakdown {
+		breakdown[rate] = inv.ToReporting(amount)
+	}
+	return breakdown
+}
@@ -1,6 +1,7 @@
 package invoice
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -18,20 +19,39 @@ const (
 	StatusOverdue   Status = "overdue"
 )
 
+// DocumentType distinguishes invoices from credit notes.
+type DocumentType string
+
+// Document type constants.
+const (
+	TypeInvoice    DocumentType = "invoice"
+	TypeCreditNote DocumentType = "credit_note"
+)
+
 // Invoice represents a complete invoice document.
 type Invoice struct {
-	Number      string     `json:"number"`
-	IssueDate   time.Time  `json:"issue_date"`
-	DueDate     time.Time  `json:"due_date"`
-	Currency    string     `json:"currency"`
-	CountryCode string     `json:"country_code"`
-	Supplier    Party      `json:"supplier"`
-	Customer    Party      `json:"customer"`
-	LineItems   []LineItem `json:"line_items"`
-	Notes       string     `json:"notes,omitempty"`
-	Terms       string     `json:"terms,omitempty"`
-	Status      Status     `json:"status"`
-	Metadata    Metadata   `json:"metadata,omitempty"`
+	Number            string          `json:"number"`
+	Type              DocumentType    `json:"type,omitempty"`
+	IssueDate         time.Time       `json:"issue_date"`
+	TaxPointDate      time.Time       `json:"tax_point_date,omitzero"`
+	DueDate           time.Time       `json:"due_date"`
+	Currency          string          `json:"currency"`
+	ReportingCurrency string          `json:"reporting_currency,omitempty"`
+	ExchangeRate      *ExchangeRate   `json:"exchange_rate,omitempty"`
+	CountryCode       string          `json:"country_code"`
+	Language          string          `json:"language,omitempty"`
+	Supplier          Party           `json:"supplier"`
+	Customer          Party           `json:"customer"`
+	LineItems         []LineItem      `json:"line_items"`
+	Withholdings      []Withholding   `json:"withholdings,omitempty"`
+	RoundingIncrement decimal.Decimal `json:"rounding_increment,omitzero"`
+	Instalments       []Instalment    `json:"instalments,omitempty"`
+	Notes             string          `json:"notes,omitempty"`
+	Terms             string          `json:"terms,omitempty"`
+	PaymentTerms      *PaymentTerms   `json:"payment_terms,omitempty"`
+	Status            Status          `json:"status"`
+	PaidDate          time.Time       `json:"paid_date,omitzero"`
+	Metadata          Metadata        `json:"metadata,omitempty"`
 }
 
 // Metadata stores arbitrary key-value pairs for an invoice.
@@ -39,7 +59,8 @@ type Metadata map[string]any
 
 // Builder provides a fluent interface for constructing invoices.
 type Builder struct {
-	inv Invoice
+	inv        Invoice
+	partyRules []PartyRule
 }
 
 // New creates a new invoice builder with default values.
@@ -59,12 +80,24 @@ func (b *Builder) Number(number string) *Builder {
 	return b
 }
 
+// Type sets the document type, e.g. TypeCreditNote.
+func (b *Builder) Type(t DocumentType) *Builder {
+	b.inv.Type = t
+	return b
+}
+
 // IssueDate sets the invoice issue date.
 func (b *Builder) IssueDate(date time.Time) *Builder {
 	b.inv.IssueDate = date
 	return b
 }
 
+// TaxPointDate sets the date of supply when it differs from the issue date.
+func (b *Builder) TaxPointDate(date time.Time) *Builder {
+	b.inv.TaxPointDate = date
+	return b
+}
+
 // DueDate sets the invoice due date.
 func (b *Builder) DueDate(date time.Time) *Builder {
 	b.inv.DueDate = date
@@ -77,12 +110,28 @@ func (b *Builder) Currency(currency string) *Builder {
 	return b
 }
 
+// ReportingCurrency sets the currency in which tax amounts must also be
+// shown, e.g. CHF for a EUR invoice of a Swiss supplier, and the rate
+// converting from the invoice currency.
+func (b *Builder) ReportingCurrency(code string, rate ExchangeRate) *Builder {
+	b.inv.ReportingCurrency = code
+	b.inv.ExchangeRate = &rate
+	return b
+}
+
 // CountryCode sets the invoice country code for tax purposes.
 func (b *Builder) CountryCode(code string) *Builder {
 	b.inv.CountryCode = code
 	return b
 }
 
+// Language sets the language of the invoice as a BCP 47 tag, e.g. "de" or
+// "fr-CH". It selects the wording of the payment terms.
+func (b *Builder) Language(lang string) *Builder {
+	b.inv.Language = lang
+	return b
+}
+
 // Supplier sets the invoice supplier (seller).
 func (b *Builder) Supplier(supplier Party) *Builder {
 	b.inv.Supplier = supplier
@@ -101,36 +150,92 @@ func (b *Builder) AddItem(item LineItem) *Builder {
 	return b
 }
 
+// AddWithholding adds a document level withholding tax applied to the
+// invoice's total net amount.
+func (b *Builder) AddWithholding(w Withholding) *Builder {
+	b.inv.Withholdings = append(b.inv.Withholdings, w)
+	return b
+}
+
+// RoundingIncrement sets the increment the amount payable is rounded to,
+// e.g. 0.05 for Swiss invoices. The difference is shown as a rounding line.
+func (b *Builder) RoundingIncrement(increment decimal.Decimal) *Builder {
+	b.inv.RoundingIncrement = increment
+	return b
+}
+
+// AddInstalment adds an instalment to the invoice's payment schedule.
+func (b *Builder) AddInstalment(i Instalment) *Builder {
+	b.inv.Instalments = append(b.inv.Instalments, i)
+	return b
+}
+
+// Instalments sets the invoice's payment schedule, e.g. from NewSchedule.
+func (b *Builder) Instalments(instalments ...Instalment) *Builder {
+	b.inv.Instalments = instalments
+	return b
+}
+
 // Notes sets additional notes on the invoice.
 func (b *Builder) Notes(notes string) *Builder {
 	b.inv.Notes = notes
 	return b
 }
 
-// Terms sets the payment terms on the invoice.
+// Terms sets the payment terms on the invoice as free text. It overrides
+// the wording of structured payment terms.
 func (b *Builder) Terms(terms string) *Builder {
 	b.inv.Terms = terms
 	return b
 }
 
+// PaymentTerms sets structured payment terms. Unless a due date is set
+// explicitly, it is derived from the issue date when the invoice is built.
+func (b *Builder) PaymentTerms(terms PaymentTerms) *Builder {
+	b.inv.PaymentTerms = &terms
+	return b
+}
+
 // Status sets the invoice status.
 func (b *Builder) Status(status Status) *Builder {
 	b.inv.Status = status
 	return b
 }
 
+// PaidDate sets the date the invoice was paid.
+func (b *Builder) PaidDate(date time.Time) *Builder {
+	b.inv.PaidDate = date
+	return b
+}
+
 // SetMetadata sets a metadata key-value pair.
 func (b *Builder) SetMetadata(key string, value any) *Builder {
 	b.inv.Metadata[key] = value
 	return b
 }
 
+// PartyRules adds optional validation rules applied to the supplier and
+// customer when the invoice is built.
+func (b *Builder) PartyRules(rules ...PartyRule) *Builder {
+	b.partyRules = append(b.partyRules, rules...)
+	return b
+}
+
 // Build validates and returns the constructed invoice.
 func (b *Builder) Build() (*Invoice, error) {
 	inv := b.inv
+	if inv.PaymentTerms != nil && inv.DueDate.IsZero() && !inv.IssueDate.IsZero() {
+		inv.DueDate = inv.PaymentTerms.DueDate(inv.IssueDate)
+	}
 	if err := inv.Validate(); err != nil {
 		return nil, err
 	}
+	if err := inv.Supplier.Validate(b.partyRules...); err != nil {
+		return nil, fmt.Errorf("supplier: %w", err)
+	}
+	if err := inv.Customer.Validate(b.partyRules...); err != nil {
+		return nil, fmt.Errorf("customer: %w", err)
+	}
 	inv.RecalculateTotals()
 	return &inv, nil
 }
@@ -166,9 +271,53 @@ func (inv *Invoice) Validate() error {
 			return ErrCurrencyMismatch
 		}
 	}
+	for _, w := range inv.Withholdings {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
+	if inv.RoundingIncrement.IsNegative() {
+		return ErrInvalidRoundingIncrement
+	}
+	if err := inv.validateSchedule(); err != nil {
+		return err
+	}
+	if inv.PaymentTerms != nil {
+		if err := inv.PaymentTerms.Validate(); err != nil {
+			return err
+		}
+		if !inv.PaymentTerms.LateFee.IsZero() && inv.PaymentTerms.LateFee.Currency != inv.Currency {
+			return ErrCurrencyMismatch
+		}
+	}
+	if inv.HasReportingCurrency() {
+		if inv.ExchangeRate == nil {
+			return ErrMissingExchangeRate
+		}
+		if err := inv.ExchangeRate.Validate(); err != nil {
+			return err
+		}
+		if inv.ExchangeRate.From != inv.Currency || inv.ExchangeRate.To != inv.ReportingCurrency {
+			return ErrInvalidExchangeRate
+		}
+	}
 	return nil
 }
 
+// IsCreditNote returns true if the document is a credit note.
+func (inv *Invoice) IsCreditNote() bool {
+	return inv.Type == TypeCreditNote
+}
+
+// TaxPoint returns the date that determines the VAT period, which is the
+// tax point date if set and the issue date otherwise.
+func (inv *Invoice) TaxPoint() time.Time {
+	if !inv.TaxPointDate.IsZero() {
+		return inv.TaxPointDate
+	}
+	return inv.IssueDate
+}
+
 // RecalculateTotals recalculates all invoice totals from line items.
 func (inv *Invoice) RecalculateTotals() {
 }
@@ -1,8 +1,11 @@
 package invoice
 
 import (
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 func TestInvoiceBuilder(t *testing.T) {
@@ -143,6 +146,21 @@ func TestLineItemWithDiscount(t *testing.T) {
 	}
 }
 
+func TestLineItemMetadata(t *testing.T) {
+	item := NewLineItem("Consulting", 8, NewMoney(120, "EUR"), 19).
+		WithSKU("CONS-01").
+		WithUnit("h").
+		WithMetadata("project", "Apollo")
+	other := item.WithMetadata("project", "Gemini")
+
+	if item.Metadata["project"] != "Apollo" {
+		t.Errorf("expected metadata of the original to be kept, got %q", item.Metadata["project"])
+	}
+	if other.Metadata["project"] != "Gemini" || other.SKU != "CONS-01" || other.Unit != "h" {
+		t.Errorf("unexpected copy %+v", other)
+	}
+}
+
 func TestInvoiceTotals(t *testing.T) {
 	inv, _ := New().
 		Number("INV-001").
@@ -197,3 +215,412 @@ func TestMoneyOperations(t *testing.T) {
 		t.Error("expected currency mismatch error")
 	}
 }
+
+func TestInvoiceWithholding(t *testing.T) {
+	inv, err := New().
+		Number("INV-001").
+		IssueDate(time.Now()).
+		DueDate(time.Now().AddDate(0, 0, 30)).
+		Currency("EUR").
+		Supplier(Party{Name: "S"}).
+		Customer(Party{Name: "C"}).
+		AddItem(NewLineItem("Consulting", 1, NewMoney(1000, "EUR"), 21).WithWithholding(NewWithholding("IRPF", 15))).
+		AddItem(NewLineItem("Travel", 1, NewMoney(200, "EUR"), 21)).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tax := inv.TotalTax(); tax.Float64() != 252 {
+		t.Errorf("expected tax 252, got %v", tax.Float64())
+	}
+	if withholding := inv.TotalWithholding(); withholding.Float64() != 150 {
+		t.Errorf("expected withholding 150, got %v", withholding.Float64())
+	}
+	if payable := inv.TotalPayable(); payable.Float64() != 1302 {
+		t.Errorf("expected payable 1302, got %v", payable.Float64())
+	}
+	if _, ok := inv.WithholdingBreakdown()["IRPF 15%"]; !ok {
+		t.Errorf("expected IRPF 15%% in breakdown, got %v", inv.WithholdingBreakdown())
+	}
+
+	// A document level withholding only applies to lines without their own.
+	inv, err = New().
+		Number("INV-003").
+		IssueDate(time.Now()).
+		DueDate(time.Now().AddDate(0, 0, 30)).
+		Currency("EUR").
+		Supplier(Party{Name: "S"}).
+		Customer(Party{Name: "C"}).
+		AddItem(NewLineItem("Consulting", 1, NewMoney(1000, "EUR"), 21).WithWithholding(NewWithholding("IRPF", 7))).
+		AddItem(NewLineItem("Training", 1, NewMoney(200, "EUR"), 21)).
+		AddWithholding(NewWithholding("IRPF", 15)).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if withholding := inv.TotalWithholding(); withholding.Float64() != 100 {
+		t.Errorf("expected withholding 70 + 30, got %v", withholding.Float64())
+	}
+	if payable := inv.TotalPayable(); payable.Float64() != 1352 {
+		t.Errorf("expected payable 1352, got %v", payable.Float64())
+	}
+
+	_, err = New().
+		Number("INV-002").
+		IssueDate(time.Now()).
+		DueDate(time.Now().AddDate(0, 0, 30)).
+		Currency("EUR").
+		Supplier(Party{Name: "S"}).
+		Customer(Party{Name: "C"}).
+		AddItem(NewLineItem("X", 1, NewMoney(100, "EUR"), 22)).
+		AddWithholding(NewWithholding("Ritenuta", -20)).
+		Build()
+	if err != ErrInvalidWithholding {
+		t.Errorf("expected ErrInvalidWithholding, got %v", err)
+	}
+}
+
+func TestInvoiceReportingCurrency(t *testing.T) {
+	rate := ExchangeRate{From: "EUR", To: "CHF", Rate: decimal.RequireFromString("0.9533"), Date: time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)}
+	inv, err := New().
+		Number("INV-001").
+		IssueDate(time.Now()).
+		DueDate(time.Now().AddDate(0, 0, 30)).
+		Currency("EUR").
+		ReportingCurrency("CHF", rate).
+		Supplier(Party{Name: "S"}).
+		Customer(Party{Name: "C"}).
+		AddItem(NewLineItem("Consulting", 10, NewMoney(150, "EUR"), 8.1)).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tax := inv.TotalTaxReporting(); tax.String() != "115.83 CHF" {
+		t.Errorf("expected tax 115.83 CHF, got %v", tax)
+	}
+	if got := rate.String(); got != "1 EUR = 0.9533 CHF (2025-03-31)" {
+		t.Errorf("unexpected rate string %q", got)
+	}
+
+	inverse, err := rate.Inverse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = New().
+		Number("INV-002").
+		IssueDate(time.Now()).
+		DueDate(time.Now().AddDate(0, 0, 30)).
+		Currency("EUR").
+		ReportingCurrency("CHF", inverse).
+		Supplier(Party{Name: "S"}).
+		Customer(Party{Name: "C"}).
+		AddItem(NewLineItem("X", 1, NewMoney(100, "EUR"), 8.1)).
+		Build()
+	if err != ErrInvalidExchangeRate {
+		t.Errorf("expected ErrInvalidExchangeRate, got %v", err)
+	}
+
+	if _, err := (ExchangeRate{From: "EUR", To: "CHF"}).Inverse(); err != ErrInvalidExchangeRate {
+		t.Errorf("expected ErrInvalidExchangeRate for a zero rate, got %v", err)
+	}
+
+	SetMinorUnits(func(code string) (int32, bool) { return 0, code == "JPY" })
+	defer SetMinorUnits(nil)
+	yen := ExchangeRate{From: "EUR", To: "JPY", Rate: decimal.RequireFromString("162.37")}
+	inv, err = New().
+		Number("INV-003").
+		IssueDate(time.Now()).
+		DueDate(time.Now().AddDate(0, 0, 30)).
+		Currency("EUR").
+		ReportingCurrency("JPY", yen).
+		Supplier(Party{Name: "S"}).
+		Customer(Party{Name: "C"}).
+		AddItem(NewLineItem("Consulting", 1, NewMoney(100, "EUR"), 10)).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tax := inv.TotalTaxReporting(); tax.Amount.String() != "1624" {
+		t.Errorf("expected 1624 JPY, got %v", tax.Amount)
+	}
+}
+
+func TestInvoiceRounding(t *testing.T) {
+	m := NewMoney(10.02, "CHF").RoundToIncrement(decimal.RequireFromString("0.05"))
+	if m.String() != "10.00 CHF" {
+		t.Errorf("expected 10.00 CHF, got %v", m)
+	}
+	m = NewMoney(-10.025, "CHF").RoundToIncrement(decimal.RequireFromString("0.05"))
+	if m.String() != "-10.05 CHF" {
+		t.Errorf("expected -10.05 CHF, got %v", m)
+	}
+
+	inv, err := New().
+		Number("INV-001").
+		IssueDate(time.Now()).
+		DueDate(time.Now().AddDate(0, 0, 30)).
+		Currency("CHF").
+		RoundingIncrement(decimal.RequireFromString("0.05")).
+		Supplier(Party{Name: "S"}).
+		Customer(Party{Name: "C"}).
+		AddItem(NewLineItem("Coffee", 3, NewMoney(4.20, "CHF"), 8.1)).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 12.60 + 8.1% = 13.6206
+	if got := inv.RoundingDifference(); got.String() != "-0.02 CHF" {
+		t.Errorf("expected rounding -0.02 CHF, got %v", got)
+	}
+	if got := inv.TotalPayable(); got.String() != "13.60 CHF" {
+		t.Errorf("expected payable 13.60 CHF, got %v", got)
+	}
+
+	// Amounts are rounded to fils before the increment, not to cents.
+	SetMinorUnits(func(code string) (int32, bool) { return 3, code == "KWD" })
+	defer SetMinorUnits(nil)
+	inv, err = New().
+		Number("INV-002").
+		IssueDate(time.Now()).
+		DueDate(time.Now().AddDate(0, 0, 30)).
+		Currency("KWD").
+		RoundingIncrement(decimal.RequireFromString("0.005")).
+		Supplier(Party{Name: "S"}).
+		Customer(Party{Name: "C"}).
+		AddItem(NewLineItem("Tea", 1, NewMoney(1.0026, "KWD"), 0)).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := inv.TotalPayable(); !got.Amount.Equal(decimal.RequireFromString("1.005")) {
+		t.Errorf("expected payable 1.005 KWD, got %v", got.Amount)
+	}
+	if got := inv.RoundingDifference(); !got.Amount.Equal(decimal.RequireFromString("0.002")) {
+		t.Errorf("expected rounding 0.002 KWD, got %v", got.Amount)
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	parts, err := NewMoney(100, "EUR").Split(3)
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	want := []string{"33.34", "33.33", "33.33"}
+	for i, p := range parts {
+		if p.Amount.StringFixed(2) != want[i] {
+			t.Errorf("Split[%d] = %s, want %s", i, p, want[i])
+		}
+	}
+	if MustSum(parts...).String() != "100.00 EUR" {
+		t.Errorf("Split parts sum to %s", MustSum(parts...))
+	}
+
+	parts, err = NewMoney(-0.05, "EUR").Allocate(decimal.NewFromInt(1), decimal.NewFromInt(1), decimal.Zero)
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if parts[0].String() != "-0.03 EUR" || parts[1].String() != "-0.02 EUR" || !parts[2].IsZero() {
+		t.Errorf("Allocate = %v", parts)
+	}
+
+	parts, _ = NewMoney(1000, "JPY").AllocatePlaces(0, decimal.NewFromInt(1), decimal.NewFromInt(2))
+	if !parts[0].Amount.Equal(decimal.NewFromInt(333)) || !parts[1].Amount.Equal(decimal.NewFromInt(667)) {
+		t.Errorf("AllocatePlaces = %v", parts)
+	}
+
+	if _, err := NewMoney(1, "EUR").Allocate(decimal.Zero); !errors.Is(err, ErrInvalidRatios) {
+		t.Errorf("Allocate(0) error = %v", err)
+	}
+	if _, err := NewMoney(1, "EUR").Div(decimal.Zero); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Div(0) error = %v", err)
+	}
+	if _, err := Sum(NewMoney(1, "EUR"), NewMoney(1, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sum error = %v", err)
+	}
+	if c := NewMoney(1, "EUR").MustCmp(NewMoney(2, "EUR")); c != -1 {
+		t.Errorf("Cmp = %d", c)
+	}
+	if m := NewMoney(-5, "EUR").Abs().Neg(); m.String() != "-5.00 EUR" {
+		t.Errorf("Abs().Neg() = %s", m)
+	}
+}
+
+func TestInvoicePaymentSchedule(t *testing.T) {
+	issue := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	schedule, err := NewSchedule("30/40/30", issue.AddDate(0, 0, 14), issue.AddDate(0, 1, 0), issue.AddDate(0, 2, 0))
+	if err != nil {
+		t.Fatalf("NewSchedule: %v", err)
+	}
+	build := func(instalments ...Instalment) (*Invoice, error) {
+		return New().
+			Number("INV-001").
+			IssueDate(issue).
+			DueDate(issue.AddDate(0, 2, 0)).
+			Currency("EUR").
+			Supplier(Party{Name: "Supplier"}).
+			Customer(Party{Name: "Customer"}).
+			AddItem(NewLineItem("Project", 1, NewMoney(1000.01, "EUR"), 0)).
+			Instalments(instalments...).
+			Build()
+	}
+
+	inv, err := build(schedule...)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	want := []string{"300.00 EUR", "400.01 EUR", "300.00 EUR"}
+	for i, instalment := range inv.PaymentSchedule() {
+		if instalment.Amount.String() != want[i] {
+			t.Errorf("instalment %d = %s, want %s", i, instalment.Amount, want[i])
+		}
+	}
+
+	deposit := NewFixedInstalment(NewMoney(200, "EUR"), issue.AddDate(0, 0, 1))
+	inv, err = build(deposit, NewPercentInstalment(50, issue.AddDate(0, 1, 0)), NewPercentInstalment(50, issue.AddDate(0, 2, 0)))
+	if err != nil {
+		t.Fatalf("Build with deposit: %v", err)
+	}
+	if got := inv.PaymentSchedule()[1].Amount.String(); got != "400.01 EUR" {
+		t.Errorf("instalment after deposit = %s, want 400.01 EUR", got)
+	}
+
+	inv, err = New().
+		Number("INV-002").
+		IssueDate(issue).
+		DueDate(issue.AddDate(0, 2, 0)).
+		Currency("EUR").
+		Supplier(Party{Name: "Supplier"}).
+		Customer(Party{Name: "Customer"}).
+		AddItem(NewLineItem("Project", 1, NewMoney(1000, "EUR"), 21)).
+		AddWithholding(NewWithholding("IRPF", 15)).
+		Instalments(NewPercentInstalment(50, issue.AddDate(0, 1, 0)), NewPercentInstalment(50, issue.AddDate(0, 2, 0))).
+		Build()
+	if err != nil {
+		t.Fatalf("Build with withholding: %v", err)
+	}
+	for i, instalment := range inv.PaymentSchedule() {
+		if instalment.Amount.String() != "530.00 EUR" {
+			t.Errorf("instalment %d with withholding = %s, want 530.00 EUR", i, instalment.Amount)
+		}
+	}
+
+	// Schedules are validated and allocated in fils for KWD.
+	SetMinorUnits(func(code string) (int32, bool) { return 3, code == "KWD" })
+	defer SetMinorUnits(nil)
+	kwd := func(instalments ...Instalment) (*Invoice, error) {
+		return New().
+			Number("INV-003").
+			IssueDate(issue).
+			DueDate(issue.AddDate(0, 2, 0)).
+			Currency("KWD").
+			Supplier(Party{Name: "Supplier"}).
+			Customer(Party{Name: "Customer"}).
+			AddItem(NewLineItem("Service", 1, NewMoney(1.234, "KWD"), 0)).
+			Instalments(instalments...).
+			Build()
+	}
+	if _, err := kwd(NewFixedInstalment(NewMoney(1.234, "KWD"), issue.AddDate(0, 1, 0))); err != nil {
+		t.Errorf("fixed instalment of the KWD total: %v", err)
+	}
+	inv, err = kwd(NewPercentInstalment(50, issue.AddDate(0, 1, 0)), NewPercentInstalment(50, issue.AddDate(0, 2, 0)))
+	if err != nil {
+		t.Fatalf("Build in KWD: %v", err)
+	}
+	for i, instalment := range inv.PaymentSchedule() {
+		if !instalment.Amount.Amount.Equal(decimal.RequireFromString("0.617")) {
+			t.Errorf("KWD instalment %d = %s, want 0.617", i, instalment.Amount.Amount)
+		}
+	}
+
+	if _, err := NewSchedule("30/40", issue, issue); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("NewSchedule(30/40) error = %v", err)
+	}
+	if _, err := build(NewPercentInstalment(100, issue)); !errors.Is(err, ErrInstalmentBeforeIssue) {
+		t.Errorf("instalment on issue date error = %v", err)
+	}
+	if _, err := build(NewFixedInstalment(NewMoney(500, "EUR"), issue.AddDate(0, 0, 1))); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("fixed instalments below total error = %v", err)
+	}
+}
+
+func TestInvoicePaymentTerms(t *testing.T) {
+	terms, err := ParsePaymentTerms("2/10 net 30")
+	if err != nil {
+		t.Fatalf("ParsePaymentTerms: %v", err)
+	}
+	issue := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	inv, err := New().
+		Number("INV-001").
+		IssueDate(issue).
+		Currency("EUR").
+		Language("de-AT").
+		Supplier(Party{Name: "Supplier"}).
+		Customer(Party{Name: "Customer"}).
+		AddItem(NewLineItem("Service", 1, NewMoney(1000, "EUR"), 0)).
+		PaymentTerms(terms.WithLateInterest(9.2)).
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if !inv.DueDate.Equal(time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("DueDate = %s", inv.DueDate)
+	}
+	if inv.DiscountedAmount().String() != "980.00 EUR" || !inv.DiscountDeadline().Equal(issue.AddDate(0, 0, 10)) {
+		t.Errorf("discount = %s by %s", inv.DiscountedAmount(), inv.DiscountDeadline())
+	}
+	want := "Zahlbar innerhalb von 30 Tagen netto bis 14.02.2025. " +
+		"Bei Zahlung innerhalb von 10 Tagen bis 25.01.2025 gewähren wir 2 % Skonto: 980.00 EUR. " +
+		"Bei Zahlungsverzug berechnen wir Verzugszinsen von 9.2 % p. a."
+	if got := inv.PaymentTermsText(); got != want {
+		t.Errorf("PaymentTermsText = %q, want %q", got, want)
+	}
+	inv.Terms = "Payable on receipt."
+	if got := inv.PaymentTermsText(); got != inv.Terms {
+		t.Errorf("PaymentTermsText with override = %q", got)
+	}
+
+	eom, _ := ParsePaymentTerms("net 30 EOM")
+	if due := eom.DueDate(issue); !due.Equal(time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("EOM DueDate = %s", due)
+	}
+	if _, err := ParsePaymentTerms("5/40 net 30"); !errors.Is(err, ErrInvalidPaymentTerms) {
+		t.Errorf("ParsePaymentTerms(5/40 net 30) error = %v", err)
+	}
+
+	// Discounts are rounded to whole yen and to fils.
+	SetMinorUnits(func(code string) (int32, bool) {
+		places := map[string]int32{"JPY": 0, "KWD": 3}[code]
+		return places, code == "JPY" || code == "KWD"
+	})
+	defer SetMinorUnits(nil)
+	for _, tt := range []struct {
+		amount               float64
+		currency             string
+		discount, discounted string
+	}{
+		{1234, "JPY", "25", "1209"},
+		{1.234, "KWD", "0.025", "1.209"},
+	} {
+		inv, err := New().
+			Number("INV-002").
+			IssueDate(issue).
+			Currency(tt.currency).
+			Supplier(Party{Name: "Supplier"}).
+			Customer(Party{Name: "Customer"}).
+			AddItem(NewLineItem("Service", 1, NewMoney(tt.amount, tt.currency), 0)).
+			PaymentTerms(terms).
+			Build()
+		if err != nil {
+			t.Fatalf("Build in %s: %v", tt.currency, err)
+		}
+		if got := inv.EarlyPaymentDiscount().Amount; !got.Equal(decimal.RequireFromString(tt.discount)) {
+			t.Errorf("EarlyPaymentDiscount in %s = %s, want %s", tt.currency, got, tt.discount)
+		}
+		if got := inv.DiscountedAmount().Amount; !got.Equal(decimal.RequireFromString(tt.discounted)) {
+			t.Errorf("DiscountedAmount in %s = %s, want %s", tt.currency, got, tt.discounted)
+		}
+	}
+}
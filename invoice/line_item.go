@@ -1,14 +1,29 @@
 package invoice
 
-import "github.com/shopspring/decimal"
+import (
+	"maps"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // LineItem represents a single item or service on an invoice.
 type LineItem struct {
-	Description string          `json:"description"`
-	Quantity    decimal.Decimal `json:"quantity"`
-	UnitPrice   Money           `json:"unit_price"`
-	TaxRate     decimal.Decimal `json:"tax_rate"`
-	Discount    decimal.Decimal `json:"discount,omitempty"`
+	Description  string          `json:"description"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	UnitPrice    Money           `json:"unit_price"`
+	TaxRate      decimal.Decimal `json:"tax_rate"`
+	Discount     decimal.Decimal `json:"discount,omitempty"`
+	TaxCategory  string          `json:"tax_category,omitempty"`
+	Withholdings []Withholding   `json:"withholdings,omitempty"`
+	SKU          string          `json:"sku,omitempty"`
+	// Unit is the unit of measure of the quantity, such as "h" or "kg".
+	Unit string `json:"unit,omitempty"`
+	// Date is the date the goods were delivered or the service performed.
+	Date time.Time `json:"date,omitzero"`
+	// Metadata holds additional values, which renderers can show in
+	// custom columns.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // NewLineItem creates a new line item with the given values.
@@ -28,6 +43,50 @@ func (li LineItem) WithDiscount(discountPercent float64) LineItem {
 	return li
 }
 
+// WithTaxCategory returns a copy of the line item assigned to a product
+// taxability category such as "clothing" or "digital_goods".
+func (li LineItem) WithTaxCategory(category string) LineItem {
+	li.TaxCategory = category
+	return li
+}
+
+// WithWithholding returns a copy of the line item with a withholding tax
+// applied to its net amount.
+func (li LineItem) WithWithholding(w Withholding) LineItem {
+	li.Withholdings = append(append([]Withholding(nil), li.Withholdings...), w)
+	return li
+}
+
+// WithSKU returns a copy of the line item with a stock keeping unit.
+func (li LineItem) WithSKU(sku string) LineItem {
+	li.SKU = sku
+	return li
+}
+
+// WithUnit returns a copy of the line item with a unit of measure.
+func (li LineItem) WithUnit(unit string) LineItem {
+	li.Unit = unit
+	return li
+}
+
+// WithDate returns a copy of the line item with a delivery or service
+// date.
+func (li LineItem) WithDate(date time.Time) LineItem {
+	li.Date = date
+	return li
+}
+
+// WithMetadata returns a copy of the line item with a metadata value set.
+func (li LineItem) WithMetadata(key, value string) LineItem {
+	metadata := maps.Clone(li.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	metadata[key] = value
+	li.Metadata = metadata
+	return li
+}
+
 // SubTotal returns the quantity times unit price before any discounts.
 func (li LineItem) SubTotal() Money {
 	amount := li.UnitPrice.Amount.Mul(li.Quantity)
@@ -67,6 +126,26 @@ func (li LineItem) GrossAmount() Money {
 	return gross
 }
 
+// withholds reports whether the line item carries the same withholding
+// tax as w.
+func (li LineItem) withholds(w Withholding) bool {
+	for _, own := range li.Withholdings {
+		if own.sameTax(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithholdingAmount returns the total withholding tax for this line item.
+func (li LineItem) WithholdingAmount() Money {
+	total := Money{Amount: decimal.Zero, Currency: li.UnitPrice.Currency}
+	for _, w := range li.Withholdings {
+		total, _ = total.Add(w.Calculate(li.NetAmount()))
+	}
+	return total
+}
+
 // Validate checks that the line item has all required fields.
 func (li LineItem) Validate() error {
 	if li.Description == "" {
@@ -81,5 +160,10 @@ func (li LineItem) Validate() error {
 	if li.TaxRate.IsNegative() {
 		return ErrInvalidTaxRate
 	}
+	for _, w := range li.Withholdings {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -3,6 +3,8 @@ package invoice
 
 import (
 	"fmt"
+	"sort"
+	"sync/atomic"
 
 	"github.com/shopspring/decimal"
 )
@@ -30,27 +32,118 @@ func NewMoneyFromString(amount string, currency string) (Money, error) {
 	return Money{Amount: d, Currency: currency}, nil
 }
 
+// sameCurrency returns ErrCurrencyMismatch if other uses a different
+// currency.
+func (m Money) sameCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
 // Add adds two Money values with the same currency.
 func (m Money) Add(other Money) (Money, error) {
-	if m.Currency != other.Currency {
-		return Money{}, fmt.Errorf("currency mismatch: %s vs %s", m.Currency, other.Currency)
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
 	}
 	return Money{Amount: m.Amount.Add(other.Amount), Currency: m.Currency}, nil
 }
 
 // Sub subtracts another Money value from this one.
 func (m Money) Sub(other Money) (Money, error) {
-	if m.Currency != other.Currency {
-		return Money{}, fmt.Errorf("currency mismatch: %s vs %s", m.Currency, other.Currency)
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
 	}
 	return Money{Amount: m.Amount.Sub(other.Amount), Currency: m.Currency}, nil
 }
 
-// Mul multiplies the Money value by a decimal factor.
+// MustAdd is like Add but panics on a currency mismatch.
+func (m Money) MustAdd(other Money) Money {
+	sum, err := m.Add(other)
+	if err != nil {
+		panic(err)
+	}
+	return sum
+}
+
+// MustSub is like Sub but panics on a currency mismatch.
+func (m Money) MustSub(other Money) Money {
+	diff, err := m.Sub(other)
+	if err != nil {
+		panic(err)
+	}
+	return diff
+}
+
+// Sum adds Money values of the same currency. The sum of no values is the
+// zero Money value.
+func Sum(amounts ...Money) (Money, error) {
+	if len(amounts) == 0 {
+		return Money{Amount: decimal.Zero}, nil
+	}
+	total := amounts[0]
+	for _, m := range amounts[1:] {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// MustSum is like Sum but panics on a currency mismatch.
+func MustSum(amounts ...Money) Money {
+	total, err := Sum(amounts...)
+	if err != nil {
+		panic(err)
+	}
+	return total
+}
+
+// Cmp compares two Money values of the same currency and returns -1, 0 or
+// +1 like decimal.Decimal.Cmp.
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	return m.Amount.Cmp(other.Amount), nil
+}
+
+// MustCmp is like Cmp but panics on a currency mismatch.
+func (m Money) MustCmp(other Money) int {
+	c, err := m.Cmp(other)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Neg returns the amount with the opposite sign.
+func (m Money) Neg() Money {
+	return Money{Amount: m.Amount.Neg(), Currency: m.Currency}
+}
+
+// Abs returns the absolute amount.
+func (m Money) Abs() Money {
+	return Money{Amount: m.Amount.Abs(), Currency: m.Currency}
+}
+
+// Mul multiplies the Money value by a decimal factor. The result keeps full
+// precision; use Round, or Allocate to distribute an amount in shares.
 func (m Money) Mul(factor decimal.Decimal) Money {
 	return Money{Amount: m.Amount.Mul(factor), Currency: m.Currency}
 }
 
+// Div divides the Money value by a decimal divisor, with the precision of
+// decimal.DivisionPrecision. It returns ErrDivisionByZero for a zero
+// divisor.
+func (m Money) Div(divisor decimal.Decimal) (Money, error) {
+	if divisor.IsZero() {
+		return Money{}, ErrDivisionByZero
+	}
+	return Money{Amount: m.Amount.Div(divisor), Currency: m.Currency}, nil
+}
+
 // MulFloat multiplies the Money value by a float64 factor.
 func (m Money) MulFloat(factor float64) Money {
 	return m.Mul(decimal.NewFromFloat(factor))
@@ -61,6 +154,129 @@ func (m Money) Round(places int32) Money {
 	return Money{Amount: m.Amount.Round(places), Currency: m.Currency}
 }
 
+// RoundToMinorUnit rounds the amount to the minor unit of its currency,
+// e.g. to cents for EUR, whole yen for JPY and fils for KWD.
+func (m Money) RoundToMinorUnit() Money {
+	return m.Round(MinorUnits(m.Currency))
+}
+
+// minorUnits is the lookup set with SetMinorUnits.
+var minorUnits atomic.Pointer[func(code string) (int32, bool)]
+
+// SetMinorUnits sets the lookup of the number of decimal places of a
+// currency. The currency package sets its ISO 4217 table, which includes
+// the currencies added with its Register function, when it is imported.
+// A nil lookup removes the lookup.
+func SetMinorUnits(lookup func(code string) (int32, bool)) {
+	if lookup == nil {
+		minorUnits.Store(nil)
+		return
+	}
+	minorUnits.Store(&lookup)
+}
+
+// MinorUnits returns the number of decimal places of a currency, or 2 if
+// the currency is unknown or no lookup has been set.
+func MinorUnits(code string) int32 {
+	if lookup := minorUnits.Load(); lookup != nil {
+		if places, ok := (*lookup)(code); ok {
+			return places
+		}
+	}
+	return 2
+}
+
+// RoundToIncrement rounds the amount to the nearest multiple of the
+// increment, e.g. 0.05 for Swiss cash rounding. Halves are rounded away
+// from zero. A zero or negative increment leaves the amount unchanged.
+func (m Money) RoundToIncrement(increment decimal.Decimal) Money {
+	if !increment.IsPositive() {
+		return m
+	}
+	steps := m.Amount.Div(increment).Round(0)
+	return Money{Amount: steps.Mul(increment), Currency: m.Currency}
+}
+
+// Allocate distributes the amount in proportion to the ratios, in cents,
+// so that the shares add up to exactly the original amount. Cents left
+// over after rounding down go to the shares with the largest remainders,
+// earlier shares first on ties. An amount with more than two decimal
+// places is distributed at its own precision.
+func (m Money) Allocate(ratios ...decimal.Decimal) ([]Money, error) {
+	return m.AllocatePlaces(2, ratios...)
+}
+
+// AllocatePlaces is like Allocate but distributes the amount in units of
+// the given number of decimal places, e.g. 0 for JPY or 3 for KWD.
+func (m Money) AllocatePlaces(places int32, ratios ...decimal.Decimal) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("%w: no ratios", ErrInvalidRatios)
+	}
+	total := decimal.Zero
+	for _, r := range ratios {
+		if r.IsNegative() {
+			return nil, fmt.Errorf("%w: negative ratio %s", ErrInvalidRatios, r.String())
+		}
+		total = total.Add(r)
+	}
+	if total.IsZero() {
+		return nil, fmt.Errorf("%w: ratios add up to zero", ErrInvalidRatios)
+	}
+	if exp := -m.Amount.Exponent(); exp > places {
+		places = exp
+	}
+
+	// Work in whole units of the smallest place on the absolute amount.
+	units := m.Amount.Abs().Shift(places)
+	shares := make([]decimal.Decimal, len(ratios))
+	remainders := make([]decimal.Decimal, len(ratios))
+	left := units
+	for i, r := range ratios {
+		exact := units.Mul(r).Div(total)
+		shares[i] = exact.Floor()
+		remainders[i] = exact.Sub(shares[i])
+		left = left.Sub(shares[i])
+	}
+	order := make([]int, len(ratios))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].GreaterThan(remainders[order[b]])
+	})
+	for _, i := range order {
+		if !left.IsPositive() {
+			break
+		}
+		shares[i] = shares[i].Add(decimal.NewFromInt(1))
+		left = left.Sub(decimal.NewFromInt(1))
+	}
+
+	result := make([]Money, len(shares))
+	for i, share := range shares {
+		amount := share.Shift(-places)
+		if m.Amount.IsNegative() {
+			amount = amount.Neg()
+		}
+		result[i] = Money{Amount: amount, Currency: m.Currency}
+	}
+	return result, nil
+}
+
+// Split divides the amount into n shares that differ by at most one cent
+// and add up to exactly the original amount, e.g. 100.00 into 33.34,
+// 33.33 and 33.33.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: cannot split into %d parts", ErrInvalidRatios, n)
+	}
+	ratios := make([]decimal.Decimal, n)
+	for i := range ratios {
+		ratios[i] = decimal.NewFromInt(1)
+	}
+	return m.Allocate(ratios...)
+}
+
 // IsZero returns true if the amount is zero.
 func (m Money) IsZero() bool {
 	return m.Amount.IsZero()
@@ -1,5 +1,10 @@
 package invoice
 
+import (
+	"strings"
+	"time"
+)
+
 // Address represents a postal address.
 type Address struct {
 	Street     string `json:"street"`
@@ -39,20 +44,85 @@ func (a Address) Lines() []string {
 	return lines
 }
 
+// TaxExemption represents a sales tax exemption certificate held by a party.
+type TaxExemption struct {
+	CertificateID string    `json:"certificate_id"`
+	Jurisdiction  string    `json:"jurisdiction"`
+	Reason        string    `json:"reason,omitempty"`
+	Categories    []string  `json:"categories,omitempty"`
+	ExpiresOn     time.Time `json:"expires_on,omitzero"`
+}
+
+// Covers returns true if the exemption applies to the given jurisdiction,
+// product category and date. An exemption without categories covers all
+// categories, and one without an expiry date never expires.
+func (e TaxExemption) Covers(jurisdiction, category string, on time.Time) bool {
+	if !strings.EqualFold(e.Jurisdiction, jurisdiction) {
+		return false
+	}
+	if !e.ExpiresOn.IsZero() && on.After(e.ExpiresOn) {
+		return false
+	}
+	if len(e.Categories) == 0 {
+		return true
+	}
+	for _, c := range e.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
 // Party represents a business entity (supplier or customer).
 type Party struct {
-	Name    string  `json:"name"`
-	Address Address `json:"address"`
-	Email   string  `json:"email,omitempty"`
-	Phone   string  `json:"phone,omitempty"`
-	VATID   string  `json:"vat_id,omitempty"`
-	IBAN    string  `json:"iban,omitempty"`
+	Name          string         `json:"name"`
+	Address       Address        `json:"address"`
+	ShipTo        *Address       `json:"ship_to,omitempty"`
+	Email         string         `json:"email,omitempty"`
+	Phone         string         `json:"phone,omitempty"`
+	VATID         string         `json:"vat_id,omitempty"`
+	IBAN          string         `json:"iban,omitempty"`
+	BIC           string         `json:"bic,omitempty"`
+	TaxExemptions []TaxExemption `json:"tax_exemptions,omitempty"`
+	// Logo is the file name or data URI of the party's logo image.
+	Logo string `json:"logo,omitempty"`
 }
 
-// Validate checks that the party has all required fields.
-func (p Party) Validate() error {
+// ShippingAddress returns the ship-to address, falling back to the
+// party's main address when no separate ship-to address is set.
+func (p Party) ShippingAddress() Address {
+	if p.ShipTo != nil && !p.ShipTo.IsEmpty() {
+		return *p.ShipTo
+	}
+	return p.Address
+}
+
+// Exemption returns the first exemption certificate covering the given
+// jurisdiction, product category and date.
+func (p Party) Exemption(jurisdiction, category string, on time.Time) (TaxExemption, bool) {
+	for _, e := range p.TaxExemptions {
+		if e.Covers(jurisdiction, category, on) {
+			return e, true
+		}
+	}
+	return TaxExemption{}, false
+}
+
+// PartyRule is an optional validation rule applied to a party in addition
+// to the required field checks.
+type PartyRule func(Party) error
+
+// Validate checks that the party has all required fields and satisfies
+// any additional rules.
+func (p Party) Validate(rules ...PartyRule) error {
 	if p.Name == "" {
 		return ErrMissingPartyName
 	}
+	for _, rule := range rules {
+		if err := rule(p); err != nil {
+			return err
+		}
+	}
 	return nil
 }
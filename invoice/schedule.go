@@ -0,0 +1,160 @@
+package invoice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Instalment is one payment of an invoice's payment schedule. An instalment
+// is either a percentage of the amount left after fixed instalments or a
+// fixed amount.
+type Instalment struct {
+	DueDate     time.Time       `json:"due_date"`
+	Percent     decimal.Decimal `json:"percent,omitzero"`
+	Amount      Money           `json:"amount,omitzero"`
+	Description string          `json:"description,omitempty"`
+}
+
+// NewPercentInstalment creates an instalment of the given percentage.
+func NewPercentInstalment(percent float64, dueDate time.Time) Instalment {
+	return Instalment{DueDate: dueDate, Percent: decimal.NewFromFloat(percent)}
+}
+
+// NewFixedInstalment creates an instalment of a fixed amount, e.g. a
+// deposit.
+func NewFixedInstalment(amount Money, dueDate time.Time) Instalment {
+	return Instalment{DueDate: dueDate, Amount: amount}
+}
+
+// NewSchedule creates percentage instalments from a template such as
+// "30/40/30", with one due date per instalment. The percentages must add
+// up to 100.
+func NewSchedule(template string, dueDates ...time.Time) ([]Instalment, error) {
+	parts := strings.Split(template, "/")
+	if len(parts) != len(dueDates) {
+		return nil, fmt.Errorf("%w: template %q has %d instalments but %d due dates were given",
+			ErrInvalidSchedule, template, len(parts), len(dueDates))
+	}
+	instalments := make([]Instalment, len(parts))
+	total := decimal.Zero
+	for i, part := range parts {
+		percent, err := decimal.NewFromString(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "%")))
+		if err != nil || !percent.IsPositive() {
+			return nil, fmt.Errorf("%w: invalid percentage %q in template %q", ErrInvalidSchedule, part, template)
+		}
+		instalments[i] = Instalment{DueDate: dueDates[i], Percent: percent}
+		total = total.Add(percent)
+	}
+	if !total.Equal(decimal.NewFromInt(100)) {
+		return nil, fmt.Errorf("%w: template %q adds up to %s%%", ErrInvalidSchedule, template, total.String())
+	}
+	return instalments, nil
+}
+
+// IsFixed returns true if the instalment is a fixed amount.
+func (i Instalment) IsFixed() bool {
+	return i.Percent.IsZero()
+}
+
+// Label returns a display label such as "30%" or the description if set.
+func (i Instalment) Label() string {
+	switch {
+	case i.Description != "":
+		return i.Description
+	case i.IsFixed():
+		return "Fixed"
+	default:
+		return i.Percent.String() + "%"
+	}
+}
+
+// Validate checks that the instalment has either a positive percentage or
+// a positive fixed amount.
+func (i Instalment) Validate() error {
+	if i.DueDate.IsZero() {
+		return fmt.Errorf("%w: due date is required", ErrInvalidSchedule)
+	}
+	if i.Percent.IsNegative() || (i.IsFixed() && !i.Amount.Amount.IsPositive()) {
+		return fmt.Errorf("%w: instalment due %s needs a positive percentage or amount",
+			ErrInvalidSchedule, i.DueDate.Format("2006-01-02"))
+	}
+	if !i.IsFixed() && !i.Amount.IsZero() {
+		return fmt.Errorf("%w: instalment due %s has both a percentage and an amount",
+			ErrInvalidSchedule, i.DueDate.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// validateSchedule checks the instalments against the invoice: due dates
+// after the issue date, fixed amounts in the invoice currency that do not
+// exceed the amount payable, and percentages adding up to 100.
+func (inv *Invoice) validateSchedule() error {
+	if len(inv.Instalments) == 0 {
+		return nil
+	}
+	fixed := Money{Amount: decimal.Zero, Currency: inv.Currency}
+	percent := decimal.Zero
+	for _, i := range inv.Instalments {
+		if err := i.Validate(); err != nil {
+			return err
+		}
+		if !i.DueDate.After(inv.IssueDate) {
+			return fmt.Errorf("%w: %s", ErrInstalmentBeforeIssue, i.DueDate.Format("2006-01-02"))
+		}
+		if i.IsFixed() {
+			var err error
+			if fixed, err = fixed.Add(i.Amount); err != nil {
+				return err
+			}
+		} else {
+			percent = percent.Add(i.Percent)
+		}
+	}
+	total := inv.TotalPayable().RoundToMinorUnit()
+	switch c := fixed.Amount.Cmp(total.Amount); {
+	case c > 0:
+		return fmt.Errorf("%w: fixed instalments of %s exceed the total of %s", ErrInvalidSchedule, fixed, total)
+	case percent.IsZero() && c != 0:
+		return fmt.Errorf("%w: fixed instalments of %s do not add up to the total of %s", ErrInvalidSchedule, fixed, total)
+	case !percent.IsZero() && !percent.Equal(decimal.NewFromInt(100)):
+		return fmt.Errorf("%w: percentages add up to %s%%", ErrInvalidSchedule, percent.String())
+	}
+	return nil
+}
+
+// PaymentSchedule returns the instalments with their amounts. Fixed
+// instalments keep their amount and the remainder of the amount payable
+// is allocated to the percentage instalments in the minor unit of the
+// currency, so that the instalments add up to exactly the amount due.
+// Withholding taxes and cash rounding are thus taken into account.
+func (inv *Invoice) PaymentSchedule() []Instalment {
+	schedule := make([]Instalment, len(inv.Instalments))
+	copy(schedule, inv.Instalments)
+
+	places := MinorUnits(inv.Currency)
+	remaining := inv.TotalPayable().Round(places)
+	var ratios []decimal.Decimal
+	for _, i := range schedule {
+		if i.IsFixed() {
+			remaining, _ = remaining.Sub(i.Amount)
+		} else {
+			ratios = append(ratios, i.Percent)
+		}
+	}
+	if len(ratios) == 0 {
+		return schedule
+	}
+	shares, err := remaining.AllocatePlaces(places, ratios...)
+	if err != nil {
+		return schedule
+	}
+	for i := range schedule {
+		if !schedule[i].IsFixed() {
+			schedule[i].Amount, shares = shares[0], shares[1:]
+		}
+	}
+	return schedule
+}
@@ -0,0 +1,272 @@
+package invoice
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PaymentTerms are structured payment terms from which the due date, the
+// early-payment discount (skonto) and the terms wording are derived.
+type PaymentTerms struct {
+	// NetDays is the number of days after the issue date, or after the end
+	// of the issue month if EndOfMonth is set, until payment is due.
+	NetDays    int  `json:"net_days"`
+	EndOfMonth bool `json:"end_of_month,omitempty"`
+	// DiscountPercent is granted if payment is received within
+	// DiscountDays of the issue date, e.g. 2% within 10 days.
+	DiscountPercent decimal.Decimal `json:"discount_percent,omitzero"`
+	DiscountDays    int             `json:"discount_days,omitempty"`
+	// LateInterest is the annual interest rate in percent charged on late
+	// payments, and LateFee a fixed fee for recovery costs.
+	LateInterest decimal.Decimal `json:"late_interest,omitzero"`
+	LateFee      Money           `json:"late_fee,omitzero"`
+}
+
+// NetTerms creates payment terms due the given number of days after the
+// issue date.
+func NetTerms(days int) PaymentTerms {
+	return PaymentTerms{NetDays: days}
+}
+
+// WithDiscount adds an early-payment discount of percent within days.
+func (t PaymentTerms) WithDiscount(percent float64, days int) PaymentTerms {
+	t.DiscountPercent = decimal.NewFromFloat(percent)
+	t.DiscountDays = days
+	return t
+}
+
+// WithLateInterest sets the annual interest rate charged on late payments.
+func (t PaymentTerms) WithLateInterest(percent float64) PaymentTerms {
+	t.LateInterest = decimal.NewFromFloat(percent)
+	return t
+}
+
+// WithLateFee sets a fixed fee charged on late payments.
+func (t PaymentTerms) WithLateFee(fee Money) PaymentTerms {
+	t.LateFee = fee
+	return t
+}
+
+var termsPattern = regexp.MustCompile(`^(?:(\d+(?:\.\d+)?)\s*%?\s*/\s*(\d+)\s*,?\s*)?(?:net|n)\s*/?\s*(\d+)(?:\s*(eom|end of month))?$`)
+
+// ParsePaymentTerms parses the common shorthand for payment terms, such as
+// "net 30", "2/10 net 30", "2/10 n/30" or "net 30 EOM".
+func ParsePaymentTerms(s string) (PaymentTerms, error) {
+	m := termsPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return PaymentTerms{}, fmt.Errorf("%w: %q", ErrInvalidPaymentTerms, s)
+	}
+	var t PaymentTerms
+	t.NetDays, _ = strconv.Atoi(m[3])
+	t.EndOfMonth = m[4] != ""
+	if m[1] != "" {
+		t.DiscountPercent, _ = decimal.NewFromString(m[1])
+		t.DiscountDays, _ = strconv.Atoi(m[2])
+	}
+	if err := t.Validate(); err != nil {
+		return PaymentTerms{}, err
+	}
+	return t, nil
+}
+
+// HasDiscount returns true if the terms grant an early-payment discount.
+func (t PaymentTerms) HasDiscount() bool {
+	return t.DiscountPercent.IsPositive()
+}
+
+// DueDate returns the date payment is due for an invoice issued on the
+// given date.
+func (t PaymentTerms) DueDate(issue time.Time) time.Time {
+	if t.EndOfMonth {
+		endOfMonth := time.Date(issue.Year(), issue.Month()+1, 0, 0, 0, 0, 0, issue.Location())
+		return endOfMonth.AddDate(0, 0, t.NetDays)
+	}
+	return issue.AddDate(0, 0, t.NetDays)
+}
+
+// DiscountDeadline returns the last day payment qualifies for the
+// early-payment discount, or the zero time if there is no discount.
+func (t PaymentTerms) DiscountDeadline(issue time.Time) time.Time {
+	if !t.HasDiscount() {
+		return time.Time{}
+	}
+	return issue.AddDate(0, 0, t.DiscountDays)
+}
+
+// Validate checks that the terms are consistent.
+func (t PaymentTerms) Validate() error {
+	switch {
+	case t.NetDays < 0 || t.DiscountDays < 0:
+		return fmt.Errorf("%w: days cannot be negative", ErrInvalidPaymentTerms)
+	case t.DiscountPercent.IsNegative() || t.DiscountPercent.GreaterThanOrEqual(decimal.NewFromInt(100)):
+		return fmt.Errorf("%w: discount must be between 0 and 100%%", ErrInvalidPaymentTerms)
+	case t.HasDiscount() != (t.DiscountDays > 0):
+		return fmt.Errorf("%w: a discount needs both a percentage and a number of days", ErrInvalidPaymentTerms)
+	case t.HasDiscount() && !t.EndOfMonth && t.DiscountDays > t.NetDays:
+		return fmt.Errorf("%w: discount period exceeds the payment period", ErrInvalidPaymentTerms)
+	case t.LateInterest.IsNegative() || t.LateFee.IsNegative():
+		return fmt.Errorf("%w: late payment interest and fee cannot be negative", ErrInvalidPaymentTerms)
+	}
+	return nil
+}
+
+// termsWording holds the standard payment terms sentences of a language.
+// Net sentences take the days and the due date, the discount sentence the
+// percentage, days, deadline and discounted amount.
+type termsWording struct {
+	dateLayout    string
+	immediate     string
+	net           string
+	endOfMonth    string
+	netEndOfMonth string
+	discount      string
+	interest      string
+	fee           string
+}
+
+var termsWordings = map[string]termsWording{
+	"en": {
+		dateLayout:    "January 2, 2006",
+		immediate:     "Payable immediately, due %[2]s.",
+		net:           "Payable within %[1]d days net, due %[2]s.",
+		endOfMonth:    "Payable by the end of the month, due %[2]s.",
+		netEndOfMonth: "Payable within %[1]d days after the end of the month, due %[2]s.",
+		discount:      "%[1]s%% discount if paid within %[2]d days, by %[3]s: %[4]s.",
+		interest:      "Late payments incur interest of %s%% per year.",
+		fee:           "A late payment fee of %s applies.",
+	},
+	"de": {
+		dateLayout:    "02.01.2006",
+		immediate:     "Zahlbar sofort, fällig am %[2]s.",
+		net:           "Zahlbar innerhalb von %[1]d Tagen netto bis %[2]s.",
+		endOfMonth:    "Zahlbar bis Monatsende, fällig am %[2]s.",
+		netEndOfMonth: "Zahlbar innerhalb von %[1]d Tagen nach Monatsende bis %[2]s.",
+		discount:      "Bei Zahlung innerhalb von %[2]d Tagen bis %[3]s gewähren wir %[1]s %% Skonto: %[4]s.",
+		interest:      "Bei Zahlungsverzug berechnen wir Verzugszinsen von %s %% p. a.",
+		fee:           "Zusätzlich wird eine Verzugspauschale von %s fällig.",
+	},
+	"fr": {
+		dateLayout:    "02/01/2006",
+		immediate:     "Payable à réception, au plus tard le %[2]s.",
+		net:           "Payable à %[1]d jours net, au plus tard le %[2]s.",
+		endOfMonth:    "Payable en fin de mois, au plus tard le %[2]s.",
+		netEndOfMonth: "Payable à %[1]d jours fin de mois, au plus tard le %[2]s.",
+		discount:      "Escompte de %[1]s %% pour paiement sous %[2]d jours, avant le %[3]s : %[4]s.",
+		interest:      "Pénalités de retard au taux annuel de %s %%.",
+		fee:           "Indemnité forfaitaire pour frais de recouvrement : %s.",
+	},
+	"it": {
+		dateLayout:    "02/01/2006",
+		immediate:     "Pagamento immediato, entro il %[2]s.",
+		net:           "Pagamento a %[1]d giorni netti, entro il %[2]s.",
+		endOfMonth:    "Pagamento a fine mese, entro il %[2]s.",
+		netEndOfMonth: "Pagamento a %[1]d giorni fine mese, entro il %[2]s.",
+		discount:      "Sconto del %[1]s%% per pagamento entro %[2]d giorni, entro il %[3]s: %[4]s.",
+		interest:      "In caso di ritardo si applicano interessi di mora del %s%% annuo.",
+		fee:           "Indennizzo per i costi di recupero: %s.",
+	},
+	"es": {
+		dateLayout:    "02/01/2006",
+		immediate:     "Pago al contado, vencimiento el %[2]s.",
+		net:           "Pago a %[1]d días netos, vencimiento el %[2]s.",
+		endOfMonth:    "Pago a fin de mes, vencimiento el %[2]s.",
+		netEndOfMonth: "Pago a %[1]d días fin de mes, vencimiento el %[2]s.",
+		discount:      "Descuento del %[1]s %% por pronto pago dentro de %[2]d días, hasta el %[3]s: %[4]s.",
+		interest:      "Los pagos atrasados devengan intereses de demora del %s %% anual.",
+		fee:           "Se aplicará una indemnización por costes de cobro de %s.",
+	},
+}
+
+// wordingFor returns the wording for a language tag such as "de-CH",
+// falling back to English.
+func wordingFor(lang string) termsWording {
+	base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+	base, _, _ = strings.Cut(base, "_")
+	if w, ok := termsWordings[base]; ok {
+		return w
+	}
+	return termsWordings["en"]
+}
+
+// Text returns the standard wording of the terms in the given language
+// (English, German, French, Italian or Spanish) for an invoice issued on
+// the given date and payable amount.
+func (t PaymentTerms) Text(lang string, issue time.Time, payable Money) string {
+	w := wordingFor(lang)
+	due := t.DueDate(issue).Format(w.dateLayout)
+
+	var sentences []string
+	switch {
+	case t.EndOfMonth && t.NetDays == 0:
+		sentences = append(sentences, fmt.Sprintf(w.endOfMonth, t.NetDays, due))
+	case t.EndOfMonth:
+		sentences = append(sentences, fmt.Sprintf(w.netEndOfMonth, t.NetDays, due))
+	case t.NetDays == 0:
+		sentences = append(sentences, fmt.Sprintf(w.immediate, t.NetDays, due))
+	default:
+		sentences = append(sentences, fmt.Sprintf(w.net, t.NetDays, due))
+	}
+	if t.HasDiscount() {
+		deadline := t.DiscountDeadline(issue).Format(w.dateLayout)
+		sentences = append(sentences, fmt.Sprintf(w.discount,
+			t.DiscountPercent.String(), t.DiscountDays, deadline, t.discounted(payable)))
+	}
+	if t.LateInterest.IsPositive() {
+		sentences = append(sentences, fmt.Sprintf(w.interest, t.LateInterest.String()))
+	}
+	if t.LateFee.Amount.IsPositive() {
+		sentences = append(sentences, fmt.Sprintf(w.fee, t.LateFee))
+	}
+	return strings.Join(sentences, " ")
+}
+
+// discount returns the early-payment discount on the payable amount,
+// rounded to the minor unit of the currency.
+func (t PaymentTerms) discount(payable Money) Money {
+	return payable.RoundToMinorUnit().Mul(t.DiscountPercent.Div(decimal.NewFromInt(100))).RoundToMinorUnit()
+}
+
+func (t PaymentTerms) discounted(payable Money) Money {
+	amount, _ := payable.RoundToMinorUnit().Sub(t.discount(payable))
+	return amount
+}
+
+// EarlyPaymentDiscount returns the discount granted on the amount payable
+// if the invoice is paid by the discount deadline, or zero without
+// payment terms or discount.
+func (inv *Invoice) EarlyPaymentDiscount() Money {
+	if inv.PaymentTerms == nil || !inv.PaymentTerms.HasDiscount() {
+		return Money{Amount: decimal.Zero, Currency: inv.Currency}
+	}
+	return inv.PaymentTerms.discount(inv.TotalPayable())
+}
+
+// DiscountedAmount returns the amount payable by the discount deadline.
+func (inv *Invoice) DiscountedAmount() Money {
+	amount, _ := inv.TotalPayable().RoundToMinorUnit().Sub(inv.EarlyPaymentDiscount())
+	return amount
+}
+
+// DiscountDeadline returns the last day payment qualifies for the
+// early-payment discount, or the zero time if there is none.
+func (inv *Invoice) DiscountDeadline() time.Time {
+	if inv.PaymentTerms == nil {
+		return time.Time{}
+	}
+	return inv.PaymentTerms.DiscountDeadline(inv.IssueDate)
+}
+
+// PaymentTermsText returns the payment terms to print on the invoice: the
+// free-text Terms if set, and otherwise the standard wording of the
+// structured payment terms in the invoice language.
+func (inv *Invoice) PaymentTermsText() string {
+	if inv.Terms != "" || inv.PaymentTerms == nil {
+		return inv.Terms
+	}
+	return inv.PaymentTerms.Text(inv.Language, inv.IssueDate, inv.TotalPayable())
+}
@@ -0,0 +1,114 @@
+package invoice
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Withholding represents a withholding tax that the customer deducts from
+// the amount payable and remits to the tax authority, such as Spanish IRPF
+// or the Italian ritenuta d'acconto. Withholding is calculated on the net
+// amount and does not affect VAT.
+type Withholding struct {
+	Name string          `json:"name"`
+	Rate decimal.Decimal `json:"rate"`
+}
+
+// NewWithholding creates a withholding tax with the given name and rate
+// percentage.
+func NewWithholding(name string, rate float64) Withholding {
+	return Withholding{Name: name, Rate: decimal.NewFromFloat(rate)}
+}
+
+// Label returns a display label such as "IRPF 15%".
+func (w Withholding) Label() string {
+	if w.Name == "" {
+		return w.Rate.String() + "%"
+	}
+	return fmt.Sprintf("%s %s%%", w.Name, w.Rate.String())
+}
+
+// Calculate returns the amount withheld from the given base amount.
+func (w Withholding) Calculate(base Money) Money {
+	return base.Mul(w.Rate.Div(decimal.NewFromInt(100)))
+}
+
+// Validate checks that the withholding rate is valid.
+func (w Withholding) Validate() error {
+	if w.Rate.IsNegative() || w.Rate.GreaterThan(decimal.NewFromInt(100)) {
+		return ErrInvalidWithholding
+	}
+	return nil
+}
+
+// TotalWithholding returns the sum of all line and document level
+// withholding taxes.
+func (inv *Invoice) TotalWithholding() Money {
+	total := Money{Amount: decimal.Zero, Currency: inv.Currency}
+	for _, amount := range inv.WithholdingBreakdown() {
+		total, _ = total.Add(amount)
+	}
+	return total
+}
+
+// WithholdingBreakdown returns a map of withholding labels to their total
+// amounts. Line level withholdings apply to the line's net amount and
+// document level withholdings to the net amount of the lines that do not
+// carry the same withholding tax themselves, so that no line is taxed twice.
+func (inv *Invoice) WithholdingBreakdown() map[string]Money {
+	breakdown := make(map[string]Money)
+	add := func(w Withholding, base Money) {
+		amount := w.Calculate(base)
+		if existing, ok := breakdown[w.Label()]; ok {
+			breakdown[w.Label()], _ = existing.Add(amount)
+		} else {
+			breakdown[w.Label()] = amount
+		}
+	}
+	for _, item := range inv.LineItems {
+		for _, w := range item.Withholdings {
+			add(w, item.NetAmount())
+		}
+	}
+	for _, w := range inv.Withholdings {
+		base := Money{Amount: decimal.Zero, Currency: inv.Currency}
+		for _, item := range inv.LineItems {
+			if !item.withholds(w) {
+				base, _ = base.Add(item.NetAmount())
+			}
+		}
+		add(w, base)
+	}
+	return breakdown
+}
+
+// sameTax reports whether two withholdings are the same tax, possibly at
+// different rates. Unnamed withholdings are compared by rate.
+func (w Withholding) sameTax(other Withholding) bool {
+	if w.Name == "" || other.Name == "" {
+		return w.Label() == other.Label()
+	}
+	return w.Name == other.Name
+}
+
+// TotalPayable returns the amount the customer pays, which is the gross
+// total less any withholding taxes, rounded to the invoice's rounding
+// increment if one is set.
+func (inv *Invoice) TotalPayable() Money {
+	payable, _ := inv.TotalGross().Sub(inv.TotalWithholding())
+	if inv.RoundingIncrement.IsPositive() {
+		return payable.RoundToMinorUnit().RoundToIncrement(inv.RoundingIncrement)
+	}
+	return payable
+}
+
+// RoundingDifference returns the adjustment from the gross total less
+// withholding taxes, rounded to the minor unit of the currency, to the
+// cash-rounded amount payable.
+func (inv *Invoice) RoundingDifference() Money {
+	payable, _ := inv.TotalGross().Sub(inv.TotalWithholding())
+	payable = payable.RoundToMinorUnit()
+	diff, _ := payable.RoundToIncrement(inv.RoundingIncrement).Sub(payable)
+	return diff
+}
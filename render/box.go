@@ -0,0 +1,553 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// userAgentCSS is the default stylesheet applied before the document's
+// own styles, following the HTML rendering defaults.
+const userAgentCSS = `
+html, body, div, p, h1, h2, h3, h4, h5, h6, ul, ol, dl, dt, dd, header, footer,
+section, article, main, nav, aside, address, blockquote, figure, figcaption,
+form, fieldset, hr, pre { display: block }
+li { display: list-item }
+table { display: table; border-spacing: 2px }
+thead { display: table-header-group }
+tbody { display: table-row-group }
+tfoot { display: table-footer-group }
+tr { display: table-row }
+td, th { display: table-cell; padding: 1px; vertical-align: middle }
+caption, colgroup, col { display: none }
+head, style, script, title, meta, link, template { display: none }
+body { margin: 8px }
+p, blockquote, figure, dl { margin-top: 1em; margin-bottom: 1em }
+ul, ol { margin-top: 1em; margin-bottom: 1em; padding-left: 40px }
+ul { list-style-type: disc }
+ol { list-style-type: decimal }
+h1 { font-size: 2em; margin-top: 0.67em; margin-bottom: 0.67em; font-weight: bold }
+h2 { font-size: 1.5em; margin-top: 0.83em; margin-bottom: 0.83em; font-weight: bold }
+h3 { font-size: 1.17em; margin-top: 1em; margin-bottom: 1em; font-weight: bold }
+h4 { margin-top: 1.33em; margin-bottom: 1.33em; font-weight: bold }
+h5 { font-size: 0.83em; margin-top: 1.67em; margin-bottom: 1.67em; font-weight: bold }
+h6 { font-size: 0.67em; margin-top: 2.33em; margin-bottom: 2.33em; font-weight: bold }
+strong, b, th, dt { font-weight: bold }
+em, i, cite, var, address { font-style: italic }
+u, ins, a { text-decoration: underline }
+s, del, strike { text-decoration: line-through }
+th { text-align: center }
+small { font-size: smaller }
+big { font-size: larger }
+pre, code, kbd, samp, tt { font-family: monospace }
+pre { white-space: pre; margin-top: 1em; margin-bottom: 1em }
+hr { border-top: 1px solid gray; margin-top: 0.5em; margin-bottom: 0.5em }
+blockquote { margin-left: 40px; margin-right: 40px }
+`
+
+// inheritedProperties are passed from parent to child unless set.
+var inheritedProperties = map[string]bool{
+	"color": true, "font-family": true, "font-size": true, "font-weight": true,
+	"font-style": true, "text-align": true, "line-height": true,
+	"text-transform": true, "white-space": true, "list-style-type": true,
+	"text-decoration": true, "border-collapse": true, "border-spacing": true,
+	"visibility": true,
+}
+
+// side indexes the top, right, bottom and left of box properties.
+const (
+	top = iota
+	right
+	bottom
+	left
+)
+
+type border struct {
+	width float64
+	color rgb
+}
+
+// style is the computed style of an element. Lengths are in millimetres
+// and font sizes in points.
+type style struct {
+	specified map[string]string
+	parent    *style
+
+	display    string
+	margin     [4]length
+	padding    [4]length
+	border     [4]border
+	width      length
+	minWidth   length
+	maxWidth   length
+	height     length
+	borderBox  bool
+	background *rgb
+
+	fontFamily    string
+	fontSize      float64
+	bold          bool
+	italic        bool
+	underline     bool
+	color         rgb
+	textAlign     string
+	lineHeight    float64
+	textTransform string
+	whiteSpace    string
+	verticalAlign string
+	listStyle     string
+	hidden        bool
+
+	flexDirection  string
+	justifyContent string
+	alignItems     string
+	flexGrow       float64
+	columnGap      float64
+
+	borderCollapse bool
+	borderSpacing  float64
+	colspan        int
+
+	breakBefore string
+	breakAfter  string
+	breakInside string
+}
+
+// value returns the specified value of a property, falling back to the
+// parent's for inherited properties.
+func (s *style) value(prop string) string {
+	v, ok := s.specified[prop]
+	if ok && v != "inherit" {
+		return v
+	}
+	if (ok || inheritedProperties[prop]) && s.parent != nil {
+		return s.parent.value(prop)
+	}
+	return ""
+}
+
+// rootStyle returns the style of the initial containing block.
+func rootStyle(fontFamily string, fontSize float64) *style {
+	return &style{
+		specified:  map[string]string{"font-family": fontFamily},
+		display:    "block",
+		fontFamily: fontFamily,
+		fontSize:   fontSize,
+		textAlign:  "left",
+		lineHeight: 1.2,
+		whiteSpace: "normal",
+		listStyle:  "disc",
+	}
+}
+
+var fontSizeKeywords = map[string]float64{
+	"xx-small": 7, "x-small": 7.5, "small": 10, "medium": 12,
+	"large": 13.5, "x-large": 18, "xx-large": 24,
+}
+
+// computeStyle resolves the specified values of an element against its
+// parent's computed style.
+func computeStyle(specified map[string]string, parent, root *style) *style {
+	s := &style{specified: specified, parent: parent}
+	get := s.value
+
+	s.fontSize = parent.fontSize
+	switch v := strings.ToLower(get("font-size")); {
+	case v == "":
+	case fontSizeKeywords[v] > 0:
+		s.fontSize = fontSizeKeywords[v]
+	case v == "smaller":
+		s.fontSize = parent.fontSize / 1.2
+	case v == "larger":
+		s.fontSize = parent.fontSize * 1.2
+	default:
+		if l, ok := parseLength(v, parent.fontSize, root.fontSize); ok {
+			s.fontSize = l.resolve(parent.fontSize*mmPerPt) / mmPerPt
+		}
+	}
+	lengthOf := func(prop string) length {
+		l, _ := parseLength(get(prop), s.fontSize, root.fontSize)
+		return l
+	}
+
+	s.display = strings.ToLower(get("display"))
+	if s.display == "" {
+		s.display = "inline"
+	}
+	for i, side := range sides {
+		s.margin[i] = lengthOf("margin-" + side)
+		s.padding[i] = lengthOf("padding-" + side)
+		if bs := get("border-" + side + "-style"); bs != "" && bs != "none" && bs != "hidden" {
+			width := 3 * mmPerPx
+			switch w := get("border-" + side + "-width"); w {
+			case "thin":
+				width = mmPerPx
+			case "thick":
+				width = 5 * mmPerPx
+			case "", "medium":
+			default:
+				width = lengthOf("border-" + side + "-width").value
+			}
+			s.border[i].width = width
+		}
+	}
+	s.width = autoLength(get("width"), s.fontSize, root.fontSize)
+	s.height = autoLength(get("height"), s.fontSize, root.fontSize)
+	s.minWidth = lengthOf("min-width")
+	s.maxWidth = autoLength(get("max-width"), s.fontSize, root.fontSize)
+	s.borderBox = get("box-sizing") == "border-box"
+	if c, ok := parseColor(get("background-color")); ok {
+		s.background = &c
+	}
+
+	s.fontFamily = parent.fontFamily
+	if v := get("font-family"); v != "" {
+		s.fontFamily = v
+	}
+	switch v := strings.ToLower(get("font-weight")); v {
+	case "bold", "bolder", "600", "700", "800", "900":
+		s.bold = true
+	}
+	switch v := strings.ToLower(get("font-style")); v {
+	case "italic", "oblique":
+		s.italic = true
+	}
+	s.underline = strings.Contains(get("text-decoration"), "underline")
+	s.color = parent.color
+	if v := get("color"); v != "" {
+		if c, ok := parseColor(v); ok {
+			s.color = c
+		}
+	}
+	for i, side := range sides {
+		var ok bool
+		if s.border[i].color, ok = parseColor(get("border-" + side + "-color")); !ok {
+			s.border[i].color = s.color
+		}
+	}
+
+	s.textAlign = strings.ToLower(get("text-align"))
+	if s.textAlign == "" {
+		s.textAlign = "left"
+	}
+	s.lineHeight = parent.lineHeight
+	if v := strings.ToLower(get("line-height")); v == "normal" {
+		s.lineHeight = 1.2
+	} else if v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			s.lineHeight = f
+		} else if l, ok := parseLength(v, s.fontSize, root.fontSize); ok {
+			// Store line heights as a multiple of the font size.
+			s.lineHeight = l.resolve(s.fontSize*mmPerPt) / (s.fontSize * mmPerPt)
+		}
+	}
+	s.textTransform = get("text-transform")
+	s.whiteSpace = get("white-space")
+	if s.whiteSpace == "" {
+		s.whiteSpace = "normal"
+	}
+	s.verticalAlign = get("vertical-align")
+	s.listStyle = get("list-style-type")
+	s.hidden = get("visibility") == "hidden"
+
+	s.flexDirection = get("flex-direction")
+	s.justifyContent = get("justify-content")
+	s.alignItems = get("align-items")
+	s.flexGrow, _ = strconv.ParseFloat(get("flex-grow"), 64)
+	s.columnGap = lengthOf("column-gap").value
+
+	s.borderCollapse = get("border-collapse") == "collapse"
+	if v := fields(get("border-spacing")); len(v) > 0 {
+		l, _ := parseLength(v[0], s.fontSize, root.fontSize)
+		s.borderSpacing = l.value
+	}
+
+	s.breakBefore = get("break-before")
+	s.breakAfter = get("break-after")
+	s.breakInside = get("break-inside")
+	return s
+}
+
+func autoLength(v string, fontSize, rootFontSize float64) length {
+	if v == "" || v == "none" {
+		return length{auto: true}
+	}
+	l, ok := parseLength(v, fontSize, rootFontSize)
+	if !ok {
+		return length{auto: true}
+	}
+	return l
+}
+
+// inheritedStyle returns the style of an anonymous box, which inherits
+// from its parent but has no box properties of its own.
+func inheritedStyle(parent *style) *style {
+	s := *parent
+	s.specified = map[string]string{}
+	s.parent = parent
+	s.display = "block"
+	s.margin, s.padding, s.border = [4]length{}, [4]length{}, [4]border{}
+	s.width, s.height, s.minWidth = length{auto: true}, length{auto: true}, length{}
+	s.maxWidth = length{auto: true}
+	s.background = nil
+	s.flexGrow, s.colspan = 0, 0
+	s.breakBefore, s.breakAfter = "", ""
+	return &s
+}
+
+// lineHeightMM returns the line height in millimetres.
+func (s *style) lineHeightMM() float64 {
+	return s.lineHeight * s.fontSize * mmPerPt
+}
+
+type boxKind int
+
+const (
+	blockBox boxKind = iota
+	flexBox
+	tableBox
+	rowGroupBox
+	rowBox
+	cellBox
+	textBox
+	breakBox
+	imageBox
+)
+
+// box is a node of the box tree. Block containers hold either only block
+// level children or only inline children (text, images and line breaks).
+type box struct {
+	kind     boxKind
+	style    *style
+	children []*box
+	text     string
+	image    *image
+	header   bool
+	// elem is the structure element of the text and images of tagged
+	// documents.
+	elem *structElem
+}
+
+func (b *box) isInline() bool {
+	return b.kind == textBox || b.kind == breakBox || b.kind == imageBox
+}
+
+// boxBuilder builds the box tree of a document.
+type boxBuilder struct {
+	sheet  *stylesheet
+	root   *style
+	images *imageSet
+	// elem is the structure element content is added to, or nil if the
+	// document is not tagged.
+	elem *structElem
+}
+
+// buildChildren converts the children of an element into boxes.
+func (bb *boxBuilder) buildChildren(n *html.Node, s *style) []*box {
+	var out []*box
+	counter := 0
+	flex := s.display == "flex" || s.display == "inline-flex"
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.TextNode:
+			if c.Data != "" {
+				out = append(out, &box{kind: textBox, style: s, text: c.Data, elem: bb.elem})
+			}
+		case html.ElementNode:
+			cs := computeStyle(bb.sheet.cascade(c), s, bb.root)
+			if c.Data == "td" || c.Data == "th" {
+				// Spans are limited to 1000 columns, as in HTML.
+				span, _ := strconv.Atoi(attr(c, "colspan"))
+				cs.colspan = min(max(span, 1), 1000)
+			}
+			if flex && (cs.display == "inline" || cs.display == "inline-block") {
+				// Children of flex containers are flex items, which are blocks.
+				cs.display = "block"
+			}
+			if cs.display == "list-item" {
+				counter++
+			}
+			out = append(out, bb.build(c, cs, counter)...)
+		}
+	}
+	return out
+}
+
+// build returns the boxes generated by an element: a single box for block
+// level elements, and the inline content of inline elements.
+func (bb *boxBuilder) build(n *html.Node, s *style, index int) []*box {
+	if s.display == "none" {
+		return nil
+	}
+	switch n.Data {
+	case "br":
+		return []*box{{kind: breakBox, style: s}}
+	case "img":
+		return bb.image(n, s)
+	}
+
+	parent, label := bb.elem, bb.elem
+	if tag := structTag(n.Data, s); tag != "" {
+		bb.elem = parent.add(tag)
+		switch {
+		case tag == "LI":
+			label = bb.elem.add("Lbl")
+			bb.elem = bb.elem.add("LBody")
+		case tag == "TH" && bb.elem != nil:
+			if strings.EqualFold(attr(n, "scope"), "row") {
+				bb.elem.scope = "Row"
+			}
+		}
+	}
+	defer func() { bb.elem = parent }()
+
+	children := bb.buildChildren(n, s)
+	switch s.display {
+	case "inline", "inline-block":
+		return children
+	case "flex", "inline-flex":
+		if s.flexDirection == "column" {
+			return []*box{bb.block(blockBox, s, children)}
+		}
+		return []*box{bb.block(flexBox, s, children)}
+	case "table", "inline-table":
+		return []*box{bb.table(s, children)}
+	case "table-header-group", "table-row-group", "table-footer-group":
+		return []*box{{kind: rowGroupBox, style: s, children: children, header: s.display == "table-header-group"}}
+	case "table-row":
+		return []*box{{kind: rowBox, style: s, children: onlyKind(children, cellBox)}}
+	case "table-cell":
+		return []*box{bb.block(cellBox, s, children)}
+	case "list-item":
+		if marker := listMarker(s.listStyle, index); marker != "" {
+			children = append([]*box{{kind: textBox, style: s, text: marker + " ", elem: label}}, children...)
+		}
+	}
+	return []*box{bb.block(blockBox, s, children)}
+}
+
+// image returns the box of an img element. Images are inline whatever
+// their display, and images that cannot be loaded are replaced by their
+// alternative text.
+func (bb *boxBuilder) image(n *html.Node, s *style) []*box {
+	img, err := bb.images.load(attr(n, "src"))
+	if err != nil {
+		if alt := attr(n, "alt"); alt != "" {
+			return []*box{{kind: textBox, style: s, text: alt, elem: bb.elem}}
+		}
+		return nil
+	}
+	// The width and height attributes are in pixels.
+	for _, dim := range []struct {
+		name string
+		l    *length
+	}{{"width", &s.width}, {"height", &s.height}} {
+		if v, err := strconv.ParseFloat(attr(n, dim.name), 64); err == nil && v > 0 && dim.l.auto {
+			*dim.l = length{value: v * mmPerPx}
+		}
+	}
+	// Images without alternative text are decorative.
+	var elem *structElem
+	if alt := attr(n, "alt"); alt != "" {
+		elem = bb.elem.add("Figure")
+		if elem != nil {
+			elem.alt = alt
+		}
+	}
+	return []*box{{kind: imageBox, style: s, image: img, elem: elem}}
+}
+
+func listMarker(listStyle string, index int) string {
+	switch listStyle {
+	case "none":
+		return ""
+	case "decimal":
+		return strconv.Itoa(index) + "."
+	case "circle":
+		return "o"
+	}
+	return "•"
+}
+
+// block creates a block container, wrapping runs of inline children in
+// anonymous blocks if it also has block level children. Flex containers
+// wrap every run of inline children.
+func (bb *boxBuilder) block(kind boxKind, s *style, children []*box) *box {
+	b := &box{kind: kind, style: s}
+	hasBlock := false
+	for _, c := range children {
+		if !c.isInline() {
+			hasBlock = true
+			break
+		}
+	}
+	if !hasBlock && kind != flexBox {
+		b.children = children
+		return b
+	}
+	var run []*box
+	flush := func() {
+		if !whitespaceOnly(run) {
+			b.children = append(b.children, &box{kind: blockBox, style: inheritedStyle(s), children: run})
+		}
+		run = nil
+	}
+	for _, c := range children {
+		if c.isInline() {
+			run = append(run, c)
+			continue
+		}
+		flush()
+		if c.kind == rowGroupBox || c.kind == rowBox || c.kind == cellBox {
+			// Table parts outside a table are laid out as blocks.
+			c = &box{kind: blockBox, style: c.style, children: c.children}
+		}
+		b.children = append(b.children, c)
+	}
+	flush()
+	return b
+}
+
+func whitespaceOnly(boxes []*box) bool {
+	for _, b := range boxes {
+		if b.kind != textBox || strings.TrimSpace(b.text) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// table normalises the children of a table into row groups of rows.
+func (bb *boxBuilder) table(s *style, children []*box) *box {
+	t := &box{kind: tableBox, style: s}
+	var loose []*box
+	flush := func() {
+		if len(loose) > 0 {
+			t.children = append(t.children, &box{kind: rowGroupBox, style: inheritedStyle(s), children: loose})
+			loose = nil
+		}
+	}
+	for _, c := range children {
+		switch c.kind {
+		case rowGroupBox:
+			flush()
+			c.children = onlyKind(c.children, rowBox)
+			t.children = append(t.children, c)
+		case rowBox:
+			loose = append(loose, c)
+		}
+	}
+	flush()
+	return t
+}
+
+func onlyKind(boxes []*box, kind boxKind) []*box {
+	var out []*box
+	for _, b := range boxes {
+		if b.kind == kind {
+			out = append(out, b)
+		}
+	}
+	return out
+}
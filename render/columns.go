@@ -0,0 +1,157 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// ItemField is a value of a line item shown in a column.
+type ItemField string
+
+// Line item fields.
+const (
+	FieldDescription    ItemField = "description"
+	FieldSKU            ItemField = "sku"
+	FieldDate           ItemField = "date"
+	FieldQuantity       ItemField = "quantity"
+	FieldUnit           ItemField = "unit"
+	FieldUnitPrice      ItemField = "unit_price"
+	FieldDiscount       ItemField = "discount"
+	FieldDiscountAmount ItemField = "discount_amount"
+	FieldTaxRate        ItemField = "tax_rate"
+	FieldTaxAmount      ItemField = "tax_amount"
+	FieldNetAmount      ItemField = "net_amount"
+	FieldAmount         ItemField = "amount"
+)
+
+// MetadataField returns the field of a line item metadata value.
+func MetadataField(key string) ItemField {
+	return ItemField("metadata." + key)
+}
+
+// Column is a column of the line item table.
+type Column struct {
+	Field ItemField
+	Title string
+	// Width is the share of the table width relative to the other
+	// columns. If zero, it is the width of ColumnFor(Field).
+	Width float64
+	// Align is "L", "C" or "R".
+	Align string
+	// Format, if set, returns the text of the cells instead of Field.
+	Format func(item invoice.LineItem) string
+}
+
+// ColumnFor returns the column of a built-in field with its default title,
+// width and alignment, or a left aligned column titled with the key of a
+// metadata field.
+func ColumnFor(field ItemField) Column {
+	switch field {
+	case FieldDescription:
+		return Column{Field: field, Title: "Description", Width: 80, Align: "L"}
+	case FieldSKU:
+		return Column{Field: field, Title: "SKU", Width: 25, Align: "L"}
+	case FieldDate:
+		return Column{Field: field, Title: "Date", Width: 22, Align: "C"}
+	case FieldQuantity:
+		return Column{Field: field, Title: "Qty", Width: 20, Align: "C"}
+	case FieldUnit:
+		return Column{Field: field, Title: "Unit", Width: 15, Align: "C"}
+	case FieldUnitPrice:
+		return Column{Field: field, Title: "Unit Price", Width: 30, Align: "R"}
+	case FieldDiscount:
+		return Column{Field: field, Title: "Disc. %", Width: 18, Align: "C"}
+	case FieldDiscountAmount:
+		return Column{Field: field, Title: "Discount", Width: 30, Align: "R"}
+	case FieldTaxRate:
+		return Column{Field: field, Title: "Tax %", Width: 20, Align: "C"}
+	case FieldTaxAmount:
+		return Column{Field: field, Title: "Tax", Width: 30, Align: "R"}
+	case FieldNetAmount:
+		return Column{Field: field, Title: "Net", Width: 40, Align: "R"}
+	case FieldAmount:
+		return Column{Field: field, Title: "Amount", Width: 40, Align: "R"}
+	}
+	key, _ := field.metadataKey()
+	return Column{Field: field, Title: key, Width: 25, Align: "L"}
+}
+
+func (f ItemField) metadataKey() (string, bool) {
+	return strings.CutPrefix(string(f), "metadata.")
+}
+
+// text returns the text of the column for a line item.
+func (c Column) text(item invoice.LineItem) string {
+	if c.Format != nil {
+		return c.Format(item)
+	}
+	switch c.Field {
+	case FieldDescription:
+		return item.Description
+	case FieldSKU:
+		return item.SKU
+	case FieldDate:
+		if item.Date.IsZero() {
+			return ""
+		}
+		return item.Date.Format("2006-01-02")
+	case FieldQuantity:
+		return item.Quantity.String()
+	case FieldUnit:
+		return item.Unit
+	case FieldUnitPrice:
+		return item.UnitPrice.String()
+	case FieldDiscount:
+		if item.Discount.IsZero() {
+			return ""
+		}
+		return item.Discount.String() + "%"
+	case FieldDiscountAmount:
+		if item.Discount.IsZero() {
+			return ""
+		}
+		return "-" + item.DiscountAmount().String()
+	case FieldTaxRate:
+		return item.TaxRate.String() + "%"
+	case FieldTaxAmount:
+		return item.TaxAmount().String()
+	case FieldNetAmount:
+		return item.NetAmount().String()
+	case FieldAmount:
+		return item.GrossAmount().String()
+	}
+	if key, ok := c.Field.metadataKey(); ok {
+		return item.Metadata[key]
+	}
+	return ""
+}
+
+// width returns the relative width of the column.
+func (c Column) width() float64 {
+	if c.Width > 0 {
+		return c.Width
+	}
+	return ColumnFor(c.Field).Width
+}
+
+// carriedColumn returns the index of the column summed in the running
+// total at page breaks, the last net or gross amount column, or -1 if
+// there is none.
+func carriedColumn(columns []Column) int {
+	for i := len(columns) - 1; i >= 0; i-- {
+		if columns[i].Format == nil && (columns[i].Field == FieldNetAmount || columns[i].Field == FieldAmount) {
+			return i
+		}
+	}
+	return -1
+}
+
+// carriedAmount returns the amount of a line item summed in the running
+// total: that of the carried column, or the gross amount if there is none.
+func carriedAmount(columns []Column, item invoice.LineItem) invoice.Money {
+	if i := carriedColumn(columns); i >= 0 && columns[i].Field == FieldNetAmount {
+		return item.NetAmount()
+	}
+	return item.GrossAmount()
+}
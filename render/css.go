@@ -0,0 +1,746 @@
+package render
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// stylesheet is a parsed CSS stylesheet. Rules in @media blocks apply only
+// for the print and all media types, and @page declarations are collected
+// separately.
+type stylesheet struct {
+	rules []cssRule
+	page  map[string]string
+	// origin is assigned to rules parsed next. Author rules override
+	// user agent rules regardless of specificity.
+	origin int
+}
+
+const (
+	userAgentOrigin = iota
+	authorOrigin
+)
+
+type cssRule struct {
+	origin      int
+	selector    selector
+	specificity int
+	order       int
+	decls       []declaration
+}
+
+type declaration struct {
+	property  string
+	value     string
+	important bool
+}
+
+// parse parses CSS source and appends its rules, so that rules of later
+// sheets win on equal specificity.
+func (s *stylesheet) parse(src string) {
+	if s.page == nil {
+		s.page = make(map[string]string)
+	}
+	src = stripComments(src)
+	for len(src) > 0 {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			return
+		}
+		if src[0] == '@' {
+			src = s.parseAtRule(src)
+			continue
+		}
+		open := strings.IndexByte(src, '{')
+		if open < 0 {
+			return
+		}
+		end := matchingBrace(src, open)
+		prelude, block := src[:open], src[open+1:end]
+		src = src[min(end+1, len(src)):]
+
+		decls := parseDeclarations(block)
+		for _, text := range strings.Split(prelude, ",") {
+			sel, ok := parseSelector(strings.TrimSpace(text))
+			if !ok {
+				continue
+			}
+			s.rules = append(s.rules, cssRule{
+				origin:      s.origin,
+				selector:    sel,
+				specificity: sel.specificity(),
+				order:       len(s.rules),
+				decls:       decls,
+			})
+		}
+	}
+}
+
+// parseAtRule handles @page and @media and skips other at-rules. It
+// returns the remaining source.
+func (s *stylesheet) parseAtRule(src string) string {
+	nameEnd := strings.IndexAny(src, " \t\n\r{;")
+	if nameEnd < 0 {
+		return ""
+	}
+	name := strings.ToLower(src[1:nameEnd])
+	open := strings.IndexByte(src, '{')
+	semi := strings.IndexByte(src, ';')
+	if open < 0 || (semi >= 0 && semi < open) {
+		if semi < 0 {
+			return ""
+		}
+		return src[semi+1:]
+	}
+	end := matchingBrace(src, open)
+	prelude, block := strings.ToLower(src[nameEnd:open]), src[open+1:end]
+	rest := src[min(end+1, len(src)):]
+	switch name {
+	case "page":
+		// Margin boxes such as @top-center are not supported.
+		for _, d := range parseDeclarations(removeBlocks(block)) {
+			s.page[d.property] = d.value
+		}
+	case "media":
+		if strings.Contains(prelude, "print") || strings.Contains(prelude, "all") {
+			s.parse(block)
+		}
+	}
+	return rest
+}
+
+func stripComments(src string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(src, "/*")
+		if start < 0 {
+			b.WriteString(src)
+			return b.String()
+		}
+		b.WriteString(src[:start])
+		end := strings.Index(src[start+2:], "*/")
+		if end < 0 {
+			return b.String()
+		}
+		src = src[start+2+end+2:]
+	}
+}
+
+// matchingBrace returns the index of the brace closing the one at open, or
+// len(src) if it is not closed.
+func matchingBrace(src string, open int) int {
+	depth := 0
+	for i := open; i < len(src); i++ {
+		switch src[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(src)
+}
+
+// removeBlocks removes nested blocks such as margin boxes from a block.
+func removeBlocks(block string) string {
+	for {
+		open := strings.IndexByte(block, '{')
+		if open < 0 {
+			return block
+		}
+		start := strings.LastIndexAny(block[:open], ";}") + 1
+		end := matchingBrace(block, open)
+		block = block[:start] + block[min(end+1, len(block)):]
+	}
+}
+
+// parseDeclarations parses a declaration block and expands shorthand
+// properties into their longhands.
+func parseDeclarations(block string) []declaration {
+	var decls []declaration
+	for _, part := range splitOutside(block, ';') {
+		prop, value, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		value = strings.TrimSpace(value)
+		important := false
+		if i := strings.Index(strings.ToLower(value), "!important"); i >= 0 {
+			important = true
+			value = strings.TrimSpace(value[:i])
+		}
+		if prop == "" || value == "" {
+			continue
+		}
+		for _, d := range expandShorthand(prop, value) {
+			d.important = important
+			decls = append(decls, d)
+		}
+	}
+	return decls
+}
+
+// splitOutside splits s at sep outside of quotes and parentheses.
+func splitOutside(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// fields splits a value at whitespace outside of parentheses.
+func fields(value string) []string {
+	var out []string
+	for _, f := range splitOutside(strings.Join(strings.Fields(value), " "), ' ') {
+		if f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+var sides = [4]string{"top", "right", "bottom", "left"}
+
+// boxSides expands 1 to 4 values into top, right, bottom and left.
+func boxSides(values []string) [4]string {
+	switch len(values) {
+	case 1:
+		return [4]string{values[0], values[0], values[0], values[0]}
+	case 2:
+		return [4]string{values[0], values[1], values[0], values[1]}
+	case 3:
+		return [4]string{values[0], values[1], values[2], values[1]}
+	default:
+		return [4]string{values[0], values[1], values[2], values[3]}
+	}
+}
+
+var borderStyles = map[string]bool{
+	"none": true, "hidden": true, "solid": true, "dashed": true, "dotted": true,
+	"double": true, "groove": true, "ridge": true, "inset": true, "outset": true,
+}
+
+func expandShorthand(prop, value string) []declaration {
+	d := func(p, v string) declaration { return declaration{property: p, value: v} }
+	switch prop {
+	case "margin", "padding":
+		v := boxSides(fields(value))
+		return []declaration{d(prop+"-top", v[0]), d(prop+"-right", v[1]), d(prop+"-bottom", v[2]), d(prop+"-left", v[3])}
+	case "border-width", "border-style", "border-color":
+		kind := strings.TrimPrefix(prop, "border-")
+		v := boxSides(fields(value))
+		var out []declaration
+		for i, side := range sides {
+			out = append(out, d("border-"+side+"-"+kind, v[i]))
+		}
+		return out
+	case "border", "border-top", "border-right", "border-bottom", "border-left":
+		width, style, color := "medium", "none", "currentcolor"
+		for _, f := range fields(value) {
+			switch {
+			case borderStyles[strings.ToLower(f)]:
+				style = strings.ToLower(f)
+			case isLength(f) || f == "thin" || f == "medium" || f == "thick":
+				width = f
+			default:
+				color = f
+			}
+		}
+		targets := sides[:]
+		if prop != "border" {
+			targets = []string{strings.TrimPrefix(prop, "border-")}
+		}
+		var out []declaration
+		for _, side := range targets {
+			out = append(out,
+				d("border-"+side+"-width", width),
+				d("border-"+side+"-style", style),
+				d("border-"+side+"-color", color))
+		}
+		return out
+	case "background":
+		color := "transparent"
+		for _, f := range fields(value) {
+			if _, ok := parseColor(f); ok {
+				color = f
+			}
+		}
+		return []declaration{d("background-color", color)}
+	case "font":
+		return expandFont(value)
+	case "gap":
+		v := fields(value)
+		column := v[0]
+		if len(v) > 1 {
+			column = v[1]
+		}
+		return []declaration{d("row-gap", v[0]), d("column-gap", column)}
+	case "flex":
+		v := fields(value)
+		switch v[0] {
+		case "none":
+			return []declaration{d("flex-grow", "0")}
+		case "auto":
+			return []declaration{d("flex-grow", "1")}
+		}
+		return []declaration{d("flex-grow", v[0])}
+	case "page-break-before", "page-break-after", "page-break-inside":
+		if value == "always" {
+			value = "page"
+		}
+		return []declaration{d(strings.Replace(prop, "page-break", "break", 1), value)}
+	}
+	return []declaration{d(prop, value)}
+}
+
+// expandFont expands the font shorthand, e.g. "italic bold 12px/1.5 Arial".
+func expandFont(value string) []declaration {
+	var out []declaration
+	parts := fields(value)
+	for i, f := range parts {
+		lower := strings.ToLower(f)
+		switch {
+		case lower == "italic" || lower == "oblique":
+			out = append(out, declaration{property: "font-style", value: lower})
+		case lower == "bold" || lower == "bolder" || (len(f) == 3 && strings.HasSuffix(f, "00")):
+			out = append(out, declaration{property: "font-weight", value: lower})
+		case lower == "normal" || lower == "small-caps":
+		case isLength(strings.SplitN(f, "/", 2)[0]) || fontSizeKeywords[strings.SplitN(lower, "/", 2)[0]] > 0:
+			size, lineHeight, hasLineHeight := strings.Cut(f, "/")
+			out = append(out, declaration{property: "font-size", value: size})
+			if hasLineHeight {
+				out = append(out, declaration{property: "line-height", value: lineHeight})
+			}
+			if i+1 < len(parts) {
+				out = append(out, declaration{property: "font-family", value: strings.Join(parts[i+1:], " ")})
+			}
+			return out
+		}
+	}
+	return out
+}
+
+// selector is a complex selector of compound selectors joined by
+// descendant (' ') or child ('>') combinators, rightmost last.
+type selector struct {
+	parts       []compound
+	combinators []byte
+}
+
+type compound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+	pseudos []string
+}
+
+type attrSelector struct {
+	name, value string
+	hasValue    bool
+}
+
+// parseSelector parses a selector. Sibling combinators, pseudo-elements
+// and unknown pseudo-classes make the selector unsupported.
+func parseSelector(text string) (selector, bool) {
+	var sel selector
+	text = strings.ReplaceAll(text, ">", " > ")
+	pending := byte(' ')
+	for _, token := range strings.Fields(text) {
+		if token == ">" {
+			pending = '>'
+			continue
+		}
+		if strings.ContainsAny(token, "+~") || strings.Contains(token, "::") {
+			return selector{}, false
+		}
+		c, ok := parseCompound(token)
+		if !ok {
+			return selector{}, false
+		}
+		if len(sel.parts) > 0 {
+			sel.combinators = append(sel.combinators, pending)
+		}
+		sel.parts = append(sel.parts, c)
+		pending = ' '
+	}
+	return sel, len(sel.parts) > 0
+}
+
+var supportedPseudos = map[string]bool{"first-child": true, "last-child": true}
+
+func parseCompound(token string) (compound, bool) {
+	var c compound
+	i := 0
+	readName := func() string {
+		start := i
+		for i < len(token) && !strings.ContainsRune(".#[:", rune(token[i])) {
+			i++
+		}
+		return token[start:i]
+	}
+	if i < len(token) && token[i] != '.' && token[i] != '#' && token[i] != '[' && token[i] != ':' {
+		c.tag = strings.ToLower(readName())
+		if c.tag == "*" {
+			c.tag = ""
+		}
+	}
+	for i < len(token) {
+		switch token[i] {
+		case '.':
+			i++
+			c.classes = append(c.classes, readName())
+		case '#':
+			i++
+			c.id = readName()
+		case ':':
+			i++
+			name := strings.ToLower(readName())
+			if !supportedPseudos[name] {
+				return compound{}, false
+			}
+			c.pseudos = append(c.pseudos, name)
+		case '[':
+			end := strings.IndexByte(token[i:], ']')
+			if end < 0 {
+				return compound{}, false
+			}
+			name, value, hasValue := strings.Cut(token[i+1:i+end], "=")
+			c.attrs = append(c.attrs, attrSelector{
+				name:     strings.ToLower(strings.TrimSpace(name)),
+				value:    strings.Trim(strings.TrimSpace(value), `"'`),
+				hasValue: hasValue,
+			})
+			i += end + 1
+		default:
+			return compound{}, false
+		}
+	}
+	return c, true
+}
+
+func (s selector) specificity() int {
+	a, b, c := 0, 0, 0
+	for _, part := range s.parts {
+		if part.id != "" {
+			a++
+		}
+		b += len(part.classes) + len(part.attrs) + len(part.pseudos)
+		if part.tag != "" {
+			c++
+		}
+	}
+	return a*10000 + b*100 + c
+}
+
+func (s selector) matches(n *html.Node) bool {
+	return s.matchFrom(len(s.parts)-1, n)
+}
+
+func (s selector) matchFrom(i int, n *html.Node) bool {
+	if !s.parts[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	for p := n.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+		if s.matchFrom(i-1, p) {
+			return true
+		}
+		if s.combinators[i-1] == '>' {
+			return false
+		}
+	}
+	return false
+}
+
+func (c compound) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if c.tag != "" && c.tag != n.Data {
+		return false
+	}
+	if c.id != "" && attr(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		classes := strings.Fields(attr(n, "class"))
+		for _, want := range c.classes {
+			found := false
+			for _, class := range classes {
+				if class == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	for _, a := range c.attrs {
+		value, ok := lookupAttr(n, a.name)
+		if !ok || (a.hasValue && value != a.value) {
+			return false
+		}
+	}
+	for _, p := range c.pseudos {
+		switch p {
+		case "first-child":
+			if previousElement(n) != nil {
+				return false
+			}
+		case "last-child":
+			if nextElement(n) != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func attr(n *html.Node, name string) string {
+	value, _ := lookupAttr(n, name)
+	return value
+}
+
+func lookupAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func previousElement(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+func nextElement(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+// cascade returns the declared values of an element's properties, applying
+// rules in order of importance, origin, specificity and source order, followed by
+// its style attribute.
+func (s *stylesheet) cascade(n *html.Node) map[string]string {
+	var matched []cssRule
+	for _, r := range s.rules {
+		if r.selector.matches(n) {
+			matched = append(matched, r)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].origin != matched[j].origin {
+			return matched[i].origin < matched[j].origin
+		}
+		if matched[i].specificity != matched[j].specificity {
+			return matched[i].specificity < matched[j].specificity
+		}
+		return matched[i].order < matched[j].order
+	})
+
+	values := make(map[string]string)
+	important := make(map[string]bool)
+	apply := func(decls []declaration, onlyImportant bool) {
+		for _, d := range decls {
+			if d.important != onlyImportant {
+				continue
+			}
+			values[d.property] = d.value
+			if d.important {
+				important[d.property] = true
+			}
+		}
+	}
+	inline := parseDeclarations(attr(n, "style"))
+	for _, r := range matched {
+		apply(r.decls, false)
+	}
+	for _, d := range inline {
+		if !d.important && !important[d.property] {
+			values[d.property] = d.value
+		}
+	}
+	for _, r := range matched {
+		apply(r.decls, true)
+	}
+	apply(inline, true)
+	return values
+}
+
+// Lengths
+
+const (
+	mmPerPx = 25.4 / 96
+	mmPerPt = 25.4 / 72
+)
+
+// length is a CSS length in millimetres, a percentage or auto.
+type length struct {
+	value   float64
+	percent bool
+	auto    bool
+}
+
+func (l length) resolve(base float64) float64 {
+	switch {
+	case l.auto:
+		return 0
+	case l.percent:
+		return base * l.value / 100
+	}
+	return l.value
+}
+
+func isLength(s string) bool {
+	_, ok := parseLength(s, 0, 0)
+	return ok && s != "auto"
+}
+
+// parseLength parses a length relative to the font size and root font
+// size in points.
+func parseLength(s string, fontSize, rootFontSize float64) (length, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "auto" {
+		return length{auto: true}, true
+	}
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"rem", rootFontSize * mmPerPt},
+		{"em", fontSize * mmPerPt},
+		{"px", mmPerPx},
+		{"pt", mmPerPt},
+		{"pc", 12 * mmPerPt},
+		{"mm", 1},
+		{"cm", 10},
+		{"in", 25.4},
+		{"%", 0},
+	}
+	for _, u := range units {
+		if !strings.HasSuffix(s, u.suffix) {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSuffix(s, u.suffix), 64)
+		if err != nil {
+			return length{}, false
+		}
+		if u.suffix == "%" {
+			return length{value: v, percent: true}, true
+		}
+		return length{value: v * u.factor}, true
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil && v == 0 {
+		return length{}, true
+	}
+	return length{}, false
+}
+
+// Colors
+
+type rgb struct {
+	r, g, b int
+}
+
+var namedColors = map[string]rgb{
+	"black": {0, 0, 0}, "white": {255, 255, 255}, "red": {255, 0, 0},
+	"green": {0, 128, 0}, "blue": {0, 0, 255}, "yellow": {255, 255, 0},
+	"gray": {128, 128, 128}, "grey": {128, 128, 128}, "silver": {192, 192, 192},
+	"maroon": {128, 0, 0}, "navy": {0, 0, 128}, "olive": {128, 128, 0},
+	"purple": {128, 0, 128}, "teal": {0, 128, 128}, "orange": {255, 165, 0},
+	"lightgray": {211, 211, 211}, "lightgrey": {211, 211, 211},
+	"darkgray": {169, 169, 169}, "darkgrey": {169, 169, 169},
+	"whitesmoke": {245, 245, 245}, "gainsboro": {220, 220, 220},
+}
+
+// parseColor parses a color. Transparent colors, including rgba with zero
+// alpha, are reported as not ok.
+func parseColor(s string) (rgb, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+	if strings.HasPrefix(s, "#") {
+		hex := s[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) == 8 {
+			hex = hex[:6]
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 6 || err != nil {
+			return rgb{}, false
+		}
+		return rgb{int(v >> 16), int(v >> 8 & 0xff), int(v & 0xff)}, true
+	}
+	if strings.HasPrefix(s, "rgb") {
+		open, end := strings.IndexByte(s, '('), strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return rgb{}, false
+		}
+		parts := strings.FieldsFunc(s[open+1:end], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(parts) < 3 {
+			return rgb{}, false
+		}
+		if len(parts) == 4 {
+			if a, err := strconv.ParseFloat(strings.TrimSuffix(parts[3], "%"), 64); err == nil && a == 0 {
+				return rgb{}, false
+			}
+		}
+		var c [3]int
+		for i := range c {
+			p := parts[i]
+			v, err := strconv.ParseFloat(strings.TrimSuffix(p, "%"), 64)
+			if err != nil {
+				return rgb{}, false
+			}
+			if strings.HasSuffix(p, "%") {
+				v = v * 255 / 100
+			}
+			c[i] = int(min(max(v, 0), 255))
+		}
+		return rgb{c[0], c[1], c[2]}, true
+	}
+	return rgb{}, false
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/wiederin/go-invoicer/dunning"
+)
+
+// RenderNotice renders a payment reminder or dunning notice to PDF using
+// the specified template. The template receives the notice as .Notice and
+// its invoice as .Invoice.
+func (e *Engine) RenderNotice(n *dunning.Notice, templateName string) ([]byte, error) {
+	data := map[string]any{
+		"Notice":        n,
+		"Invoice":       n.Invoice,
+		"TotalPaid":     n.TotalPaid(),
+		"TotalInterest": n.TotalInterest(),
+		"TotalDue":      n.TotalDue(),
+	}
+
+	html, err := e.Templates.RenderHTML(templateName, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return e.htmlToPDF(html, n.Invoice, noticeMetadata(n))
+}
+
+// RenderNotice renders a payment reminder or dunning notice to PDF bytes.
+func (r *SimpleRenderer) RenderNotice(n *dunning.Notice) ([]byte, error) {
+	inv := n.Invoice
+
+	return r.render(inv, noticeMetadata(n), func(pdf *document) {
+		t := pdf.theme
+		lh := t.line(t.TextSize)
+		r.renderTitleText(pdf, n.Name)
+
+		r.renderParties(pdf, inv)
+		pdf.Ln(t.SectionSpacing)
+
+		pdf.setFont("", t.TextSize)
+		pdf.mark("P", func() { pdf.Cell(0, lh, fmt.Sprintf("Date: %s", n.Date.Format("2006-01-02"))) })
+		pdf.Ln(lh)
+		pdf.mark("P", func() {
+			pdf.Cell(0, lh, fmt.Sprintf("Invoice %s of %s, due %s (%d days overdue)",
+				inv.Number, inv.IssueDate.Format("2006-01-02"), inv.DueDate.Format("2006-01-02"), n.DaysOverdue))
+		})
+		pdf.Ln(lh + t.SectionSpacing/2)
+		if n.Text != "" {
+			pdf.mark("P", func() { pdf.MultiCell(0, lh, n.Text, "", "", false) })
+			pdf.Ln(t.SectionSpacing / 2)
+		}
+
+		if len(n.Interest) > 0 {
+			widths := pdf.columnWidths(50, 20, 40, 30, 40)
+			aligns := []string{"L", "C", "R", "C", "R"}
+			pdf.tag("Table", func() {
+				pdf.drawRow(widths, aligns, pdf.row(widths, []string{"Period", "Days", "Principal", "Rate", "Interest"}, headerRow))
+				for _, period := range n.Interest {
+					texts := []string{
+						period.From.Format("2006-01-02") + " - " + period.To.Format("2006-01-02"),
+						fmt.Sprintf("%d", period.Days),
+						period.Principal.String(),
+						period.Rate.String() + "%",
+						period.Interest.String(),
+					}
+					pdf.drawRow(widths, aligns, pdf.row(widths, texts, bodyRow))
+				}
+			})
+			pdf.Ln(t.SectionSpacing / 2)
+		}
+
+		pdf.tag("Div", func() {
+			pdf.totalsRow("Invoice amount:", n.Amount.String(), "", t.TextSize)
+			pdf.totalsRow("Payments received:", "-"+n.TotalPaid().String(), "", t.TextSize)
+			pdf.totalsRow("Outstanding:", n.Outstanding.String(), "", t.TextSize)
+			pdf.totalsRow("Interest:", n.TotalInterest().String(), "", t.TextSize)
+			pdf.totalsRow("Fees:", n.Fees.String(), "", t.TextSize)
+			pdf.totalsRow("Total Due:", n.TotalDue().String(), "B", t.TotalSize)
+		})
+		pdf.Ln(t.SectionSpacing / 2)
+
+		pdf.setFont("", t.TextSize)
+		pdf.mark("P", func() {
+			pdf.MultiCell(0, lh, fmt.Sprintf("Please pay %s by %s.", n.TotalDue(), n.PayBy.Format("2006-01-02")), "", "", false)
+		})
+	})
+}
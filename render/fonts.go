@@ -0,0 +1,426 @@
+package render
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Font is a TrueType font family embedded in the PDF with Unicode support.
+// Only the glyphs used are embedded. Styles without a file use the
+// regular face.
+type Font struct {
+	Family     string
+	Regular    string
+	Bold       string
+	Italic     string
+	BoldItalic string
+	// FS is the file system the files are read from. If nil, they are
+	// read from the operating system's file system.
+	FS fs.FS
+}
+
+func (f Font) files() map[string]string {
+	return map[string]string{"": f.Regular, "B": f.Bold, "I": f.Italic, "BI": f.BoldItalic}
+}
+
+// fontSet holds the fonts of a document and chooses a font for each
+// character. Families that are not registered are PDF core fonts, which
+// cover the Windows-1252 character set.
+type fontSet struct {
+	primary   string
+	faces     map[string]*fontFace
+	fallbacks map[string][]string
+}
+
+type fontFace struct {
+	family string
+	styles map[string]bool
+	ranges []runeRange
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+// loadFonts registers the fonts of the options with a PDF.
+func loadFonts(pdf *fpdf.Fpdf, opts Options) (*fontSet, error) {
+	set := &fontSet{
+		primary:   opts.FontFamily,
+		faces:     make(map[string]*fontFace),
+		fallbacks: opts.FallbackFonts,
+	}
+	if set.primary == "" {
+		set.primary = "Arial"
+	}
+	for _, f := range opts.Fonts {
+		if f.Family == "" || f.Regular == "" {
+			return nil, fmt.Errorf("font %q: family and regular face are required", f.Family)
+		}
+		face := &fontFace{family: f.Family, styles: make(map[string]bool)}
+		for style, name := range f.files() {
+			if name == "" {
+				continue
+			}
+			data, err := readFile(f.FS, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load font %s: %w", f.Family, err)
+			}
+			if style == "" {
+				if face.ranges, err = parseCmap(data); err != nil {
+					return nil, fmt.Errorf("font %s: %w", f.Family, err)
+				}
+			}
+			pdf.AddUTF8FontFromBytes(f.Family, style, data)
+			face.styles[style] = true
+		}
+		if err := pdf.Error(); err != nil {
+			return nil, fmt.Errorf("font %s: %w", f.Family, err)
+		}
+		set.faces[strings.ToLower(f.Family)] = face
+	}
+	return set, nil
+}
+
+func readFile(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(fsys, name)
+}
+
+// face returns the registered font of a family, or nil for core fonts.
+func (s *fontSet) face(family string) *fontFace {
+	return s.faces[strings.ToLower(family)]
+}
+
+// style returns the closest style of a family that is available.
+func (s *fontSet) style(family, style string) string {
+	face := s.face(family)
+	if face == nil || face.styles[strings.ReplaceAll(style, "U", "")] {
+		return style
+	}
+	underline := ""
+	if strings.Contains(style, "U") {
+		underline = "U"
+	}
+	if strings.Contains(style, "B") && face.styles["B"] {
+		return "B" + underline
+	}
+	if strings.Contains(style, "I") && face.styles["I"] {
+		return "I" + underline
+	}
+	return underline
+}
+
+// covers returns true if a family has a glyph for r.
+func (s *fontSet) covers(family string, r rune) bool {
+	face := s.face(family)
+	if face == nil {
+		_, ok := charmap.Windows1252.EncodeRune(r)
+		return ok
+	}
+	i := sort.Search(len(face.ranges), func(i int) bool { return face.ranges[i].hi >= r })
+	return i < len(face.ranges) && face.ranges[i].lo <= r
+}
+
+// pick returns the family used for r: the first of families that has a
+// glyph for it, then the fallbacks for its script and the fallbacks for
+// all scripts. If none has, the first family is used.
+func (s *fontSet) pick(families []string, r rune) string {
+	for _, f := range families {
+		if s.covers(f, r) {
+			return f
+		}
+	}
+	for _, f := range s.fallbacks[script(r)] {
+		if s.covers(f, r) {
+			return f
+		}
+	}
+	for _, f := range s.fallbacks[""] {
+		if s.covers(f, r) {
+			return f
+		}
+	}
+	return families[0]
+}
+
+// pickText returns the family used for text set in a single font: the
+// first of families, its fallbacks and the fallbacks for all scripts that
+// has every glyph.
+func (s *fontSet) pickText(family, text string) string {
+	candidates := []string{family}
+	for _, r := range text {
+		if !s.covers(family, r) {
+			candidates = append(candidates, s.fallbacks[script(r)]...)
+			break
+		}
+	}
+	candidates = append(candidates, s.fallbacks[""]...)
+	for _, f := range candidates {
+		if s.coversAll(f, text) {
+			return f
+		}
+	}
+	return family
+}
+
+func (s *fontSet) coversAll(family, text string) bool {
+	for _, r := range text {
+		if !s.covers(family, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// encode converts text to the encoding of a family: UTF-8 for registered
+// fonts and Windows-1252 for core fonts.
+func (s *fontSet) encode(family, text string) string {
+	if s.face(family) != nil {
+		return text
+	}
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		b, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			b = '?'
+		}
+		out = append(out, b)
+	}
+	return string(out)
+}
+
+// script returns the name of the Unicode script of r, as in
+// unicode.Scripts.
+func script(r rune) string {
+	for name, table := range unicode.Scripts {
+		if unicode.Is(table, r) {
+			return name
+		}
+	}
+	return ""
+}
+
+var errCmap = errors.New("no Unicode character map")
+
+// parseCmap returns the characters mapped to glyphs by the Unicode cmap
+// subtable of a TrueType or OpenType font, as sorted ranges.
+func parseCmap(data []byte) ([]runeRange, error) {
+	u16 := func(off int) int {
+		if off < 0 || off+2 > len(data) {
+			return -1
+		}
+		return int(binary.BigEndian.Uint16(data[off:]))
+	}
+	u32 := func(off int) int {
+		if off < 0 || off+4 > len(data) {
+			return -1
+		}
+		return int(binary.BigEndian.Uint32(data[off:]))
+	}
+
+	cmap := -1
+	for i := range max(u16(4), 0) {
+		rec := 12 + 16*i
+		if rec+16 <= len(data) && string(data[rec:rec+4]) == "cmap" {
+			cmap = u32(rec + 8)
+		}
+	}
+	if cmap < 0 {
+		return nil, errCmap
+	}
+	// Prefer full Unicode subtables over BMP ones.
+	best, bestRank := -1, 0
+	for i := range max(u16(cmap+2), 0) {
+		rec := cmap + 4 + 8*i
+		platform, encoding, offset := u16(rec), u16(rec+2), u32(rec+4)
+		rank := 0
+		switch {
+		case platform == 3 && encoding == 10, platform == 0 && (encoding == 4 || encoding == 6):
+			rank = 2
+		case platform == 3 && encoding == 1, platform == 0:
+			rank = 1
+		}
+		if rank > bestRank && offset >= 0 {
+			best, bestRank = cmap+offset, rank
+		}
+	}
+	if best < 0 {
+		return nil, errCmap
+	}
+
+	var ranges []runeRange
+	add := func(r rune) {
+		if n := len(ranges); n > 0 && ranges[n-1].hi == r-1 {
+			ranges[n-1].hi = r
+			return
+		}
+		ranges = append(ranges, runeRange{r, r})
+	}
+	switch u16(best) {
+	case 4:
+		segments := u16(best+6) / 2
+		ends := best + 14
+		starts := ends + 2*segments + 2
+		deltas := starts + 2*segments
+		offsets := deltas + 2*segments
+		if u16(offsets+2*segments-2) < 0 {
+			return nil, errCmap
+		}
+		for i := range segments {
+			start, end := u16(starts+2*i), u16(ends+2*i)
+			delta, rangeOffset := u16(deltas+2*i), u16(offsets+2*i)
+			for c := start; c <= end && c != 0xffff; c++ {
+				glyph := c + delta
+				if rangeOffset != 0 {
+					if glyph = u16(offsets + 2*i + rangeOffset + 2*(c-start)); glyph <= 0 {
+						continue
+					}
+					glyph += delta
+				}
+				if glyph&0xffff > 0 {
+					add(rune(c))
+				}
+			}
+		}
+	case 12:
+		groups := u32(best + 12)
+		if u32(best+16+12*groups-4) < 0 {
+			return nil, errCmap
+		}
+		for i := range groups {
+			start, end := u32(best+16+12*i), u32(best+20+12*i)
+			if end-start > unicode.MaxRune {
+				return nil, errCmap
+			}
+			if n := len(ranges); n > 0 && ranges[n-1].hi >= rune(start)-1 {
+				ranges[n-1].hi = max(ranges[n-1].hi, rune(end))
+				continue
+			}
+			ranges = append(ranges, runeRange{rune(start), rune(end)})
+		}
+	default:
+		return nil, errCmap
+	}
+	return ranges, nil
+}
+
+// document is a PDF set in the font family of the rendering options or its
+// theme. It encodes text for the font and switches to a fallback family for
+// text the font cannot display.
+type document struct {
+	*fpdf.Fpdf
+	fonts  *fontSet
+	theme  Theme
+	family string
+	style  string
+	size   float64
+	// tags is the structure of tagged documents.
+	tags *structTree
+}
+
+// newDocument creates a PDF with the page setup and fonts of the options.
+// The margins include the space for headers and footers.
+func newDocument(opts Options) (*document, error) {
+	pdf := fpdf.New(opts.Orientation, "mm", opts.PageSize, "")
+	fonts, err := loadFonts(pdf, opts)
+	if err != nil {
+		return nil, err
+	}
+	pdf.SetMargins(opts.MarginLeft, opts.MarginTop+opts.headerHeight(), opts.MarginRight)
+	pdf.SetAutoPageBreak(true, opts.MarginBottom+opts.Footer.height())
+	return &document{Fpdf: pdf, fonts: fonts, family: fonts.primary}, nil
+}
+
+// setFont sets the style and size of the text that follows.
+func (d *document) setFont(style string, size float64) {
+	d.family = d.fonts.primary
+	if d.theme.FontFamily != "" {
+		d.family = d.theme.FontFamily
+	}
+	d.style, d.size = style, size
+	d.use(d.family)
+}
+
+// setHeadingFont sets the style and size of the headings that follow.
+func (d *document) setHeadingFont(style string, size float64) {
+	d.setFont(style, size)
+	if d.theme.HeadingFontFamily != "" {
+		d.family = d.theme.HeadingFontFamily
+		d.use(d.family)
+	}
+}
+
+func (d *document) use(family string) {
+	d.SetFont(family, d.fonts.style(family, d.style), d.size)
+}
+
+// text selects the font for text and returns the text encoded for it.
+func (d *document) text(text string) string {
+	family := d.fonts.pickText(d.family, text)
+	d.use(family)
+	return d.fonts.encode(family, text)
+}
+
+// Cell prints text in a cell like fpdf.Fpdf.Cell.
+func (d *document) Cell(w, h float64, text string) {
+	d.Fpdf.Cell(w, h, d.text(text))
+}
+
+// CellFormat prints text in a cell like fpdf.Fpdf.CellFormat.
+func (d *document) CellFormat(w, h float64, text, border string, ln int, align string, fill bool, link int, linkStr string) {
+	d.Fpdf.CellFormat(w, h, d.text(text), border, ln, align, fill, link, linkStr)
+}
+
+// MultiCell prints text with line breaks like fpdf.Fpdf.MultiCell.
+func (d *document) MultiCell(w, h float64, text, border, align string, fill bool) {
+	d.Fpdf.MultiCell(w, h, d.text(text), border, align, fill)
+}
+
+// splitText breaks text into lines that fit into width w in the current
+// style, breaking words that are longer than a line.
+func (d *document) splitText(text string, w float64) []string {
+	family := d.fonts.pickText(d.family, text)
+	d.use(family)
+	width := func(s string) float64 {
+		return d.GetStringWidth(d.fonts.encode(family, s))
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if width(candidate) <= w || line == "" && width(word) <= w {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			for _, r := range word {
+				if line != "" && width(line+string(r)) > w {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
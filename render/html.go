@@ -0,0 +1,145 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/net/html"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// renderHTML lays out an HTML document with its stylesheets and returns
+// the PDF. Page size and margins are taken from the options unless set by
+// an @page rule. The headers, footers and watermarks are those of the
+// invoice, which may be nil. The structure tree is nil unless the options
+// ask for a tagged PDF.
+func renderHTML(doc *html.Node, opts Options, inv *invoice.Invoice) (*fpdf.Fpdf, *structTree, error) {
+	l, end, err := layoutHTML(doc, opts, inv)
+	if err != nil {
+		return nil, nil, err
+	}
+	l.paint(end)
+	return l.pdf, l.tags, l.pdf.Error()
+}
+
+// layoutHTML lays out an HTML document and returns the layout and the
+// bottom of its content.
+func layoutHTML(doc *html.Node, opts Options, inv *invoice.Invoice) (*layout, float64, error) {
+	sheet := &stylesheet{}
+	sheet.parse(userAgentCSS)
+	sheet.origin = authorOrigin
+	for _, n := range findAll(doc, "style") {
+		if media := strings.ToLower(attr(n, "media")); media != "" &&
+			!strings.Contains(media, "print") && !strings.Contains(media, "all") {
+			continue
+		}
+		var src strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			src.WriteString(c.Data)
+		}
+		sheet.parse(src.String())
+	}
+
+	pdf, margins := newPage(opts, sheet.page)
+	pageMargins := margins
+	margins[top] += opts.headerHeight()
+	margins[bottom] += opts.Footer.height()
+	pdf.SetMargins(margins[left], margins[top], margins[right])
+	pdf.SetAutoPageBreak(false, margins[bottom])
+	pageWidth, pageHeight := pdf.GetPageSize()
+	fonts, err := loadFonts(pdf, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	sections := newPageSections(opts, fonts, inv, pageMargins)
+	images := newImageSet(pdf, opts.Images)
+	if err := sections.loadImages(images, opts.Logo.Source); err != nil {
+		return nil, 0, err
+	}
+
+	l := &layout{
+		pdf:        pdf,
+		fonts:      fonts,
+		sections:   sections,
+		marginTop:  margins[top],
+		pageHeight: pageHeight - margins[top] - margins[bottom],
+		paginate:   true,
+		widths:     make(map[textKey]float64),
+		families:   make(map[string][]string),
+	}
+	root := rootStyle(opts.FontFamily, opts.FontSize)
+	builder := &boxBuilder{sheet: sheet, root: root, images: images}
+	if opts.Tagged {
+		l.tags = newStructTree()
+		builder.elem = l.tags.root
+	}
+	var boxes []*box
+	if roots := findAll(doc, "html"); len(roots) > 0 {
+		boxes = builder.build(roots[0], computeStyle(sheet.cascade(roots[0]), root, root), 0)
+	}
+	return l, l.layoutBlocks(boxes, margins[left], 0, pageWidth-margins[left]-margins[right]), nil
+}
+
+// newPage creates a PDF with the page size and orientation of an @page
+// rule or the options, and returns it with the page margins.
+func newPage(opts Options, page map[string]string) (*fpdf.Fpdf, [4]float64) {
+	init := &fpdf.InitType{
+		OrientationStr: opts.Orientation,
+		UnitStr:        "mm",
+		SizeStr:        opts.PageSize,
+	}
+	var dims []float64
+	for _, v := range fields(page["size"]) {
+		switch v = strings.ToLower(v); v {
+		case "portrait":
+			init.OrientationStr = "P"
+		case "landscape":
+			init.OrientationStr = "L"
+		case "a3", "a4", "a5", "letter", "legal", "tabloid":
+			init.SizeStr = v
+		default:
+			if l, ok := parseLength(v, opts.FontSize, opts.FontSize); ok && !l.percent && !l.auto {
+				dims = append(dims, l.value)
+			}
+		}
+	}
+	switch len(dims) {
+	case 1:
+		init.Size = fpdf.SizeType{Wd: dims[0], Ht: dims[0]}
+	case 2:
+		init.Size = fpdf.SizeType{Wd: dims[0], Ht: dims[1]}
+	}
+
+	margins := [4]float64{opts.MarginTop, opts.MarginRight, opts.MarginBottom, opts.MarginLeft}
+	for i, side := range sides {
+		if l, ok := parseLength(page["margin-"+side], opts.FontSize, opts.FontSize); ok && !l.percent && !l.auto {
+			margins[i] = l.value
+		}
+	}
+	return fpdf.NewCustom(init), margins
+}
+
+// textContent returns the text of a node and its descendants.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// findAll returns the elements with the given tag in document order.
+func findAll(n *html.Node, tag string) []*html.Node {
+	var out []*html.Node
+	if n.Type == html.ElementNode && n.Data == tag {
+		out = append(out, n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		out = append(out, findAll(c, tag)...)
+	}
+	return out
+}
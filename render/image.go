@@ -0,0 +1,162 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// ErrUnsupportedImage is returned for images that are not PNG, JPEG, GIF,
+// SVG or PDF, or that cannot be read.
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+// Logo places an image on the first page. Images are given as file names
+// or data URIs ("data:image/png;base64,...").
+type Logo struct {
+	// Source is the image. The SimpleRenderer uses the supplier's logo if
+	// it is empty.
+	Source string
+	// X and Y are the position of the top left corner on the page in mm.
+	// If both are zero, the logo is placed at the top right margin, below
+	// the header.
+	X, Y float64
+	// Width and Height are the size in mm. If one is zero, it follows
+	// from the aspect ratio of the image. If both are, the height is 20 mm.
+	Width, Height float64
+}
+
+// Letterhead is stationery printed behind the content of the pages. The
+// images are stretched to cover the page. For PDFs, the first page is
+// used.
+type Letterhead struct {
+	// First is the background of the first page.
+	First string
+	// Next is the background of the following pages. If it is empty, they
+	// have none.
+	Next string
+}
+
+// image is an image registered with a PDF. SVG images are drawn as
+// vector paths and PDF pages as form XObjects.
+type image struct {
+	name string
+	svg  *svgImage
+	page *pdfPage
+	// width and height are the intrinsic size in mm at 96 dpi.
+	width, height float64
+}
+
+// imageSet loads the images of a document, each source once.
+type imageSet struct {
+	pdf    *fpdf.Fpdf
+	fsys   fs.FS
+	images map[string]*image
+}
+
+func newImageSet(pdf *fpdf.Fpdf, fsys fs.FS) *imageSet {
+	return &imageSet{pdf: pdf, fsys: fsys, images: make(map[string]*image)}
+}
+
+// load registers the image of a file name or data URI with the PDF.
+func (s *imageSet) load(src string) (*image, error) {
+	if img, ok := s.images[src]; ok {
+		return img, nil
+	}
+	name := src
+	if strings.HasPrefix(src, "data:") {
+		name = "data URI"
+	}
+	data, err := readImage(s.fsys, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image %s: %w", name, err)
+	}
+
+	img := &image{name: fmt.Sprintf("image%d", len(s.images))}
+	var imageType string
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG")):
+		imageType = "PNG"
+	case bytes.HasPrefix(data, []byte("\xff\xd8")):
+		imageType = "JPG"
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		imageType = "GIF"
+	case bytes.HasPrefix(data, []byte("%PDF")):
+		page, err := importPage(s.pdf, data, img.name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrUnsupportedImage, name, err)
+		}
+		img.page = page
+		img.width, img.height = page.width(), page.height()
+		s.images[src] = img
+		return img, nil
+	case bytes.Contains(data[:min(len(data), 1024)], []byte("<svg")):
+		svg, err := parseSVG(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrUnsupportedImage, name, err)
+		}
+		img.svg = svg
+		img.width, img.height = svg.width, svg.height
+		s.images[src] = img
+		return img, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, name)
+	}
+
+	info := s.pdf.RegisterImageOptionsReader(img.name, fpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(data))
+	if err := s.pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to load image %s: %w", name, err)
+	}
+	info.SetDpi(96)
+	img.width, img.height = info.Extent()
+	s.images[src] = img
+	return img, nil
+}
+
+// readImage reads the content of a file name or data URI.
+func readImage(fsys fs.FS, src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "data:") {
+		return readFile(fsys, src)
+	}
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(src, "data:"), ",")
+	if !ok {
+		return nil, errors.New("malformed data URI")
+	}
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(payload), ""))
+	}
+	text, err := url.PathUnescape(payload)
+	return []byte(text), err
+}
+
+// size returns the size of the image drawn with the given width and
+// height, either of which may be zero to keep the aspect ratio.
+func (img *image) size(w, h float64) (float64, float64) {
+	switch {
+	case w > 0 && h > 0:
+		return w, h
+	case w > 0:
+		return w, w * img.height / img.width
+	case h > 0:
+		return h * img.width / img.height, h
+	}
+	return img.width, img.height
+}
+
+// draw draws the image into a rectangle. SVG images keep their aspect
+// ratio.
+func (img *image) draw(pdf *fpdf.Fpdf, x, y, w, h float64) {
+	switch {
+	case img.svg != nil:
+		img.svg.draw(pdf, x, y, w, h)
+	case img.page != nil:
+		img.page.draw(pdf, x, y, w, h)
+	default:
+		pdf.ImageOptions(img.name, x, y, w, h, false, fpdf.ImageOptions{}, 0, "")
+	}
+}
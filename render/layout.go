@@ -0,0 +1,1137 @@
+package render
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// layout positions the boxes of a document. Vertical positions are
+// continuous across pages: page n covers [n*pageHeight, (n+1)*pageHeight)
+// of the content area, and items are distributed to pages when painted.
+type layout struct {
+	pdf        *fpdf.Fpdf
+	fonts      *fontSet
+	sections   *pageSections
+	tags       *structTree
+	marginTop  float64
+	pageHeight float64
+	paginate   bool
+	items      []drawItem
+	widths     map[textKey]float64
+	families   map[string][]string
+	// artifacts is set while laying out content that is not part of the
+	// structure of tagged documents, such as repeated table headers.
+	artifacts bool
+}
+
+type textKey struct {
+	font font
+	text string
+}
+
+type font struct {
+	family string
+	style  string
+	size   float64
+}
+
+type drawKind int
+
+const (
+	drawBox drawKind = iota
+	drawText
+	drawImage
+)
+
+// drawItem is a box decoration, a run of text or an image. Text is
+// positioned at the top of its line box, with the baseline given as an
+// offset.
+type drawItem struct {
+	kind     drawKind
+	style    *style
+	x, y     float64
+	w, h     float64
+	baseline float64
+	font     font
+	text     string
+	image    *image
+	// elem is the structure element of text and images, or nil for
+	// artifacts.
+	elem *structElem
+}
+
+const epsilon = 1e-6
+
+func (l *layout) page(y float64) int {
+	return int(math.Floor(y/l.pageHeight + epsilon))
+}
+
+// nextPage returns the top of the page following y, or y itself if it is
+// already at the top of a page.
+func (l *layout) nextPage(y float64) float64 {
+	if !l.paginate {
+		return y
+	}
+	start := float64(l.page(y)) * l.pageHeight
+	if y-start < epsilon {
+		return y
+	}
+	return start + l.pageHeight
+}
+
+// fit returns the position of content of height h placed at y, moving it
+// to the next page if it would be cut by a page break and fits on a page.
+func (l *layout) fit(y, h float64) float64 {
+	if !l.paginate || h > l.pageHeight || l.page(y) == l.page(y+h-epsilon) {
+		return y
+	}
+	return l.nextPage(y)
+}
+
+// measure runs f without pagination or output and returns its result.
+func (l *layout) measure(f func() float64) float64 {
+	paginate, n := l.paginate, len(l.items)
+	l.paginate = false
+	v := f()
+	l.paginate = paginate
+	l.items = l.items[:n]
+	return v
+}
+
+// fontOf maps the font properties of a style to a PDF font in the first
+// family of its font-family list.
+func (l *layout) fontOf(s *style) font {
+	f := font{family: l.familyList(s.fontFamily)[0], size: math.Round(s.fontSize*100) / 100}
+	if s.bold {
+		f.style += "B"
+	}
+	if s.italic {
+		f.style += "I"
+	}
+	if s.underline {
+		f.style += "U"
+	}
+	return f
+}
+
+// familyList resolves a font-family list to the registered fonts and PDF
+// core fonts it names, followed by the font family of the options.
+func (l *layout) familyList(list string) []string {
+	if families, ok := l.families[list]; ok {
+		return families
+	}
+	var families []string
+	add := func(family string) {
+		for _, f := range families {
+			if strings.EqualFold(f, family) {
+				return
+			}
+		}
+		families = append(families, family)
+	}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.Trim(strings.TrimSpace(name), `"'`)
+		if face := l.fonts.face(name); face != nil {
+			add(face.family)
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "arial", "helvetica", "sans-serif", "system-ui", "verdana", "tahoma",
+			"segoe ui", "roboto", "inter", "-apple-system":
+			add("Arial")
+		case "times", "times new roman", "serif", "georgia":
+			add("Times")
+		case "courier", "courier new", "monospace":
+			add("Courier")
+		}
+	}
+	add(l.fonts.primary)
+	l.families[list] = families
+	return families
+}
+
+// textRun is text set in a single font.
+type textRun struct {
+	font font
+	text string
+}
+
+// runs splits text into runs, setting each character in the first family
+// of the style or of the fallback fonts that has a glyph for it.
+func (l *layout) runs(s *style, text string) []textRun {
+	base := l.fontOf(s)
+	families := l.familyList(s.fontFamily)
+	var out []textRun
+	for _, r := range text {
+		f := base
+		f.family = l.fonts.pick(families, r)
+		f.style = l.fonts.style(f.family, base.style)
+		if n := len(out); n > 0 && out[n-1].font == f {
+			out[n-1].text += string(r)
+			continue
+		}
+		out = append(out, textRun{font: f, text: string(r)})
+	}
+	return out
+}
+
+// textWidth returns the width of text in a style.
+func (l *layout) textWidth(s *style, text string) float64 {
+	w := 0.0
+	for _, run := range l.runs(s, text) {
+		w += l.runWidth(run)
+	}
+	return w
+}
+
+func (l *layout) runWidth(run textRun) float64 {
+	key := textKey{run.font, run.text}
+	if w, ok := l.widths[key]; ok {
+		return w
+	}
+	f := run.font
+	l.pdf.SetFont(f.family, strings.ReplaceAll(f.style, "U", ""), f.size)
+	w := l.pdf.GetStringWidth(l.fonts.encode(f.family, run.text))
+	l.widths[key] = w
+	return w
+}
+
+// Block layout
+
+// layoutBlocks stacks block level boxes vertically, collapsing adjacent
+// margins, and returns the bottom including the last margin.
+func (l *layout) layoutBlocks(children []*box, x, y, w float64) float64 {
+	margin := 0.0
+	for _, c := range children {
+		y, margin = l.layoutBlock(c, x, y, w, margin)
+	}
+	return y + margin
+}
+
+// layoutBlock lays out a block level box in a containing block of width
+// cbWidth after a sibling with the given bottom margin. It returns the
+// bottom of its border box and its bottom margin.
+func (l *layout) layoutBlock(b *box, x, y, cbWidth, prevMargin float64) (float64, float64) {
+	s := b.style
+	y += max(prevMargin, s.margin[top].resolve(cbWidth))
+	if s.breakBefore == "page" {
+		y = l.nextPage(y)
+	}
+	ml, w := l.horizontal(b, cbWidth)
+	if s.breakInside == "avoid" && l.paginate {
+		height := l.measure(func() float64 { return l.layoutBox(b, x+ml, y, w, 0, 0) - y })
+		y = l.fit(y, height)
+	}
+	end := l.layoutBox(b, x+ml, y, w, 0, 0)
+	if s.breakAfter == "page" {
+		return l.nextPage(end), 0
+	}
+	return end, s.margin[bottom].resolve(cbWidth)
+}
+
+// horizontal returns the left margin and border box width of a block in a
+// containing block of width cbWidth.
+func (l *layout) horizontal(b *box, cbWidth float64) (float64, float64) {
+	s := b.style
+	ml, mr := s.margin[left].resolve(cbWidth), s.margin[right].resolve(cbWidth)
+	extra := horizontalExtra(s, cbWidth)
+
+	var w float64
+	switch {
+	case !s.width.auto:
+		w = s.width.resolve(cbWidth)
+		if !s.borderBox {
+			w += extra
+		}
+	case b.kind == tableBox:
+		// Tables shrink to fit their content.
+		minW, maxW := l.contentWidths(b)
+		w = min(max(minW, cbWidth-ml-mr), maxW)
+	default:
+		w = cbWidth - ml - mr
+	}
+	if !s.maxWidth.auto {
+		maxW := s.maxWidth.resolve(cbWidth)
+		if !s.borderBox {
+			maxW += extra
+		}
+		w = min(w, maxW)
+	}
+	if minW := s.minWidth.resolve(cbWidth); minW > 0 {
+		if !s.borderBox {
+			minW += extra
+		}
+		w = max(w, minW)
+	}
+	w = max(w, extra)
+
+	free := cbWidth - w - ml - mr
+	switch {
+	case s.margin[left].auto && s.margin[right].auto:
+		ml += free / 2
+	case s.margin[left].auto:
+		ml += free
+	}
+	return ml, w
+}
+
+// horizontalExtra returns the horizontal padding and border of a style.
+func horizontalExtra(s *style, cbWidth float64) float64 {
+	return s.padding[left].resolve(cbWidth) + s.padding[right].resolve(cbWidth) +
+		s.border[left].width + s.border[right].width
+}
+
+// layoutBox lays out the content of a box whose border box starts at x, y
+// and returns the bottom of the border box. The box is at least minHeight
+// tall and its content is moved down by offset.
+func (l *layout) layoutBox(b *box, x, y, w, minHeight, offset float64) float64 {
+	s := b.style
+	index := len(l.items)
+	l.items = append(l.items, drawItem{kind: drawBox, style: s, x: x, y: y, w: w})
+
+	pt, pb := s.padding[top].resolve(w), s.padding[bottom].resolve(w)
+	pl := s.padding[left].resolve(w)
+	cx := x + s.border[left].width + pl
+	cy := y + s.border[top].width + pt
+	cw := max(w-horizontalExtra(s, w), 0)
+
+	var end float64
+	switch {
+	case b.kind == flexBox:
+		end = l.layoutFlex(b, cx, cy+offset, cw)
+	case b.kind == tableBox:
+		end = l.layoutTable(b, cx, cy+offset, cw)
+	case inlineContent(b.children):
+		end = l.layoutInline(b.children, s, cx, cy+offset, cw)
+	default:
+		end = l.layoutBlocks(b.children, cx, cy+offset, cw)
+	}
+	if !s.height.auto && !s.height.percent {
+		h := s.height.value
+		if s.borderBox {
+			h -= pt + pb + s.border[top].width + s.border[bottom].width
+		}
+		end = max(end, cy+h)
+	}
+	bottomEdge := max(end+pb+s.border[bottom].width, y+minHeight)
+	l.items[index].h = bottomEdge - y
+	return bottomEdge
+}
+
+func inlineContent(children []*box) bool {
+	for _, c := range children {
+		if !c.isInline() {
+			return false
+		}
+	}
+	return true
+}
+
+// Inline layout
+
+// token is a word, a space, an image or a forced line break in inline
+// content. The size of images is set by chunks.
+type token struct {
+	style  *style
+	text   string
+	space  bool
+	brk    bool
+	image  *image
+	width  float64
+	height float64
+	elem   *structElem
+}
+
+// tokenize splits inline content into tokens, collapsing white space
+// according to the white-space property.
+func tokenize(children []*box) []token {
+	var tokens []token
+	collapsed := true
+	for _, c := range children {
+		s := c.style
+		if c.kind == breakBox {
+			tokens = append(tokens, token{style: s, brk: true})
+			collapsed = true
+			continue
+		}
+		if c.kind == imageBox {
+			tokens = append(tokens, token{style: s, image: c.image, elem: c.elem})
+			collapsed = false
+			continue
+		}
+		text := transform(c.text, s.textTransform)
+		preserve := s.whiteSpace == "pre" || s.whiteSpace == "pre-wrap"
+		var word strings.Builder
+		flush := func() {
+			if word.Len() > 0 {
+				tokens = append(tokens, token{style: s, text: word.String(), elem: c.elem})
+				word.Reset()
+			}
+		}
+		for _, r := range text {
+			switch {
+			case r == '\n' && preserve:
+				flush()
+				tokens = append(tokens, token{style: s, brk: true})
+			case unicode.IsSpace(r) && r != '\u00a0':
+				if collapsed && !preserve {
+					continue
+				}
+				collapsed = true
+				if s.whiteSpace == "nowrap" || s.whiteSpace == "pre" {
+					// Spaces that may not wrap are part of the word.
+					word.WriteByte(' ')
+					continue
+				}
+				flush()
+				tokens = append(tokens, token{style: s, text: " ", space: true, elem: c.elem})
+			default:
+				word.WriteRune(r)
+				collapsed = false
+			}
+		}
+		flush()
+	}
+	return tokens
+}
+
+func transform(text, textTransform string) string {
+	switch textTransform {
+	case "uppercase":
+		return strings.ToUpper(text)
+	case "lowercase":
+		return strings.ToLower(text)
+	case "capitalize":
+		runes := []rune(text)
+		for i, r := range runes {
+			if i == 0 || unicode.IsSpace(runes[i-1]) {
+				runes[i] = unicode.ToTitle(r)
+			}
+		}
+		return string(runes)
+	}
+	return text
+}
+
+// chunk is a run of tokens between break opportunities.
+type chunk struct {
+	tokens []token
+	width  float64
+	space  bool
+	brk    bool
+}
+
+// chunks groups tokens into words that may not be broken, spaces and
+// forced breaks. Percentage sizes of images refer to the width w of the
+// container, or are ignored if it is zero.
+func (l *layout) chunks(tokens []token, w float64) []chunk {
+	var out []chunk
+	var cur chunk
+	flush := func() {
+		if len(cur.tokens) > 0 {
+			out = append(out, cur)
+		}
+		cur = chunk{}
+	}
+	for _, t := range tokens {
+		switch {
+		case t.brk:
+			flush()
+			out = append(out, chunk{brk: true, tokens: []token{t}})
+		case t.space:
+			flush()
+			out = append(out, chunk{space: true, tokens: []token{t}, width: l.textWidth(t.style, t.text)})
+		case t.image != nil:
+			t.width, t.height = imageSize(t.style, t.image, w)
+			cur.tokens = append(cur.tokens, t)
+			cur.width += t.width
+		default:
+			cur.tokens = append(cur.tokens, t)
+			cur.width += l.textWidth(t.style, t.text)
+		}
+	}
+	flush()
+	return out
+}
+
+// inlineWidths returns the widest word and the widest line of inline
+// content.
+func (l *layout) inlineWidths(children []*box) (float64, float64) {
+	var minW, maxW, lineW float64
+	for _, c := range l.chunks(tokenize(children), 0) {
+		if c.brk {
+			maxW, lineW = max(maxW, lineW), 0
+			continue
+		}
+		if !c.space {
+			minW = max(minW, c.width)
+		}
+		lineW += c.width
+	}
+	return minW, max(maxW, lineW)
+}
+
+// line is a line box of inline content.
+type line struct {
+	tokens []token
+	width  float64
+}
+
+// layoutInline breaks inline content into lines of width w and returns
+// the bottom of the last line.
+func (l *layout) layoutInline(children []*box, s *style, x, y, w float64) float64 {
+	chunks := l.chunks(tokenize(children), w)
+	if len(chunks) == 0 {
+		return y
+	}
+
+	var lines []line
+	var cur line
+	pending := false
+	push := func() {
+		// Trailing spaces do not count towards alignment.
+		for len(cur.tokens) > 0 && cur.tokens[len(cur.tokens)-1].space {
+			last := cur.tokens[len(cur.tokens)-1]
+			cur.width -= l.textWidth(last.style, last.text)
+			cur.tokens = cur.tokens[:len(cur.tokens)-1]
+		}
+		lines = append(lines, cur)
+		cur = line{}
+		pending = false
+	}
+	for _, c := range chunks {
+		switch {
+		case c.brk:
+			cur.tokens = append(cur.tokens, c.tokens...)
+			push()
+		case c.space:
+			if len(cur.tokens) > 0 {
+				cur.tokens = append(cur.tokens, c.tokens...)
+				cur.width += c.width
+				pending = true
+			}
+		default:
+			if cur.width+c.width > w+epsilon && pending {
+				push()
+			}
+			if c.width > w+epsilon && len(cur.tokens) == 0 {
+				// Break words that do not fit on a line of their own.
+				for _, t := range c.tokens {
+					if t.image != nil {
+						if len(cur.tokens) > 0 && cur.width+t.width > w+epsilon {
+							push()
+						}
+						cur.tokens = append(cur.tokens, t)
+						cur.width += t.width
+						continue
+					}
+					for t.text != "" {
+						n := l.fitRunes(t.style, t.text, w-cur.width)
+						if n == 0 && len(cur.tokens) == 0 {
+							_, n = utf8.DecodeRuneInString(t.text)
+						}
+						if n > 0 {
+							part := token{style: t.style, text: t.text[:n], elem: t.elem}
+							cur.tokens = append(cur.tokens, part)
+							cur.width += l.textWidth(t.style, part.text)
+							t.text = t.text[n:]
+						}
+						if t.text != "" {
+							push()
+						}
+					}
+				}
+				pending = false
+				continue
+			}
+			cur.tokens = append(cur.tokens, c.tokens...)
+			cur.width += c.width
+			pending = false
+		}
+	}
+	if len(cur.tokens) > 0 {
+		push()
+	}
+
+	for _, ln := range lines {
+		y = l.layoutLine(ln, s, x, y, w)
+	}
+	return y
+}
+
+// fitRunes returns the length in bytes of the longest prefix of text that
+// fits into width w.
+func (l *layout) fitRunes(s *style, text string, w float64) int {
+	n := 0
+	for i, r := range text {
+		end := i + utf8.RuneLen(r)
+		if l.textWidth(s, text[:end]) > w+epsilon {
+			break
+		}
+		n = end
+	}
+	return n
+}
+
+// imageSize returns the size of an image with the given style in a
+// container of width cbWidth. Percentages are ignored if cbWidth is zero.
+func imageSize(s *style, img *image, cbWidth float64) (float64, float64) {
+	resolvable := func(l length) bool {
+		return !l.auto && (!l.percent || cbWidth > 0)
+	}
+	var w, h float64
+	if resolvable(s.width) {
+		w = s.width.resolve(cbWidth)
+	}
+	if !s.height.auto && !s.height.percent {
+		h = s.height.value
+	}
+	w, h = img.size(w, h)
+	if resolvable(s.maxWidth) && w > s.maxWidth.resolve(cbWidth) {
+		maxW := s.maxWidth.resolve(cbWidth)
+		w, h = maxW, h*maxW/w
+	}
+	return w, h
+}
+
+// ascent returns the distance from the top of a line box of the given
+// style to its baseline, and the height below the baseline.
+func ascent(s *style) (float64, float64) {
+	size := s.fontSize * mmPerPt
+	lh := s.lineHeightMM()
+	above := (lh-size)/2 + 0.8*size
+	return above, lh - above
+}
+
+// layoutLine places the text of a line and returns its bottom.
+func (l *layout) layoutLine(ln line, s *style, x, y, w float64) float64 {
+	above, below := ascent(s)
+	for _, t := range ln.tokens {
+		if t.image != nil {
+			// Images sit on the baseline.
+			above = max(above, t.height)
+			continue
+		}
+		a, b := ascent(t.style)
+		above, below = max(above, a), max(below, b)
+	}
+	height := above + below
+	y = l.fit(y, height)
+
+	switch s.textAlign {
+	case "right", "end":
+		x += w - ln.width
+	case "center":
+		x += (w - ln.width) / 2
+	}
+	// Draw runs of tokens with the same style together.
+	for i := 0; i < len(ln.tokens); {
+		t := ln.tokens[i]
+		if t.brk {
+			i++
+			continue
+		}
+		elem := t.elem
+		if l.artifacts {
+			elem = nil
+		}
+		if t.image != nil {
+			if !t.style.hidden {
+				l.items = append(l.items, drawItem{
+					kind: drawImage, style: t.style, x: x, y: y + above - t.height, w: t.width, h: t.height, image: t.image, elem: elem,
+				})
+			}
+			x += t.width
+			i++
+			continue
+		}
+		var text strings.Builder
+		j := i
+		for ; j < len(ln.tokens) && ln.tokens[j].style == t.style && ln.tokens[j].elem == t.elem && !ln.tokens[j].brk && ln.tokens[j].image == nil; j++ {
+			text.WriteString(ln.tokens[j].text)
+		}
+		draw := !t.style.hidden && strings.TrimSpace(text.String()) != ""
+		for _, run := range l.runs(t.style, text.String()) {
+			width := l.runWidth(run)
+			if draw {
+				l.items = append(l.items, drawItem{
+					kind: drawText, style: t.style, x: x, y: y, w: width, h: height,
+					baseline: above, font: run.font, text: run.text, elem: elem,
+				})
+			}
+			x += width
+		}
+		i = j
+	}
+	return y + height
+}
+
+// Flex layout
+
+// layoutFlex lays out the items of a single line, row direction flex
+// container and returns the bottom of the line.
+func (l *layout) layoutFlex(b *box, x, y, w float64) float64 {
+	items := b.children
+	if len(items) == 0 {
+		return y
+	}
+	s := b.style
+	gap := s.columnGap
+	widths := make([]float64, len(items))
+	mins := make([]float64, len(items))
+	margins := make([][2]float64, len(items))
+	total := gap * float64(len(items)-1)
+	for i, item := range items {
+		is := item.style
+		margins[i] = [2]float64{is.margin[left].resolve(w), is.margin[right].resolve(w)}
+		minW, maxW := l.contentWidths(item)
+		if !is.width.auto {
+			maxW = is.width.resolve(w)
+			if !is.borderBox {
+				maxW += horizontalExtra(is, w)
+			}
+			minW = min(minW, maxW)
+		}
+		widths[i], mins[i] = maxW, minW
+		total += maxW + margins[i][0] + margins[i][1]
+	}
+
+	free := w - total
+	if free < 0 {
+		shrinkable := 0.0
+		for i := range items {
+			shrinkable += widths[i] - mins[i]
+		}
+		if shrinkable > 0 {
+			ratio := min(-free/shrinkable, 1)
+			for i := range items {
+				widths[i] -= (widths[i] - mins[i]) * ratio
+			}
+			free = min(free+shrinkable*ratio, 0)
+		}
+	} else {
+		grow := 0.0
+		for _, item := range items {
+			grow += item.style.flexGrow
+		}
+		if grow > 0 {
+			for i, item := range items {
+				widths[i] += free * item.style.flexGrow / grow
+			}
+			free = 0
+		}
+	}
+
+	start, between := 0.0, gap
+	if free > 0 {
+		switch s.justifyContent {
+		case "flex-end", "end", "right":
+			start = free
+		case "center":
+			start = free / 2
+		case "space-between":
+			if len(items) > 1 {
+				between += free / float64(len(items)-1)
+			}
+		case "space-around":
+			between += free / float64(len(items))
+			start = free / float64(len(items)) / 2
+		case "space-evenly":
+			between += free / float64(len(items)+1)
+			start = free / float64(len(items)+1)
+		}
+	}
+
+	heights := make([]float64, len(items))
+	rowHeight := 0.0
+	for i, item := range items {
+		is := item.style
+		heights[i] = l.measure(func() float64 { return l.layoutBox(item, 0, 0, widths[i], 0, 0) })
+		rowHeight = max(rowHeight, heights[i]+is.margin[top].resolve(w)+is.margin[bottom].resolve(w))
+	}
+	y = l.fit(y, rowHeight)
+
+	end := y + rowHeight
+	ix := x + start
+	for i, item := range items {
+		is := item.style
+		mt, mb := is.margin[top].resolve(w), is.margin[bottom].resolve(w)
+		ix += margins[i][0]
+		iy, minHeight := y+mt, 0.0
+		switch s.alignItems {
+		case "center":
+			iy += (rowHeight - mt - mb - heights[i]) / 2
+		case "flex-end", "end":
+			iy += rowHeight - mt - mb - heights[i]
+		case "flex-start", "start", "baseline":
+		default:
+			if is.height.auto {
+				minHeight = rowHeight - mt - mb
+			}
+		}
+		end = max(end, l.layoutBox(item, ix, iy, widths[i], minHeight, 0)+mb)
+		ix += widths[i] + margins[i][1] + between
+	}
+	return end
+}
+
+// Intrinsic widths
+
+// contentWidths returns the min-content and max-content widths of the
+// border box of a box.
+func (l *layout) contentWidths(b *box) (float64, float64) {
+	s := b.style
+	extra := horizontalExtra(s, 0)
+	if !s.width.auto && !s.width.percent {
+		w := s.width.value
+		if !s.borderBox {
+			w += extra
+		}
+		return w, w
+	}
+
+	var minW, maxW float64
+	switch {
+	case b.kind == tableBox:
+		cols := l.columns(b)
+		spacing := tableSpacing(s) * float64(len(cols)+1)
+		minW, maxW = spacing, spacing
+		for _, c := range cols {
+			minW += c.min
+			maxW += c.max
+		}
+	case b.kind == flexBox:
+		minW = s.columnGap * float64(max(len(b.children)-1, 0))
+		maxW = minW
+		for _, c := range b.children {
+			cmin, cmax := l.contentWidths(c)
+			m := c.style.margin[left].resolve(0) + c.style.margin[right].resolve(0)
+			minW += cmin + m
+			maxW += cmax + m
+		}
+	case inlineContent(b.children):
+		minW, maxW = l.inlineWidths(b.children)
+	default:
+		for _, c := range b.children {
+			cmin, cmax := l.contentWidths(c)
+			m := c.style.margin[left].resolve(0) + c.style.margin[right].resolve(0)
+			minW = max(minW, cmin+m)
+			maxW = max(maxW, cmax+m)
+		}
+	}
+	return minW + extra, maxW + extra
+}
+
+// Table layout
+
+type column struct {
+	min, max float64
+	fixed    bool
+}
+
+type cell struct {
+	box  *box
+	col  int
+	span int
+}
+
+type row struct {
+	box    *box
+	cells  []cell
+	header bool
+}
+
+func tableSpacing(s *style) float64 {
+	if s.borderCollapse {
+		return 0
+	}
+	return s.borderSpacing
+}
+
+// tableRows returns the rows of a table with the columns of their cells.
+func tableRows(t *box) []row {
+	var rows []row
+	for _, group := range t.children {
+		for _, r := range group.children {
+			tr := row{box: r, header: group.header}
+			col := 0
+			for _, c := range r.children {
+				span := max(c.style.colspan, 1)
+				tr.cells = append(tr.cells, cell{box: c, col: col, span: span})
+				col += span
+			}
+			rows = append(rows, tr)
+		}
+	}
+	return rows
+}
+
+// columns computes the minimum and maximum widths of the columns of a
+// table from the content and widths of its cells.
+func (l *layout) columns(t *box) []column {
+	rows := tableRows(t)
+	n := 0
+	for _, r := range rows {
+		if len(r.cells) > 0 {
+			last := r.cells[len(r.cells)-1]
+			n = max(n, last.col+last.span)
+		}
+	}
+	cols := make([]column, n)
+	var spanning []cell
+	for _, r := range rows {
+		for _, c := range r.cells {
+			if c.span > 1 {
+				spanning = append(spanning, c)
+				continue
+			}
+			minW, maxW := l.contentWidths(c.box)
+			col := &cols[c.col]
+			col.min, col.max = max(col.min, minW), max(col.max, maxW)
+			if !c.box.style.width.auto && !c.box.style.width.percent {
+				col.fixed = true
+			}
+		}
+	}
+	// Spread the excess width of spanning cells evenly over their columns.
+	spacing := tableSpacing(t.style)
+	for _, c := range spanning {
+		minW, maxW := l.contentWidths(c.box)
+		var curMin, curMax float64
+		for i := c.col; i < c.col+c.span; i++ {
+			curMin += cols[i].min
+			curMax += cols[i].max
+		}
+		inner := spacing * float64(c.span-1)
+		for i := c.col; i < c.col+c.span; i++ {
+			cols[i].min += max(minW-inner-curMin, 0) / float64(c.span)
+			cols[i].max += max(maxW-inner-curMax, 0) / float64(c.span)
+		}
+	}
+	for i := range cols {
+		cols[i].max = max(cols[i].max, cols[i].min)
+	}
+	return cols
+}
+
+// columnWidths distributes the width w of a table over its columns.
+func (l *layout) columnWidths(t *box, w float64) []float64 {
+	cols := l.columns(t)
+	// Percentage widths of cells apply to the table width.
+	for _, r := range tableRows(t) {
+		for _, c := range r.cells {
+			if cw := c.box.style.width; c.span == 1 && cw.percent {
+				v := cw.resolve(w)
+				cols[c.col].min = max(cols[c.col].min, min(v, cols[c.col].max))
+				cols[c.col].max = max(cols[c.col].max, v)
+				cols[c.col].fixed = true
+			}
+		}
+	}
+	available := w - tableSpacing(t.style)*float64(len(cols)+1)
+	widths := make([]float64, len(cols))
+	var sumMin, sumMax, sumAuto float64
+	for _, c := range cols {
+		sumMin += c.min
+		sumMax += c.max
+		if !c.fixed {
+			sumAuto += c.max
+		}
+	}
+	switch {
+	case sumMin >= available:
+		for i, c := range cols {
+			widths[i] = c.min
+		}
+	case sumMax <= available:
+		// Give the remaining width to auto columns in proportion to their
+		// maximum width, or to all columns if there are none.
+		free := available - sumMax
+		for i, c := range cols {
+			widths[i] = c.max
+			switch {
+			case sumAuto > 0 && !c.fixed:
+				widths[i] += free * c.max / sumAuto
+			case sumAuto == 0 && sumMax > 0:
+				widths[i] += free * c.max / sumMax
+			case sumMax == 0:
+				widths[i] += free / float64(len(cols))
+			}
+		}
+	default:
+		ratio := (available - sumMin) / (sumMax - sumMin)
+		for i, c := range cols {
+			widths[i] = c.min + (c.max-c.min)*ratio
+		}
+	}
+	return widths
+}
+
+// layoutTable lays out the rows of a table and returns the bottom of the
+// last row. Rows are not split across pages unless taller than a page, and
+// header rows are repeated on each page the table continues on.
+func (l *layout) layoutTable(t *box, x, y, w float64) float64 {
+	widths := l.columnWidths(t, w)
+	spacing := tableSpacing(t.style)
+	offsets := make([]float64, len(widths)+1)
+	offsets[0] = spacing
+	for i, cw := range widths {
+		offsets[i+1] = offsets[i] + cw + spacing
+	}
+	cellWidth := func(c cell) float64 {
+		return offsets[c.col+c.span] - offsets[c.col] - spacing
+	}
+
+	// measureRow returns the heights of the cells of a row and of the row.
+	measureRow := func(r row) ([]float64, float64) {
+		heights := make([]float64, len(r.cells))
+		rowHeight := 0.0
+		if rh := r.box.style.height; !rh.auto && !rh.percent {
+			rowHeight = rh.value
+		}
+		for i, c := range r.cells {
+			heights[i] = l.measure(func() float64 { return l.layoutBox(c.box, 0, 0, cellWidth(c), 0, 0) })
+			rowHeight = max(rowHeight, heights[i])
+		}
+		return heights, rowHeight
+	}
+	// layoutRow lays out a row at y and returns the top of the next row.
+	layoutRow := func(r row, y float64, heights []float64, rowHeight float64) float64 {
+		y = l.fit(y, rowHeight)
+		rowIndex := len(l.items)
+		l.items = append(l.items, drawItem{kind: drawBox, style: r.box.style, x: x, y: y, w: w})
+		end := y + rowHeight
+		for i, c := range r.cells {
+			offset := 0.0
+			switch c.box.style.verticalAlign {
+			case "top", "baseline":
+			case "bottom":
+				offset = rowHeight - heights[i]
+			default:
+				offset = (rowHeight - heights[i]) / 2
+			}
+			end = max(end, l.layoutBox(c.box, x+offsets[c.col], y, cellWidth(c), rowHeight, offset))
+		}
+		l.items[rowIndex].h = end - y
+		return end + spacing
+	}
+
+	rows := tableRows(t)
+	var headers []row
+	for _, r := range rows {
+		if r.header {
+			headers = append(headers, r)
+		}
+	}
+	y += spacing
+	for _, r := range rows {
+		heights, rowHeight := measureRow(r)
+		if next := l.fit(y, rowHeight); next != y && !r.header {
+			y = next
+			l.artifacts = true
+			for _, header := range headers {
+				headerHeights, headerHeight := measureRow(header)
+				y = layoutRow(header, y, headerHeights, headerHeight)
+			}
+			l.artifacts = false
+		}
+		y = layoutRow(r, y, heights, rowHeight)
+	}
+	return y
+}
+
+// Painting
+
+// paintOp is a filled rectangle or a run of text on a page.
+type paintOp struct {
+	page       int
+	x, y, w, h float64
+	color      rgb
+	text       *drawItem
+	image      *image
+	elem       *structElem
+}
+
+// paint adds the pages of a document whose content ends at end and draws
+// the items on them.
+func (l *layout) paint(end float64) {
+	var ops []paintOp
+	fill := func(x, y, w, h float64, c rgb) {
+		if w <= 0 || h <= 0 {
+			return
+		}
+		// Split rectangles at page breaks.
+		for h > epsilon {
+			page := l.page(y)
+			part := min(h, float64(page+1)*l.pageHeight-y)
+			ops = append(ops, paintOp{page: page, x: x, y: y - float64(page)*l.pageHeight, w: w, h: part, color: c})
+			y, h = y+part, h-part
+		}
+	}
+	for i := range l.items {
+		it := &l.items[i]
+		if it.style.hidden {
+			continue
+		}
+		switch it.kind {
+		case drawBox:
+			s := it.style
+			if s.background != nil {
+				fill(it.x, it.y, it.w, it.h, *s.background)
+			}
+			b := s.border
+			fill(it.x, it.y, it.w, b[top].width, b[top].color)
+			fill(it.x, it.y+it.h-b[bottom].width, it.w, b[bottom].width, b[bottom].color)
+			fill(it.x, it.y, b[left].width, it.h, b[left].color)
+			fill(it.x+it.w-b[right].width, it.y, b[right].width, it.h, b[right].color)
+		case drawText:
+			page := l.page(it.y)
+			ops = append(ops, paintOp{page: page, y: it.y - float64(page)*l.pageHeight, text: it})
+		case drawImage:
+			page := l.page(it.y)
+			ops = append(ops, paintOp{page: page, x: it.x, y: it.y - float64(page)*l.pageHeight, w: it.w, h: it.h, image: it.image, elem: it.elem})
+		}
+	}
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].page < ops[j].page })
+
+	pages := l.page(max(end-epsilon, 0)) + 1
+	if len(ops) > 0 {
+		pages = max(pages, ops[len(ops)-1].page+1)
+	}
+	next := 0
+	for page := 0; page < pages; page++ {
+		l.pdf.AddPage()
+		l.tags.begin(l.pdf, nil)
+		l.sections.drawBackground(l.pdf, page+1)
+		l.sections.drawHeader(l.pdf, page+1, pages)
+		l.sections.drawFooter(l.pdf, page+1, pages)
+		l.tags.end(l.pdf)
+		for ; next < len(ops) && ops[next].page == page; next++ {
+			op := ops[next]
+			if t := op.text; t != nil {
+				l.tags.begin(l.pdf, t.elem)
+				l.pdf.SetFont(t.font.family, t.font.style, t.font.size)
+				l.pdf.SetTextColor(t.style.color.r, t.style.color.g, t.style.color.b)
+				l.pdf.Text(t.x, l.marginTop+op.y+t.baseline, l.fonts.encode(t.font.family, t.text))
+				l.tags.end(l.pdf)
+				continue
+			}
+			l.tags.begin(l.pdf, op.elem)
+			if op.image != nil {
+				op.image.draw(l.pdf, op.x, l.marginTop+op.y, op.w, op.h)
+			} else {
+				l.pdf.SetFillColor(op.color.r, op.color.g, op.color.b)
+				l.pdf.Rect(op.x, l.marginTop+op.y, op.w, op.h, "F")
+			}
+			l.tags.end(l.pdf)
+		}
+		l.tags.begin(l.pdf, nil)
+		l.sections.drawWatermark(l.pdf, page+1)
+		l.tags.end(l.pdf)
+	}
+}
@@ -0,0 +1,212 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/wiederin/go-invoicer/dunning"
+	"github.com/wiederin/go-invoicer/internal/pdfupdate"
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// producer is the application named in the document information.
+const producer = "go-invoicer"
+
+// metadata is the document information of a PDF, written both to the
+// information dictionary and as XMP.
+type metadata struct {
+	title    string
+	author   string
+	subject  string
+	keywords []string
+	// lang is the language of the text as a BCP 47 tag.
+	lang    string
+	created time.Time
+}
+
+// invoiceMetadata returns the document information of an invoice.
+func invoiceMetadata(inv *invoice.Invoice) metadata {
+	subject := fmt.Sprintf("Invoice %s from %s to %s, issued %s", inv.Number,
+		inv.Supplier.Name, inv.Customer.Name, inv.IssueDate.Format("2006-01-02"))
+	if !inv.DueDate.IsZero() {
+		subject += ", due " + inv.DueDate.Format("2006-01-02")
+	}
+	return metadata{
+		title:    "Invoice " + inv.Number,
+		author:   inv.Supplier.Name,
+		subject:  subject,
+		keywords: []string{"invoice", inv.Number, inv.Supplier.Name, inv.Customer.Name},
+	}
+}
+
+// noticeMetadata returns the document information of a dunning notice.
+func noticeMetadata(n *dunning.Notice) metadata {
+	inv := n.Invoice
+	return metadata{
+		title:  n.Name + " " + inv.Number,
+		author: inv.Supplier.Name,
+		subject: fmt.Sprintf("%s for invoice %s from %s to %s, dated %s", n.Name, inv.Number,
+			inv.Supplier.Name, inv.Customer.Name, n.Date.Format("2006-01-02")),
+		keywords: []string{strings.ToLower(n.Name), "invoice", inv.Number, inv.Supplier.Name, inv.Customer.Name},
+	}
+}
+
+// apply sets the information dictionary and the language of a PDF. The
+// creation date is set to now unless given.
+func (m *metadata) apply(pdf *fpdf.Fpdf) {
+	if m.created.IsZero() {
+		m.created = time.Now().Truncate(time.Second)
+	}
+	pdf.SetTitle(m.title, true)
+	pdf.SetAuthor(m.author, true)
+	pdf.SetSubject(m.subject, true)
+	pdf.SetKeywords(strings.Join(m.keywords, ", "), true)
+	pdf.SetCreator(producer, true)
+	pdf.SetProducer(producer, true)
+	pdf.SetCreationDate(m.created)
+	pdf.SetModificationDate(m.created)
+	if m.lang != "" {
+		pdf.SetLang(m.lang)
+	}
+}
+
+// xmp returns the metadata as an XMP packet. PDF/UA documents identify
+// themselves by the pdfuaid:part property.
+func (m metadata) xmp(ua bool) string {
+	esc := func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	// The dates match those of the information dictionary, which fpdf
+	// writes without a time zone.
+	date := m.created.Format("2006-01-02T15:04:05")
+
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString("<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("<rdf:Description rdf:about=\"\"\n")
+	b.WriteString(" xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n")
+	b.WriteString(" xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\"\n")
+	b.WriteString(" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"\n")
+	b.WriteString(" xmlns:pdfuaid=\"http://www.aiim.org/pdfua/ns/id/\">\n")
+	b.WriteString("<dc:format>application/pdf</dc:format>\n")
+	fmt.Fprintf(&b, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", esc(m.title))
+	if m.author != "" {
+		fmt.Fprintf(&b, "<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", esc(m.author))
+	}
+	fmt.Fprintf(&b, "<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", esc(m.subject))
+	if m.lang != "" {
+		fmt.Fprintf(&b, "<dc:language><rdf:Bag><rdf:li>%s</rdf:li></rdf:Bag></dc:language>\n", esc(m.lang))
+	}
+	fmt.Fprintf(&b, "<pdf:Keywords>%s</pdf:Keywords>\n", esc(strings.Join(m.keywords, ", ")))
+	fmt.Fprintf(&b, "<pdf:Producer>%s</pdf:Producer>\n", producer)
+	fmt.Fprintf(&b, "<xmp:CreatorTool>%s</xmp:CreatorTool>\n", producer)
+	fmt.Fprintf(&b, "<xmp:CreateDate>%s</xmp:CreateDate>\n", date)
+	fmt.Fprintf(&b, "<xmp:ModifyDate>%s</xmp:ModifyDate>\n", date)
+	fmt.Fprintf(&b, "<xmp:MetadataDate>%s</xmp:MetadataDate>\n", date)
+	if ua {
+		b.WriteString("<pdfuaid:part>1</pdfuaid:part>\n")
+	}
+	b.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>")
+	return b.String()
+}
+
+// finishPDF adds the XMP metadata and, if tree is not nil, the structure
+// tree to a PDF written by fpdf, which supports neither. Tagged PDFs claim
+// PDF/UA conformance only if all their fonts are embedded.
+func finishPDF(data []byte, m metadata, tree *structTree) ([]byte, error) {
+	u, err := pdfupdate.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	xmp := m.xmp(tree != nil && embedsFonts(u))
+	xmpNum := u.Reserve()
+	u.Set(xmpNum, fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(xmp), xmp))
+
+	entries := fmt.Sprintf(" /Metadata %d 0 R /ViewerPreferences << /DisplayDocTitle true >>", xmpNum)
+	if tree != nil {
+		tagged, err := tree.write(u)
+		if err != nil {
+			return nil, err
+		}
+		// Tagged PDF requires version 1.4 and PDF/UA is based on 1.7, while
+		// fpdf writes 1.3 headers.
+		entries += tagged + " /Version /1.7"
+	}
+	catalog, err := u.Extend(u.Root, entries)
+	if err != nil {
+		return nil, err
+	}
+	u.Set(u.Root, catalog)
+	return u.Bytes(), nil
+}
+
+// embedsFonts reports whether the fonts used by the pages of a PDF, and by
+// the forms they draw, are all embedded. The standard PDF fonts are not.
+func embedsFonts(u *pdfupdate.Update) bool {
+	pages, err := u.Pages()
+	if err != nil {
+		return false
+	}
+	seen := make(map[int]bool)
+	var embedded func(resources string) bool
+	embedded = func(resources string) bool {
+		dict := pdfupdate.Dict(u.Resolve(resources))
+		for _, font := range pdfupdate.Dict(u.Resolve(dict["Font"])) {
+			if !fontEmbedded(u, font) {
+				return false
+			}
+		}
+		for _, ref := range pdfupdate.Dict(u.Resolve(dict["XObject"])) {
+			n, ok := pdfupdate.Ref(ref)
+			if !ok || seen[n] {
+				continue
+			}
+			seen[n] = true
+			xobj, _, err := u.Stream(n)
+			if err != nil {
+				return false
+			}
+			if r, ok := pdfupdate.Dict(xobj)["Resources"]; ok && !embedded(r) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, n := range pages {
+		page, err := u.Object(n)
+		if err != nil || !embedded(pdfupdate.Dict(page)["Resources"]) {
+			return false
+		}
+	}
+	return true
+}
+
+// fontEmbedded reports whether a font has its font program embedded.
+// Type 3 fonts are drawn by their own content streams.
+func fontEmbedded(u *pdfupdate.Update, ref string) bool {
+	font := pdfupdate.Dict(u.Resolve(ref))
+	switch font["Subtype"] {
+	case "/Type3":
+		return true
+	case "/Type0":
+		kids := pdfupdate.Array(u.Resolve(font["DescendantFonts"]))
+		if len(kids) == 0 {
+			return false
+		}
+		font = pdfupdate.Dict(u.Resolve(kids[0]))
+	}
+	desc := pdfupdate.Dict(u.Resolve(font["FontDescriptor"]))
+	for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		if _, ok := desc[key]; ok {
+			return true
+		}
+	}
+	return false
+}
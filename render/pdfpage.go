@@ -0,0 +1,207 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/wiederin/go-invoicer/internal/pdfupdate"
+)
+
+// pdfPage is the first page of a PDF imported as a form XObject.
+type pdfPage struct {
+	// name is the resource name of the form.
+	name string
+	// box is the visible area of the page in points.
+	box [4]float64
+}
+
+// width and height return the size of the page in mm.
+func (p *pdfPage) width() float64  { return (p.box[2] - p.box[0]) * mmPerPt }
+func (p *pdfPage) height() float64 { return (p.box[3] - p.box[1]) * mmPerPt }
+
+// draw draws the page stretched into a rectangle.
+func (p *pdfPage) draw(pdf *fpdf.Fpdf, x, y, w, h float64) {
+	sx, sy := w/(p.box[2]-p.box[0]), h/(p.box[3]-p.box[1])
+	pdf.UseImportedTemplate(p.name, sx, sy, x-p.box[0]*sx, -y-h-p.box[1]*sy)
+}
+
+// importPage imports the first page of a PDF with the objects it uses.
+// Like pdfupdate, it reads cross-reference tables but not cross-reference
+// streams, and the page contents must be uncompressed or Flate-compressed.
+func importPage(pdf *fpdf.Fpdf, data []byte, name string) (*pdfPage, error) {
+	u, err := pdfupdate.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := u.Object(u.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first page is found by descending the page tree, whose nodes
+	// pass on their resources and boxes.
+	inherited := map[string]string{}
+	node := pdfupdate.Dict(catalog)["Pages"]
+	var page map[string]string
+	for depth := 0; page == nil; depth++ {
+		n, ok := pdfupdate.Ref(node)
+		if !ok || depth > 32 {
+			return nil, errors.New("no pages")
+		}
+		obj, err := u.Object(n)
+		if err != nil {
+			return nil, err
+		}
+		dict := pdfupdate.Dict(obj)
+		for _, key := range []string{"Resources", "MediaBox", "CropBox"} {
+			if v, ok := dict[key]; ok {
+				inherited[key] = v
+			}
+		}
+		if kids, ok := dict["Kids"]; ok {
+			items := pdfupdate.Array(kids)
+			if len(items) == 0 {
+				return nil, errors.New("no pages")
+			}
+			node = items[0]
+			continue
+		}
+		page = dict
+	}
+
+	box := inherited["CropBox"]
+	if box == "" {
+		box = inherited["MediaBox"]
+	}
+	p := &pdfPage{name: "/TPL" + name}
+	if items := pdfupdate.Array(u.Resolve(box)); len(items) == 4 {
+		for i, item := range items {
+			p.box[i], _ = strconv.ParseFloat(u.Resolve(item), 64)
+		}
+	}
+	if p.box[2] <= p.box[0] || p.box[3] <= p.box[1] {
+		return nil, errors.New("page has no size")
+	}
+
+	var content bytes.Buffer
+	// Contents are a stream or an array of streams, which may be referenced.
+	refs := []string{page["Contents"]}
+	if contents := u.Resolve(page["Contents"]); strings.HasPrefix(contents, "[") {
+		refs = pdfupdate.Array(contents)
+	}
+	for _, ref := range refs {
+		n, ok := pdfupdate.Ref(ref)
+		if !ok {
+			continue
+		}
+		dict, data, err := u.Stream(n)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = decodeStream(pdfupdate.Dict(dict), data); err != nil {
+			return nil, err
+		}
+		content.Write(data)
+		content.WriteByte('\n')
+	}
+
+	// Objects are keyed by a hash, which fpdf replaces with their new
+	// numbers wherever they are referenced.
+	objs := make(map[string][]byte)
+	positions := make(map[string]map[int]string)
+	key := func(s string) string {
+		sum := sha1.Sum([]byte(name + ":" + s))
+		return hex.EncodeToString(sum[:])
+	}
+	var queue []int
+	relink := func(text string) (string, map[int]string) {
+		var keys []string
+		text, offsets := pdfupdate.ReplaceRefs(text, func(n int) string {
+			k := key(strconv.Itoa(n))
+			if _, seen := objs[k]; !seen {
+				objs[k] = nil
+				queue = append(queue, n)
+			}
+			keys = append(keys, k)
+			return k + " 0 R"
+		})
+		pos := make(map[int]string, len(offsets))
+		for i, off := range offsets {
+			pos[off] = keys[i]
+		}
+		return text, pos
+	}
+
+	resources := inherited["Resources"]
+	if resources == "" {
+		resources = "<< >>"
+	}
+	resources, resourcePos := relink(resources)
+	var zipped bytes.Buffer
+	zw := zlib.NewWriter(&zipped)
+	zw.Write(content.Bytes())
+	zw.Close()
+	form := fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [%s %s %s %s] /Resources ",
+		fmtPt(p.box[0]), fmtPt(p.box[1]), fmtPt(p.box[2]), fmtPt(p.box[3]))
+	formPos := make(map[int]string)
+	for off, k := range resourcePos {
+		formPos[len(form)+off] = k
+	}
+	form += resources + fmt.Sprintf(" /Filter /FlateDecode /Length %d >>\nstream\n", zipped.Len())
+	formKey := key("form")
+	objs[formKey] = append(append([]byte(form), zipped.Bytes()...), "\nendstream\nendobj"...)
+	positions[formKey] = formPos
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		dict, data, err := u.Stream(n)
+		if err != nil {
+			return nil, err
+		}
+		text, pos := relink(dict)
+		obj := []byte(text)
+		if data != nil {
+			obj = append(append(append(obj, "\nstream\n"...), data...), "\nendstream"...)
+		}
+		k := key(strconv.Itoa(n))
+		objs[k] = append(obj, "\nendobj"...)
+		positions[k] = pos
+	}
+
+	pdf.ImportObjects(objs)
+	pdf.ImportObjPos(positions)
+	pdf.ImportTemplates(map[string]string{p.name: formKey})
+	return p, nil
+}
+
+// decodeStream decodes the data of a stream with no filter or the Flate
+// filter.
+func decodeStream(dict map[string]string, data []byte) ([]byte, error) {
+	switch f := strings.Trim(dict["Filter"], "[] \n"); f {
+	case "":
+		return data, nil
+	case "/FlateDecode", "/Fl":
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	default:
+		return nil, fmt.Errorf("unsupported filter %s", f)
+	}
+}
+
+// fmtPt formats a coordinate in points.
+func fmtPt(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -6,9 +6,15 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
+	"reflect"
+	"sort"
 	"strings"
 
-	"github.com/go-pdf/fpdf"
+	"github.com/shopspring/decimal"
+	"golang.org/x/net/html"
+
+	"github.com/wiederin/go-invoicer/banking"
 	"github.com/wiederin/go-invoicer/invoice"
 	"github.com/wiederin/go-invoicer/template"
 )
@@ -18,6 +24,12 @@ type PDFRenderer interface {
 	RenderHTML(html string) ([]byte, error)
 }
 
+// Signer signs rendered PDFs, as signature.Signer does with PAdES
+// signatures.
+type Signer interface {
+	Sign(pdf []byte) ([]byte, error)
+}
+
 // Options configures PDF rendering settings.
 type Options struct {
 	PageSize     string
@@ -28,6 +40,43 @@ type Options struct {
 	MarginLeft   float64
 	FontFamily   string
 	FontSize     float64
+	// Fonts are TrueType fonts to embed, which FontFamily and the CSS
+	// font-family of templates may name.
+	Fonts []Font
+	// FallbackFonts maps Unicode script names, as in unicode.Scripts, to
+	// font families tried in order for characters the font in use cannot
+	// display. The families under "" are tried for all scripts.
+	FallbackFonts map[string][]string
+	// Header and Footer are printed on every page within the page margins.
+	// The space for them is reserved in addition to the margins.
+	Header Section
+	Footer Section
+	// ContinuationHeader, if set, replaces Header on the pages after the
+	// first.
+	ContinuationHeader *Section
+	Logo               Logo
+	Letterhead         Letterhead
+	// Images is the file system image files are read from. If nil, they
+	// are read from the operating system's file system.
+	Images fs.FS
+	// Watermark is printed across the pages. Unless disabled, drafts and
+	// cancelled invoices are marked as such and paid invoices get a PAID
+	// stamp.
+	Watermark Watermark
+	// Copy, such as "COPY" or "DUPLICATE", is added to the watermark when
+	// an issued invoice is rendered again.
+	Copy string
+	// Language is the language of the text as a BCP 47 tag such as "en"
+	// or "de-CH". If empty, the SimpleRenderer declares English and the
+	// Engine the lang attribute of the html element.
+	Language string
+	// Tagged adds the logical structure of the content, with headings,
+	// paragraphs, tables and figures in reading order, for accessibility
+	// as required by PDF/UA. The PDF claims PDF/UA conformance only if all
+	// its fonts are embedded, that is registered in Fonts.
+	Tagged bool
+	// Signer, if set, signs the PDF after it is complete.
+	Signer Signer
 }
 
 // DefaultOptions returns sensible default PDF options.
@@ -77,7 +126,7 @@ func (e *Engine) RenderInvoice(inv *invoice.Invoice, templateName string) ([]byt
 		return nil, fmt.Errorf("failed to render template: %w", err)
 	}
 
-	return e.htmlToPDF(html)
+	return e.htmlToPDF(html, inv, invoiceMetadata(inv))
 }
 
 func (e *Engine) prepareTemplateData(inv *invoice.Invoice) map[string]any {
@@ -89,118 +138,78 @@ func (e *Engine) prepareTemplateData(inv *invoice.Invoice) map[string]any {
 		"TotalTax":      inv.TotalTax(),
 		"TotalGross":    inv.TotalGross(),
 		"TaxBreakdown":  inv.TaxBreakdown(),
+
+		"TotalWithholding":     inv.TotalWithholding(),
+		"WithholdingBreakdown": inv.WithholdingBreakdown(),
+		"TotalPayable":         inv.TotalPayable(),
+		"RoundingDifference":   inv.RoundingDifference(),
+
+		"TotalTaxReporting":     inv.TotalTaxReporting(),
+		"TaxBreakdownReporting": inv.TaxBreakdownReporting(),
+
+		"PaymentSchedule":      inv.PaymentSchedule(),
+		"PaymentTerms":         inv.PaymentTermsText(),
+		"EarlyPaymentDiscount": inv.EarlyPaymentDiscount(),
+		"DiscountedAmount":     inv.DiscountedAmount(),
+		"DiscountDeadline":     inv.DiscountDeadline(),
 	}
 }
 
-func (e *Engine) htmlToPDF(html string) ([]byte, error) {
-	pdf := fpdf.New(e.Options.Orientation, "mm", e.Options.PageSize, "")
-	pdf.SetMargins(e.Options.MarginLeft, e.Options.MarginTop, e.Options.MarginRight)
-	pdf.SetAutoPageBreak(true, e.Options.MarginBottom)
-	pdf.AddPage()
-	pdf.SetFont(e.Options.FontFamily, "", e.Options.FontSize)
-
-	htmlContent := parseHTMLContent(html)
-	_, lineHt := pdf.GetFontSize()
-
-	for _, block := range htmlContent {
-		switch block.Type {
-		case "h1":
-			pdf.SetFont(e.Options.FontFamily, "B", 24)
-			pdf.MultiCell(0, 12, block.Text, "", "", false)
-			pdf.Ln(5)
-		case "h2":
-			pdf.SetFont(e.Options.FontFamily, "B", 18)
-			pdf.MultiCell(0, 10, block.Text, "", "", false)
-			pdf.Ln(4)
-		case "h3":
-			pdf.SetFont(e.Options.FontFamily, "B", 14)
-			pdf.MultiCell(0, 8, block.Text, "", "", false)
-			pdf.Ln(3)
-		case "p", "div":
-			pdf.SetFont(e.Options.FontFamily, "", e.Options.FontSize)
-			pdf.MultiCell(0, lineHt*1.5, block.Text, "", "", false)
-			pdf.Ln(2)
-		case "strong", "b":
-			pdf.SetFont(e.Options.FontFamily, "B", e.Options.FontSize)
-			pdf.MultiCell(0, lineHt*1.5, block.Text, "", "", false)
-		default:
-			pdf.SetFont(e.Options.FontFamily, "", e.Options.FontSize)
-			if block.Text != "" {
-				pdf.MultiCell(0, lineHt*1.5, block.Text, "", "", false)
-			}
+// htmlToPDF lays out the rendered HTML and its CSS and writes the PDF with
+// the headers and footers of the invoice. The title of the HTML document
+// replaces that of the metadata.
+func (e *Engine) htmlToPDF(src string, inv *invoice.Invoice, meta metadata) ([]byte, error) {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	pdf, tree, err := renderHTML(doc, e.Options, inv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lay out HTML: %w", err)
+	}
+
+	if titles := findAll(doc, "title"); len(titles) > 0 {
+		if title := strings.Join(strings.Fields(textContent(titles[0])), " "); title != "" {
+			meta.title = title
 		}
 	}
+	meta.lang = e.Options.Language
+	if roots := findAll(doc, "html"); meta.lang == "" && len(roots) > 0 {
+		meta.lang = attr(roots[0], "lang")
+	}
+	meta.apply(pdf)
 
 	var buf bytes.Buffer
 	if err := pdf.Output(&buf); err != nil {
 		return nil, fmt.Errorf("failed to generate PDF: %w", err)
 	}
 
-	return buf.Bytes(), nil
-}
-
-type htmlBlock struct {
-	Type string
-	Text string
-}
-
-func parseHTMLContent(html string) []htmlBlock {
-	var blocks []htmlBlock
-	var currentTag string
-	var currentText strings.Builder
-	inTag := false
-	isClosing := false
-
-	for _, r := range html {
-		if r == '<' {
-			if currentText.Len() > 0 {
-				text := strings.TrimSpace(currentText.String())
-				if text != "" {
-					blocks = append(blocks, htmlBlock{Type: currentTag, Text: text})
-				}
-				currentText.Reset()
-			}
-			inTag = true
-			isClosing = false
-			continue
-		}
-		if r == '>' {
-			inTag = false
-			continue
-		}
-		if inTag {
-			if r == '/' {
-				isClosing = true
-			} else if !isClosing && r != ' ' {
-				currentTag += string(r)
-			}
-			if r == ' ' || isClosing {
-				currentTag = strings.ToLower(currentTag)
-			}
-			continue
-		}
-		currentText.WriteRune(r)
+	data, err := finishPDF(buf.Bytes(), meta, tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
 	}
-
-	if currentText.Len() > 0 {
-		text := strings.TrimSpace(currentText.String())
-		if text != "" {
-			blocks = append(blocks, htmlBlock{Type: currentTag, Text: text})
+	if e.Options.Signer != nil {
+		if data, err = e.Options.Signer.Sign(data); err != nil {
+			return nil, fmt.Errorf("failed to sign PDF: %w", err)
 		}
 	}
-
-	return blocks
+	return data, nil
 }
 
 // SimpleRenderer renders invoices directly to PDF without templates.
 type SimpleRenderer struct {
 	Options Options
+	// Theme is the design of the documents. If it is the zero value, the
+	// classic theme is used.
+	Theme Theme
 }
 
 // NewSimpleRenderer creates a new simple renderer with default options.
 func NewSimpleRenderer() *SimpleRenderer {
 	return &SimpleRenderer{
 		Options: DefaultOptions(),
+		Theme:   ClassicTheme(),
 	}
 }
 
@@ -210,144 +219,439 @@ func (r *SimpleRenderer) RenderInvoice(inv *invoice.Invoice) ([]byte, error) {
 		return nil, fmt.Errorf("invalid invoice: %w", err)
 	}
 
-	pdf := fpdf.New(r.Options.Orientation, "mm", r.Options.PageSize, "")
-	pdf.SetMargins(r.Options.MarginLeft, r.Options.MarginTop, r.Options.MarginRight)
-	pdf.SetAutoPageBreak(true, r.Options.MarginBottom)
-	pdf.AddPage()
+	return r.render(inv, invoiceMetadata(inv), func(pdf *document) {
+		layout := pdf.theme.Layout
+		for i, block := range layout {
+			page, y := pdf.PageNo(), pdf.GetY()
+			switch block {
+			case BlockTitle:
+				r.renderTitle(pdf, inv)
+			case BlockParties:
+				r.renderParties(pdf, inv)
+			case BlockItems:
+				r.renderLineItems(pdf, inv, i+1 < len(layout) && layout[i+1] == BlockTotals)
+			case BlockTotals:
+				r.renderTotals(pdf, inv)
+			case BlockSchedule:
+				r.renderSchedule(pdf, inv)
+			case BlockNotes:
+				r.renderNotes(pdf, inv)
+			}
+			if pdf.PageNo() != page || pdf.GetY() != y {
+				pdf.Ln(pdf.theme.SectionSpacing)
+			}
+		}
+	})
+}
 
-	r.renderHeader(pdf, inv)
-	r.renderParties(pdf, inv)
-	r.renderLineItems(pdf, inv)
-	r.renderTotals(pdf, inv)
-	r.renderFooter(pdf, inv)
+// theme returns the theme of the renderer with the sizes, spacing, columns
+// and layout it leaves zero taken from the classic theme. Zero colors are
+// black, except that a header row with black text on black gets the
+// classic fill.
+func (r *SimpleRenderer) theme() Theme {
+	classic := ClassicTheme()
+	if reflect.ValueOf(r.Theme).IsZero() {
+		return classic
+	}
+	t := r.Theme
+	for _, f := range []struct{ v, def *float64 }{
+		{&t.TitleSize, &classic.TitleSize},
+		{&t.TextSize, &classic.TextSize},
+		{&t.TableSize, &classic.TableSize},
+		{&t.TotalSize, &classic.TotalSize},
+		{&t.LineSpacing, &classic.LineSpacing},
+		{&t.CellPadding, &classic.CellPadding},
+		{&t.SectionSpacing, &classic.SectionSpacing},
+		{&t.TotalsWidth, &classic.TotalsWidth},
+	} {
+		if *f.v <= 0 {
+			*f.v = *f.def
+		}
+	}
+	if t.TableHeaderFill == (Color{}) && t.TableHeaderColor == (Color{}) {
+		t.TableHeaderFill = classic.TableHeaderFill
+	}
+	if len(t.Columns) == 0 {
+		t.Columns = classic.Columns
+	}
+	if len(t.Layout) == 0 {
+		t.Layout = classic.Layout
+	}
+	return t
+}
+
+// render writes a document whose content is drawn by content, with the
+// headers and footers of the invoice and the given metadata. If they show
+// the page count, the content is laid out twice to count the pages first.
+func (r *SimpleRenderer) render(inv *invoice.Invoice, meta metadata, content func(pdf *document)) ([]byte, error) {
+	theme := r.theme()
+	newPDF := func() (*document, error) {
+		pdf, err := newDocument(r.Options)
+		if err != nil {
+			return nil, err
+		}
+		pdf.theme = theme
+		pdf.SetTextColor(theme.TextColor.rgb())
+		pdf.SetDrawColor(theme.BorderColor.rgb())
+		return pdf, nil
+	}
+
+	pages := 0
+	if r.Options.showsPageCount() {
+		pdf, err := newPDF()
+		if err != nil {
+			return nil, err
+		}
+		pdf.AddPage()
+		content(pdf)
+		pages = pdf.PageNo()
+	}
+
+	pdf, err := newPDF()
+	if err != nil {
+		return nil, err
+	}
+	margins := [4]float64{r.Options.MarginTop, r.Options.MarginRight, r.Options.MarginBottom, r.Options.MarginLeft}
+	sections := newPageSections(r.Options, pdf.fonts, inv, margins)
+	logo := r.Options.Logo.Source
+	if logo == "" {
+		logo = inv.Supplier.Logo
+	}
+	if err := sections.loadImages(newImageSet(pdf.Fpdf, r.Options.Images), logo); err != nil {
+		return nil, err
+	}
+	if r.Options.Tagged {
+		pdf.tags = newStructTree()
+	}
+	// Content interrupted by a page break continues on the next page.
+	pdf.SetHeaderFunc(func() {
+		pdf.artifact(func() {
+			sections.drawBackground(pdf.Fpdf, pdf.PageNo())
+			sections.drawHeader(pdf.Fpdf, pdf.PageNo(), pages)
+		})
+		pdf.tags.resume(pdf.Fpdf)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.tags.suspend(pdf.Fpdf)
+		pdf.artifact(func() {
+			sections.drawFooter(pdf.Fpdf, pdf.PageNo(), pages)
+			sections.drawWatermark(pdf.Fpdf, pdf.PageNo())
+		})
+	})
+	meta.lang = r.Options.Language
+	if meta.lang == "" {
+		meta.lang = "en"
+	}
+	meta.apply(pdf.Fpdf)
+	pdf.AddPage()
+	content(pdf)
 
 	var buf bytes.Buffer
 	if err := pdf.Output(&buf); err != nil {
 		return nil, fmt.Errorf("failed to generate PDF: %w", err)
 	}
 
-	return buf.Bytes(), nil
+	data, err := finishPDF(buf.Bytes(), meta, pdf.tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+	}
+	if r.Options.Signer != nil {
+		if data, err = r.Options.Signer.Sign(data); err != nil {
+			return nil, fmt.Errorf("failed to sign PDF: %w", err)
+		}
+	}
+	return data, nil
+}
+
+func (r *SimpleRenderer) renderTitle(pdf *document, inv *invoice.Invoice) {
+	t := pdf.theme
+	r.renderTitleText(pdf, "INVOICE")
+	pdf.setFont("", t.TextSize)
+	lh := t.line(t.TextSize)
+	for _, line := range []string{
+		fmt.Sprintf("Invoice Number: %s", inv.Number),
+		fmt.Sprintf("Issue Date: %s", inv.IssueDate.Format("2006-01-02")),
+		fmt.Sprintf("Due Date: %s", inv.DueDate.Format("2006-01-02")),
+	} {
+		pdf.mark("P", func() { pdf.Cell(0, lh, line) })
+		pdf.Ln(lh)
+	}
+}
+
+// renderTitleText renders the title of a document.
+func (r *SimpleRenderer) renderTitleText(pdf *document, title string) {
+	t := pdf.theme
+	pdf.setHeadingFont("B", t.TitleSize)
+	pdf.SetTextColor(t.TitleColor.rgb())
+	pdf.mark("H1", func() { pdf.Cell(0, t.line(t.TitleSize), title) })
+	pdf.Ln(t.line(t.TitleSize) + t.SectionSpacing/2)
+	pdf.SetTextColor(t.TextColor.rgb())
 }
 
-func (r *SimpleRenderer) renderHeader(pdf *fpdf.Fpdf, inv *invoice.Invoice) {
-	pdf.SetFont("Arial", "B", 24)
-	pdf.Cell(0, 15, "INVOICE")
-	pdf.Ln(20)
-
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(95, 6, fmt.Sprintf("Invoice Number: %s", inv.Number))
-	pdf.Ln(6)
-	pdf.Cell(95, 6, fmt.Sprintf("Issue Date: %s", inv.IssueDate.Format("2006-01-02")))
-	pdf.Ln(6)
-	pdf.Cell(95, 6, fmt.Sprintf("Due Date: %s", inv.DueDate.Format("2006-01-02")))
-	pdf.Ln(15)
+// renderHeading renders the heading of a block.
+func (r *SimpleRenderer) renderHeading(pdf *document, heading string) {
+	t := pdf.theme
+	pdf.setHeadingFont("B", t.TextSize)
+	pdf.SetTextColor(t.HeadingColor.rgb())
+	pdf.mark("H2", func() { pdf.Cell(0, t.line(t.TextSize), heading) })
+	pdf.Ln(t.line(t.TextSize))
+	pdf.SetTextColor(t.TextColor.rgb())
+	pdf.setFont("", t.TextSize)
 }
 
-func (r *SimpleRenderer) renderParties(pdf *fpdf.Fpdf, inv *invoice.Invoice) {
+// renderParties renders the supplier and the customer side by side.
+func (r *SimpleRenderer) renderParties(pdf *document, inv *invoice.Invoice) {
+	left, _, _, _ := pdf.GetMargins()
+	half := pdf.contentWidth() / 2
 	startY := pdf.GetY()
+	bottom := startY
+
+	for i, party := range []struct {
+		heading string
+		lines   []string
+	}{
+		{"From:", r.partyLines(inv.Supplier, true)},
+		{"To:", r.partyLines(inv.Customer, false)},
+	} {
+		x := left + float64(i)*half
+		pdf.SetLeftMargin(x)
+		pdf.SetXY(x, startY)
+		pdf.tag("Div", func() {
+			r.renderHeading(pdf, party.heading)
+			lh := pdf.theme.line(pdf.theme.TextSize)
+			pdf.mark("P", func() {
+				for _, line := range party.lines {
+					pdf.CellFormat(half-5, lh, line, "", 2, "", false, 0, "")
+				}
+			})
+		})
+		bottom = max(bottom, pdf.GetY())
+	}
+	pdf.SetLeftMargin(left)
+	pdf.SetXY(left, bottom)
+}
 
-	pdf.SetFont("Arial", "B", 10)
-	pdf.Cell(95, 6, "From:")
-	pdf.Ln(6)
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(95, 5, inv.Supplier.Name)
-	pdf.Ln(5)
-	for _, line := range inv.Supplier.Address.Lines() {
-		pdf.Cell(95, 5, line)
-		pdf.Ln(5)
-	}
-	if inv.Supplier.VATID != "" {
-		pdf.Cell(95, 5, fmt.Sprintf("VAT ID: %s", inv.Supplier.VATID))
-		pdf.Ln(5)
-	}
-
-	pdf.SetXY(105, startY)
-	pdf.SetFont("Arial", "B", 10)
-	pdf.Cell(95, 6, "To:")
-	pdf.Ln(6)
-	pdf.SetX(105)
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(95, 5, inv.Customer.Name)
-	pdf.Ln(5)
-	pdf.SetX(105)
-	for _, line := range inv.Customer.Address.Lines() {
-		pdf.Cell(95, 5, line)
-		pdf.Ln(5)
-		pdf.SetX(105)
-	}
-	if inv.Customer.VATID != "" {
-		pdf.Cell(95, 5, fmt.Sprintf("VAT ID: %s", inv.Customer.VATID))
-		pdf.Ln(5)
-	}
-
-	pdf.Ln(15)
+// partyLines returns the name, address and identifiers of a party, with
+// the bank details of the supplier.
+func (r *SimpleRenderer) partyLines(p invoice.Party, bank bool) []string {
+	lines := append([]string{p.Name}, p.Address.Lines()...)
+	if p.VATID != "" {
+		lines = append(lines, fmt.Sprintf("VAT ID: %s", p.VATID))
+	}
+	if bank && p.IBAN != "" {
+		lines = append(lines, fmt.Sprintf("IBAN: %s", banking.FormatIBAN(p.IBAN)))
+	}
+	if bank && p.BIC != "" {
+		lines = append(lines, fmt.Sprintf("BIC: %s", p.BIC))
+	}
+	return lines
 }
 
-func (r *SimpleRenderer) renderLineItems(pdf *fpdf.Fpdf, inv *invoice.Invoice) {
-	pdf.SetFont("Arial", "B", 9)
-	pdf.SetFillColor(240, 240, 240)
-	pdf.CellFormat(80, 8, "Description", "1", 0, "", true, 0, "")
-	pdf.CellFormat(20, 8, "Qty", "1", 0, "C", true, 0, "")
-	pdf.CellFormat(30, 8, "Unit Price", "1", 0, "R", true, 0, "")
-	pdf.CellFormat(20, 8, "Tax %", "1", 0, "C", true, 0, "")
-	pdf.CellFormat(40, 8, "Amount", "1", 0, "R", true, 0, "")
-	pdf.Ln(8)
-
-	pdf.SetFont("Arial", "", 9)
-	for _, item := range inv.LineItems {
-		pdf.CellFormat(80, 7, item.Description, "1", 0, "", false, 0, "")
-		pdf.CellFormat(20, 7, item.Quantity.String(), "1", 0, "C", false, 0, "")
-		pdf.CellFormat(30, 7, item.UnitPrice.String(), "1", 0, "R", false, 0, "")
-		pdf.CellFormat(20, 7, item.TaxRate.String()+"%", "1", 0, "C", false, 0, "")
-		pdf.CellFormat(40, 7, item.GrossAmount().String(), "1", 0, "R", false, 0, "")
-		pdf.Ln(7)
-	}
-	pdf.Ln(5)
+// renderLineItems renders the line item table with the columns of the
+// theme. Cells wrap their text, and at page breaks the running total is
+// carried forward and the header repeated. If keepWithTotals is set, the
+// last row is moved to the next page if the totals would not fit after it.
+func (r *SimpleRenderer) renderLineItems(pdf *document, inv *invoice.Invoice, keepWithTotals bool) {
+	t := pdf.theme
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, marginBottom := pdf.GetMargins()
+	limit := pageHeight - marginBottom
+
+	relative := make([]float64, len(t.Columns))
+	aligns := make([]string, len(t.Columns))
+	titles := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		relative[i], aligns[i], titles[i] = c.width(), c.Align, c.Title
+	}
+	widths := pdf.columnWidths(relative...)
+	header := func() {
+		pdf.drawRow(widths, aligns, pdf.row(widths, titles, headerRow))
+	}
+	// The running total is printed under the column it sums, or the last
+	// one, and labelled in the columns before it, or after it if it is
+	// the first.
+	sum := carriedColumn(t.Columns)
+	if sum < 0 {
+		sum = len(widths) - 1
+	}
+	before, after := 0.0, 0.0
+	for i, w := range widths {
+		if i < sum {
+			before += w
+		} else if i > sum {
+			after += w
+		}
+	}
+	carriedWidths, carriedAligns := []float64{widths[sum]}, []string{"R"}
+	label, amountCell := -1, 0
+	if before > 0 {
+		carriedWidths, carriedAligns = append([]float64{before}, carriedWidths...), append([]string{"R"}, carriedAligns...)
+		label, amountCell = 0, 1
+	}
+	if after > 0 {
+		carriedWidths, carriedAligns = append(carriedWidths, after), append(carriedAligns, "L")
+		if label < 0 {
+			label = len(carriedWidths) - 1
+		}
+	}
+	carriedRow := func(text string, amount invoice.Money) tableRow {
+		texts := make([]string, len(carriedWidths))
+		if label >= 0 {
+			texts[label] = text
+		}
+		texts[amountCell] = amount.String()
+		return pdf.row(carriedWidths, texts, totalRow)
+	}
+
+	pdf.tag("Table", func() {
+		header()
+		carried := invoice.Money{Amount: decimal.Zero, Currency: inv.Currency}
+		rowsOnPage := 0
+		for i, item := range inv.LineItems {
+			texts := make([]string, len(t.Columns))
+			for j, c := range t.Columns {
+				texts[j] = c.text(item)
+			}
+			row := pdf.row(widths, texts, bodyRow)
+
+			needed := row.height + carriedRow("", carried).height
+			if i == len(inv.LineItems)-1 && keepWithTotals {
+				needed = row.height + t.SectionSpacing + r.totalsHeight(pdf, inv)
+			}
+			if rowsOnPage > 0 && pdf.GetY()+needed > limit {
+				// The repeated header and the carried total are artifacts.
+				pdf.artifact(func() {
+					pdf.drawRow(carriedWidths, carriedAligns, carriedRow("Carried forward:", carried))
+				})
+				pdf.AddPage()
+				pdf.artifact(func() {
+					header()
+					pdf.drawRow(carriedWidths, carriedAligns, carriedRow("Brought forward:", carried))
+				})
+				rowsOnPage = 0
+			}
+			pdf.drawRow(widths, aligns, row)
+
+			carried, _ = carried.Add(carriedAmount(t.Columns, item))
+			rowsOnPage++
+		}
+	})
 }
 
-func (r *SimpleRenderer) renderTotals(pdf *fpdf.Fpdf, inv *invoice.Invoice) {
-	pdf.SetX(120)
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(40, 6, "Subtotal:")
-	pdf.Cell(30, 6, inv.SubTotal().String())
-	pdf.Ln(6)
+// totalsLine is a line of the totals.
+type totalsLine struct {
+	label string
+	value string
+	style string
+	size  float64
+}
 
+// totalsLines returns the lines of the totals of an invoice.
+func (r *SimpleRenderer) totalsLines(t Theme, inv *invoice.Invoice) []totalsLine {
+	lines := []totalsLine{{"Subtotal:", inv.SubTotal().String(), "", t.TextSize}}
 	if !inv.TotalDiscount().IsZero() {
-		pdf.SetX(120)
-		pdf.Cell(40, 6, "Discount:")
-		pdf.Cell(30, 6, "-"+inv.TotalDiscount().String())
-		pdf.Ln(6)
-	}
-
-	pdf.SetX(120)
-	pdf.Cell(40, 6, "Tax:")
-	pdf.Cell(30, 6, inv.TotalTax().String())
-	pdf.Ln(6)
-
-	pdf.SetFont("Arial", "B", 11)
-	pdf.SetX(120)
-	pdf.Cell(40, 8, "Total:")
-	pdf.Cell(30, 8, inv.TotalGross().String())
-	pdf.Ln(15)
+		lines = append(lines, totalsLine{"Discount:", "-" + inv.TotalDiscount().String(), "", t.TextSize})
+	}
+	lines = append(lines,
+		totalsLine{"Tax:", inv.TotalTax().String(), "", t.TextSize},
+		totalsLine{"Total:", inv.TotalGross().String(), "B", t.TotalSize},
+	)
+
+	withholding := inv.WithholdingBreakdown()
+	rounding := inv.RoundingDifference()
+	if len(withholding) > 0 || !rounding.IsZero() {
+		labels := make([]string, 0, len(withholding))
+		for label := range withholding {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+
+		for _, label := range labels {
+			lines = append(lines, totalsLine{fmt.Sprintf("Withholding (%s):", label), "-" + withholding[label].String(), "", t.TextSize})
+		}
+		if !rounding.IsZero() {
+			lines = append(lines, totalsLine{"Rounding:", rounding.String(), "", t.TextSize})
+		}
+		lines = append(lines, totalsLine{"Amount Due:", inv.TotalPayable().String(), "B", t.TotalSize})
+	}
+
+	if inv.HasReportingCurrency() {
+		lines = append(lines, r.reportingTaxLines(t, inv)...)
+	}
+	return lines
+}
+
+// totalsHeight returns the height of the block written by renderTotals.
+func (r *SimpleRenderer) totalsHeight(pdf *document, inv *invoice.Invoice) float64 {
+	h := 0.0
+	for _, line := range r.totalsLines(pdf.theme, inv) {
+		h += pdf.theme.line(line.size)
+	}
+	return h
+}
+
+func (r *SimpleRenderer) renderTotals(pdf *document, inv *invoice.Invoice) {
+	pdf.tag("Div", func() {
+		for _, line := range r.totalsLines(pdf.theme, inv) {
+			pdf.totalsRow(line.label, line.value, line.style, line.size)
+		}
+	})
 }
 
-func (r *SimpleRenderer) renderFooter(pdf *fpdf.Fpdf, inv *invoice.Invoice) {
-	pdf.SetFont("Arial", "", 9)
+// reportingTaxLines shows the tax amounts converted to the reporting
+// currency together with the exchange rate used.
+func (r *SimpleRenderer) reportingTaxLines(t Theme, inv *invoice.Invoice) []totalsLine {
+	breakdown := inv.TaxBreakdownReporting()
+	rates := make([]string, 0, len(breakdown))
+	for rate := range breakdown {
+		rates = append(rates, rate)
+	}
+	sort.Strings(rates)
+
+	var lines []totalsLine
+	for _, rate := range rates {
+		lines = append(lines, totalsLine{fmt.Sprintf("Tax %s%% in %s:", rate, inv.ReportingCurrency), breakdown[rate].String(), "", t.TableSize})
+	}
+	lines = append(lines, totalsLine{fmt.Sprintf("Tax in %s:", inv.ReportingCurrency), inv.TotalTaxReporting().String(), "", t.TableSize})
+	if inv.ExchangeRate != nil {
+		lines = append(lines, totalsLine{"Rate: " + inv.ExchangeRate.String(), "", "", t.TableSize})
+	}
+	return lines
+}
+
+// renderSchedule shows the payment schedule as a table of instalments.
+func (r *SimpleRenderer) renderSchedule(pdf *document, inv *invoice.Invoice) {
+	schedule := inv.PaymentSchedule()
+	if len(schedule) == 0 {
+		return
+	}
+
+	r.renderHeading(pdf, "Payment Schedule:")
+	widths := pdf.columnWidths(20, 60, 40, 40)
+	aligns := []string{"C", "L", "C", "R"}
+	pdf.tag("Table", func() {
+		pdf.drawRow(widths, aligns, pdf.row(widths, []string{"#", "Instalment", "Due Date", "Amount"}, headerRow))
+		for i, instalment := range schedule {
+			texts := []string{fmt.Sprintf("%d", i+1), instalment.Label(), instalment.DueDate.Format("2006-01-02"), instalment.Amount.String()}
+			pdf.drawRow(widths, aligns, pdf.row(widths, texts, bodyRow))
+		}
+	})
+}
+
+// renderNotes renders the notes and the payment terms.
+func (r *SimpleRenderer) renderNotes(pdf *document, inv *invoice.Invoice) {
+	lh := pdf.theme.line(pdf.theme.TextSize)
 	if inv.Notes != "" {
-		pdf.SetFont("Arial", "B", 9)
-		pdf.Cell(0, 6, "Notes:")
-		pdf.Ln(6)
-		pdf.SetFont("Arial", "", 9)
-		pdf.MultiCell(0, 5, inv.Notes, "", "", false)
-		pdf.Ln(5)
-	}
-
-	if inv.Terms != "" {
-		pdf.SetFont("Arial", "B", 9)
-		pdf.Cell(0, 6, "Payment Terms:")
-		pdf.Ln(6)
-		pdf.SetFont("Arial", "", 9)
-		pdf.MultiCell(0, 5, inv.Terms, "", "", false)
+		r.renderHeading(pdf, "Notes:")
+		pdf.mark("P", func() { pdf.MultiCell(0, lh, inv.Notes, "", "", false) })
+	}
+
+	if terms := inv.PaymentTermsText(); terms != "" {
+		if inv.Notes != "" {
+			pdf.Ln(lh)
+		}
+		r.renderHeading(pdf, "Payment Terms:")
+		pdf.mark("P", func() { pdf.MultiCell(0, lh, terms, "", "", false) })
 	}
 }
 
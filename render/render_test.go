@@ -0,0 +1,887 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	stdimage "image"
+	"image/png"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/wiederin/go-invoicer/internal/pdfupdate"
+	"github.com/wiederin/go-invoicer/invoice"
+	"github.com/wiederin/go-invoicer/signature"
+	"github.com/wiederin/go-invoicer/template"
+	"golang.org/x/net/html"
+)
+
+func layoutString(t *testing.T, src string, opts Options) (*layout, float64) {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l, end, err := layoutHTML(doc, opts, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return l, end
+}
+
+func textItem(t *testing.T, l *layout, text string) drawItem {
+	t.Helper()
+	for _, it := range l.items {
+		if it.kind == drawText && strings.TrimSpace(it.text) == text {
+			return it
+		}
+	}
+	t.Fatalf("text %q not laid out", text)
+	return drawItem{}
+}
+
+// testInvoice returns invoice INV-001 from Supplier GmbH in Berlin to
+// Customer AG in Basel, issued on 2025-01-15 and due on 2025-02-14, with
+// the line items, in their currency.
+func testInvoice(t *testing.T, items ...invoice.LineItem) *invoice.Invoice {
+	t.Helper()
+	b := invoice.New().
+		Number("INV-001").
+		IssueDate(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)).
+		DueDate(time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC)).
+		Currency(items[0].UnitPrice.Currency).
+		Supplier(invoice.Party{Name: "Supplier GmbH", Address: invoice.Address{City: "Berlin", Country: "Germany"}}).
+		Customer(invoice.Party{Name: "Customer AG", Address: invoice.Address{City: "Basel", Country: "Switzerland"}})
+	for _, item := range items {
+		b.AddItem(item)
+	}
+	inv, err := b.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return inv
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestCascade(t *testing.T) {
+	sheet := &stylesheet{}
+	sheet.parse(userAgentCSS)
+	sheet.origin = authorOrigin
+	sheet.parse(`* { margin: 0 } p.note { color: red } .note { color: blue !important } #x { color: green }`)
+	doc, _ := html.Parse(strings.NewReader(`<p id="x" class="note" style="padding: 2mm">Hi</p>`))
+	p := findAll(doc, "p")[0]
+
+	root := rootStyle("Arial", 10)
+	s := computeStyle(sheet.cascade(p), root, root)
+	if s.margin[top].value != 0 {
+		t.Errorf("author rule should override user agent margin, got %v", s.margin[top])
+	}
+	if s.color != (rgb{0, 0, 255}) {
+		t.Errorf("important declaration should win, got %v", s.color)
+	}
+	if s.padding[left].value != 2 {
+		t.Errorf("style attribute should apply, got %v", s.padding[left])
+	}
+	if s.display != "block" {
+		t.Errorf("expected block, got %q", s.display)
+	}
+}
+
+func TestLayoutTable(t *testing.T) {
+	l, _ := layoutString(t, `<style>
+		* { margin: 0; padding: 0 }
+		table { width: 100mm; border-collapse: collapse }
+		td.fixed { width: 30mm }
+		td.right { text-align: right }
+	</style>
+	<table><tr><td class="fixed">A</td><td class="right">B</td></tr></table>`, DefaultOptions())
+
+	a, b := textItem(t, l, "A"), textItem(t, l, "B")
+	if !near(a.x, 10) {
+		t.Errorf("expected first column at the left margin, got %v", a.x)
+	}
+	if !near(b.x+b.w, 110) {
+		t.Errorf("expected right aligned text to end at 110mm, got %v", b.x+b.w)
+	}
+	if !near(a.y, b.y) {
+		t.Errorf("expected cells on one row, got %v and %v", a.y, b.y)
+	}
+
+	// Huge spans are clamped rather than allocating their columns.
+	l, _ = layoutString(t, `<table><tr><td colspan="2000000000">Wide</td></tr><tr><td colspan="-3">C</td></tr></table>`, DefaultOptions())
+	textItem(t, l, "Wide")
+	textItem(t, l, "C")
+}
+
+func TestLayoutFlex(t *testing.T) {
+	l, _ := layoutString(t, `<style>
+		* { margin: 0 }
+		.row { display: flex; justify-content: space-between }
+	</style>
+	<div class="row"><span>Left</span><span>Right</span></div>`, DefaultOptions())
+
+	first, last := textItem(t, l, "Left"), textItem(t, l, "Right")
+	if !near(first.x, 10) || !near(last.x+last.w, 200) {
+		t.Errorf("expected items at both edges, got %v and %v", first.x, last.x+last.w)
+	}
+}
+
+func TestLayoutPagination(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`<style>@page { margin: 20mm } * { margin: 0 } p { line-height: 50mm }</style>`)
+	for range 6 {
+		b.WriteString("<p>Paragraph</p>")
+	}
+	b.WriteString(`<p style="break-before: page">Last</p>`)
+	l, end := layoutString(t, b.String(), DefaultOptions())
+
+	if !near(l.pageHeight, 297-40) {
+		t.Fatalf("expected @page margins to apply, got page height %v", l.pageHeight)
+	}
+	// Five 50mm lines fit on a 257mm page; the sixth is moved to the next.
+	var lines []float64
+	for _, it := range l.items {
+		if it.kind == drawText {
+			lines = append(lines, it.y)
+		}
+	}
+	if len(lines) != 7 || !near(lines[5], l.pageHeight) {
+		t.Fatalf("expected sixth paragraph at the top of page 2, got %v", lines)
+	}
+	if l.page(lines[6]) != 2 || l.page(end-epsilon) != 2 {
+		t.Errorf("expected forced break to page 3, got page %d", l.page(lines[6]))
+	}
+}
+
+func TestLayoutTableHeaderRepeated(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`<style>* { margin: 0 } td { height: 20mm }</style><table><thead><tr><th>Header</th></tr></thead><tbody>`)
+	for i := range 30 {
+		fmt.Fprintf(&b, "<tr><td>Row %d</td></tr>", i)
+	}
+	b.WriteString("</tbody></table>")
+	l, end := layoutString(t, b.String(), DefaultOptions())
+
+	pages := l.page(end-epsilon) + 1
+	var headers []float64
+	for _, it := range l.items {
+		if it.kind == drawText && it.text == "Header" {
+			headers = append(headers, it.y)
+		}
+	}
+	if pages < 2 || len(headers) != pages {
+		t.Fatalf("expected a header on each of %d pages, got %v", pages, headers)
+	}
+	for i, y := range headers {
+		if l.page(y) != i {
+			t.Errorf("expected header %d on page %d, got page %d", i, i, l.page(y))
+		}
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	pdf, err := newDocument(DefaultOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pdf.setFont("", 9)
+	lines := pdf.splitText("Implementation of the customer portal including single sign-on\nand a Donaudampfschifffahrtsgesellschaftskapitänsmütze", 40)
+	if len(lines) < 4 {
+		t.Fatalf("expected wrapped lines, got %q", lines)
+	}
+	for _, line := range lines {
+		if w := pdf.GetStringWidth(pdf.fonts.encode("Arial", line)); w > 40 {
+			t.Errorf("line %q is %.1fmm wide", line, w)
+		}
+	}
+}
+
+func TestSimpleRendererPageBreaks(t *testing.T) {
+	items := make([]invoice.LineItem, 40)
+	for i := range items {
+		items[i] = invoice.NewLineItem(fmt.Sprintf("Item %d with a description long enough to wrap onto a second line of the column", i),
+			1, invoice.NewMoney(10, "EUR"), 19)
+	}
+	pdf, err := NewSimpleRenderer().RenderInvoice(testInvoice(t, items...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pages := len(regexp.MustCompile(`/Type /Page\b[^s]`).FindAll(pdf, -1)); pages < 3 {
+		t.Errorf("expected at least 3 pages, got %d", pages)
+	}
+}
+
+func TestEngineRenderInvoice(t *testing.T) {
+	inv := testInvoice(t, invoice.NewLineItem("Consulting", 10, invoice.NewMoney(100, "EUR"), 19))
+	engine := NewEngine(template.NewManager(template.NewFSSource(os.DirFS("../templates"))))
+	pdf, err := engine.RenderInvoice(inv, "invoice_default.html")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Error("expected PDF output")
+	}
+}
+
+// dejaVu returns the subset of DejaVu Sans Condensed under testdata, which
+// covers Latin, Greek and Cyrillic.
+func dejaVu(t *testing.T) Font {
+	t.Helper()
+	return Font{
+		Family:  "DejaVu",
+		Regular: "DejaVuSansCondensed.ttf",
+		Bold:    "DejaVuSansCondensed-Bold.ttf",
+		FS:      os.DirFS("testdata"),
+	}
+}
+
+func TestParseCmap(t *testing.T) {
+	font := dejaVu(t)
+	data, err := readFile(font.FS, font.Regular)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ranges, err := parseCmap(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	set := &fontSet{faces: map[string]*fontFace{"dejavu": {family: "DejaVu", ranges: ranges}}}
+	for _, r := range "AßłŁΩЖ€" {
+		if !set.covers("DejaVu", r) {
+			t.Errorf("expected glyph for %q", r)
+		}
+	}
+	if set.covers("DejaVu", '中') {
+		t.Error("expected no glyph for CJK")
+	}
+	if set.covers("Arial", 'ł') || !set.covers("Arial", 'ü') {
+		t.Error("core fonts should cover Windows-1252 only")
+	}
+	if _, err := parseCmap([]byte("not a font")); err == nil {
+		t.Error("expected error for invalid font")
+	}
+}
+
+func TestLayoutFontFallback(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Fonts = []Font{dejaVu(t)}
+	opts.FallbackFonts = map[string][]string{"Greek": {"Times", "DejaVu"}}
+	l, _ := layoutString(t, `<p style="font-family: Helvetica">Zürich Łódź Ωμέγα</p>`, opts)
+
+	var runs []string
+	for _, it := range l.items {
+		if it.kind == drawText {
+			runs = append(runs, it.font.family+":"+it.text)
+		}
+	}
+	// Polish letters are missing from the core fonts and have no script
+	// fallback, so they stay in Helvetica; Greek falls back to DejaVu.
+	want := []string{"Arial:Zürich Łódź ", "DejaVu:Ωμέγα"}
+	if strings.Join(runs, "|") != strings.Join(want, "|") {
+		t.Errorf("expected runs %q, got %q", want, runs)
+	}
+
+	opts.FontFamily = "DejaVu"
+	l, _ = layoutString(t, `<p style="font-family: Helvetica">Łódź</p>`, opts)
+	if it := textItem(t, l, "Ł"); it.font.family != "DejaVu" {
+		t.Errorf("expected fallback to the options font, got %q", it.font.family)
+	}
+}
+
+func TestSimpleRendererFonts(t *testing.T) {
+	inv := testInvoice(t, invoice.NewLineItem("Usługi doradcze", 1, invoice.NewMoney(100, "PLN"), 23))
+	inv.Supplier = invoice.Party{Name: "Dostawca Sp. z o.o.", Address: invoice.Address{City: "Łódź", Country: "Poland"}}
+	inv.Customer = invoice.Party{Name: "Πελάτης Α.Ε.", Address: invoice.Address{City: "Αθήνα", Country: "Greece"}}
+
+	renderer := NewSimpleRenderer()
+	renderer.Options.Fonts = []Font{dejaVu(t)}
+	renderer.Options.FontFamily = "DejaVu"
+	pdf, err := renderer.RenderInvoice(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(pdf, []byte("/FontFile2")) {
+		t.Error("expected embedded TrueType font")
+	}
+
+	renderer.Options.Fonts = []Font{{Family: "Missing", Regular: "missing.ttf"}}
+	if _, err := renderer.RenderInvoice(inv); err == nil {
+		t.Error("expected error for missing font file")
+	}
+}
+
+// pageContents returns the uncompressed content streams of a PDF.
+func pageContents(t *testing.T, pdf []byte) string {
+	t.Helper()
+	var out strings.Builder
+	for _, m := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		r, err := zlib.NewReader(bytes.NewReader(m[1]))
+		if err != nil {
+			continue
+		}
+		data, _ := io.ReadAll(r)
+		out.Write(data)
+	}
+	return out.String()
+}
+
+func TestSections(t *testing.T) {
+	items := make([]invoice.LineItem, 40)
+	for i := range items {
+		items[i] = invoice.NewLineItem(fmt.Sprintf("Item %d", i), 1, invoice.NewMoney(10, "EUR"), 19)
+	}
+	inv := testInvoice(t, items...)
+	inv.Number = "INV-004"
+	inv.Supplier.IBAN = "DE89370400440532013000"
+
+	opts := DefaultOptions()
+	opts.Header = Section{Left: "{supplier.name}", Right: "Invoice {invoice.number}", Line: true}
+	opts.ContinuationHeader = &Section{Right: "Invoice {invoice.number} (continued)"}
+	opts.Footer = Section{Left: "IBAN {supplier.iban}", Right: "Page {page} of {pages}"}
+
+	engine := NewEngine(template.NewManager(template.NewFSSource(os.DirFS("../templates")))).WithOptions(opts)
+	fromTemplate, err := engine.RenderInvoice(inv, "invoice_default.html")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	renderer := NewSimpleRenderer()
+	renderer.Options = opts
+	simple, err := renderer.RenderInvoice(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, pdf := range map[string][]byte{"engine": fromTemplate, "simple": simple} {
+		pages := len(regexp.MustCompile(`/Type /Page\b[^s]`).FindAll(pdf, -1))
+		if pages < 2 {
+			t.Fatalf("%s: expected at least 2 pages, got %d", name, pages)
+		}
+		content := pageContents(t, pdf)
+		for _, want := range []string{
+			"(Page 1 of " + fmt.Sprint(pages) + ")",
+			"(Page " + fmt.Sprint(pages) + " of " + fmt.Sprint(pages) + ")",
+			"(IBAN DE89 3704 0044 0532 0130 00)",
+			"(Supplier GmbH)",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("%s: expected %s in the page content", name, want)
+			}
+		}
+		if got := strings.Count(content, "(Invoice INV-004 \\(continued\\))"); got != pages-1 {
+			t.Errorf("%s: expected %d continuation headers, got %d", name, pages-1, got)
+		}
+		if got := strings.Count(content, "(Invoice INV-004)"); got != 1 {
+			t.Errorf("%s: expected 1 first-page header, got %d", name, got)
+		}
+	}
+}
+
+// testPNG returns a PNG image of the given size in pixels.
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, stdimage.NewGray(stdimage.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestLayoutImage(t *testing.T) {
+	src := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t, 40, 20))
+	svg := `data:image/svg+xml,<svg width="30" height="10"><path d="M0 0 L30 10"/></svg>`
+	l, _ := layoutString(t, `<p>Logo <img src="`+src+`" width="100"> <img src="missing.png" alt="ACME"></p>
+		<div style="width: 50mm"><img src='`+svg+`' style="width: 100%"></div>`, DefaultOptions())
+
+	var images []drawItem
+	for _, it := range l.items {
+		if it.kind == drawImage {
+			images = append(images, it)
+		}
+	}
+	if len(images) != 2 {
+		t.Fatalf("expected 2 images, got %d", len(images))
+	}
+	if !near(images[0].w, 100*mmPerPx) || !near(images[0].h, 50*mmPerPx) {
+		t.Errorf("expected 100x50 px PNG, got %.2fx%.2f mm", images[0].w, images[0].h)
+	}
+	if logo := textItem(t, l, "Logo"); !near(images[0].y+images[0].h, logo.y+logo.baseline) {
+		t.Errorf("expected image on the baseline")
+	}
+	if !near(images[1].w, 50) || !near(images[1].h, 50.0/3) {
+		t.Errorf("expected 50 mm wide SVG, got %.2fx%.2f mm", images[1].w, images[1].h)
+	}
+	textItem(t, l, "ACME")
+}
+
+func TestSimpleRendererImages(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "letterhead.png"), testPNG(t, 210, 297), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stationery := fpdf.New("P", "mm", "A4", "")
+	stationery.AddPage()
+	stationery.SetFont("Helvetica", "", 8)
+	stationery.Text(20, 285, "Stationery")
+	var buf bytes.Buffer
+	if err := stationery.Output(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "letterhead.pdf"), buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.pdf"), []byte("%PDF-1.4"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inv := testInvoice(t, invoice.NewLineItem("Consulting", 10, invoice.NewMoney(100, "EUR"), 19))
+	inv.Supplier.Logo = "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t, 40, 20))
+
+	renderer := NewSimpleRenderer()
+	renderer.Options.Images = os.DirFS(dir)
+	renderer.Options.Letterhead = Letterhead{First: "letterhead.png"}
+	pdf, err := renderer.RenderInvoice(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := bytes.Count(pdf, []byte("/Subtype /Image")); got != 2 {
+		t.Errorf("expected logo and letterhead images, got %d", got)
+	}
+
+	engine := NewEngine(template.NewManager(template.NewFSSource(os.DirFS("../templates")))).WithOptions(renderer.Options)
+	if pdf, err = engine.RenderInvoice(inv, "invoice_default.html"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := bytes.Count(pdf, []byte("/Subtype /Image")); got != 2 {
+		t.Errorf("expected logo and letterhead images, got %d", got)
+	}
+
+	// PDF letterheads are imported as forms, with the fonts they use.
+	renderer.Options.Letterhead = Letterhead{First: "letterhead.pdf"}
+	if pdf, err = renderer.RenderInvoice(inv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := bytes.Count(pdf, []byte("/Subtype /Form")); got != 1 {
+		t.Errorf("expected the letterhead as a form, got %d", got)
+	}
+	content := pageContents(t, pdf)
+	for _, want := range []string{"(Stationery) Tj", "/TPLimage1 Do", "(Supplier GmbH)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %s in the page content", want)
+		}
+	}
+	if got := bytes.Count(pdf, []byte("/BaseFont /Helvetica\n")); got != 2 {
+		t.Errorf("expected the letterhead font to be copied, got %d Helvetica fonts", got)
+	}
+	if _, err := pdfupdate.Parse(pdf); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	renderer.Options.Letterhead = Letterhead{First: "broken.pdf"}
+	if _, err := renderer.RenderInvoice(inv); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("expected ErrUnsupportedImage for a broken PDF letterhead, got %v", err)
+	}
+
+	// SVG shapes are filled and stroked in their colors.
+	renderer.Options.Letterhead = Letterhead{}
+	renderer.Options.Logo = Logo{Source: `data:image/svg+xml,<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 40 20" width="40" height="20">` +
+		`<style>.mark { fill: #0000ff }</style>` +
+		`<rect width="40" height="20" fill="#ff0000" stroke="black" stroke-width="2"/>` +
+		`<g transform="translate(20 10)"><circle class="mark" r="5"/><path d="M-4 0 a4 4 0 0 1 8 0z" style="fill: none; stroke: green"/></g></svg>`}
+	if pdf, err = renderer.RenderInvoice(inv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content = pageContents(t, pdf)
+	for _, want := range []string{"1.000 0.000 0.000 rg", "0.000 0.000 1.000 rg", "0.000 0.502 0.000 RG", "\nB\n", "\nf\n", "\nS\n"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in the page content", want)
+		}
+	}
+}
+
+func TestSimpleRendererThemes(t *testing.T) {
+	items := make([]invoice.LineItem, 30)
+	for i := range items {
+		items[i] = invoice.NewLineItem(fmt.Sprintf("Item %d with a description that wraps in narrow columns", i),
+			1, invoice.NewMoney(1000, "EUR"), 19)
+	}
+	inv := testInvoice(t, items...)
+
+	rect := regexp.MustCompile(`([\d.]+) [\d.-]+ ([\d.]+) [\d.-]+ re`)
+	for name, theme := range map[string]Theme{"classic": ClassicTheme(), "modern": ModernTheme(), "compact": CompactTheme(), "zero": {}} {
+		for _, page := range []struct {
+			size        string
+			orientation string
+			width       float64
+		}{{"A4", "P", 210}, {"A5", "P", 148.5}, {"Letter", "P", 215.9}, {"A4", "L", 297}} {
+			renderer := NewSimpleRenderer()
+			renderer.Theme = theme
+			renderer.Options.PageSize = page.size
+			renderer.Options.Orientation = page.orientation
+			pdf, err := renderer.RenderInvoice(inv)
+			if err != nil {
+				t.Fatalf("%s %s: unexpected error: %v", name, page.size, err)
+			}
+			// Tables span the width between the margins.
+			right := 0.0
+			for _, m := range rect.FindAllStringSubmatch(pageContents(t, pdf), -1) {
+				x, _ := strconv.ParseFloat(m[1], 64)
+				w, _ := strconv.ParseFloat(m[2], 64)
+				right = max(right, (x+w)*25.4/72)
+			}
+			if want := page.width - renderer.Options.MarginRight; math.Abs(right-want) > 0.1 {
+				t.Errorf("%s %s %s: expected content to end at %.1f mm, got %.1f mm", name, page.size, page.orientation, want, right)
+			}
+		}
+	}
+
+	// Sizes and spacing a theme leaves zero are those of the classic theme.
+	render := func(theme Theme) string {
+		renderer := NewSimpleRenderer()
+		renderer.Theme = theme
+		pdf, err := renderer.RenderInvoice(inv)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return pageContents(t, pdf)
+	}
+	blue := Color{59, 130, 246}
+	want := ClassicTheme()
+	want.TitleColor = blue
+	if render(Theme{TitleColor: blue}) != render(want) {
+		t.Error("expected a partial theme to be completed from the classic theme")
+	}
+}
+
+func TestSimpleRendererColumns(t *testing.T) {
+	inv := testInvoice(t, invoice.NewLineItem("Consulting", 8, invoice.NewMoney(120, "EUR"), 19).
+		WithSKU("CONS-01").
+		WithUnit("h").
+		WithDate(time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)).
+		WithMetadata("project", "Apollo").
+		WithDiscount(10))
+
+	renderer := NewSimpleRenderer()
+	renderer.Options.Orientation = "L"
+	renderer.Theme.Columns = []Column{
+		ColumnFor(FieldDate),
+		ColumnFor(FieldSKU),
+		ColumnFor(FieldDescription),
+		ColumnFor(FieldQuantity),
+		ColumnFor(FieldUnit),
+		ColumnFor(FieldDiscount),
+		ColumnFor(MetadataField("project")),
+		{Title: "Rate", Width: 30, Align: "R", Format: func(item invoice.LineItem) string {
+			return item.UnitPrice.Amount.StringFixed(2) + "/" + item.Unit
+		}},
+		ColumnFor(FieldNetAmount),
+	}
+	pdf, err := renderer.RenderInvoice(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := pageContents(t, pdf)
+	for _, want := range []string{"(2025-01-10)", "(CONS-01)", "(h)", "(10%)", "(project)", "(Apollo)", "(120.00/h)", "(Net)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %s in the page content", want)
+		}
+	}
+	if strings.Contains(content, "(Tax %)") {
+		t.Error("expected no tax column")
+	}
+
+	// Columns without a width get their default one, and the running
+	// total is printed under the column it sums.
+	items := make([]invoice.LineItem, 60)
+	for i := range items {
+		items[i] = invoice.NewLineItem("Consulting", 1, invoice.NewMoney(100, "EUR"), 19)
+	}
+	inv = testInvoice(t, items...)
+	renderer = NewSimpleRenderer()
+	renderer.Theme.Columns = []Column{
+		{Field: FieldDescription, Title: "Description"},
+		{Field: FieldNetAmount, Title: "Net", Align: "R"},
+		{Field: FieldQuantity, Title: "Qty", Align: "C"},
+	}
+	if pdf, err = renderer.RenderInvoice(inv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content = pageContents(t, pdf)
+	m := regexp.MustCompile(`\(Carried forward:\)Tj ET Q[^(]*?BT ([\d.]+) [\d.]+ Td \((\d+\.\d+ EUR)\)Tj`).FindStringSubmatch(content)
+	if m == nil {
+		t.Fatal("expected a carried forward amount")
+	}
+	// Description, net and quantity take 80, 40 and 20 parts of 190 mm.
+	x, _ := strconv.ParseFloat(m[1], 64)
+	if x *= 25.4 / 72; x < 10+190*80/140 || x > 10+190*120/140 {
+		t.Errorf("expected the carried amount %s under the net column, got x = %.1f mm", m[2], x)
+	}
+}
+
+func TestWatermarks(t *testing.T) {
+	build := func(t *testing.T, status invoice.Status) *invoice.Invoice {
+		inv := testInvoice(t, invoice.NewLineItem("Consulting", 1, invoice.NewMoney(100, "EUR"), 19))
+		inv.Status = status
+		inv.PaidDate = time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)
+		return inv
+	}
+	engine := NewEngine(template.NewManager(template.NewFSSource(os.DirFS("../templates"))))
+
+	tests := []struct {
+		name    string
+		status  invoice.Status
+		opts    func(*Options)
+		want    []string
+		notWant []string
+	}{
+		{"draft", invoice.StatusDraft, nil, []string{"(DRAFT)"}, []string{"(PAID)"}},
+		{"cancelled", invoice.StatusCancelled, nil, []string{"(CANCELLED)"}, nil},
+		{"paid", invoice.StatusPaid, nil, []string{"(PAID)", "(2025-02-03)"}, []string{"(DRAFT)"}},
+		{"issued", invoice.StatusIssued, nil, nil, []string{"(DRAFT)", "(PAID)", "(COPY)"}},
+		{"copy", invoice.StatusIssued, func(o *Options) { o.Copy = "COPY" }, []string{"(COPY)"}, nil},
+		{"text", invoice.StatusDraft, func(o *Options) { o.Watermark.Text = "SPECIMEN" }, []string{"(SPECIMEN)"}, []string{"(DRAFT)"}},
+		{"no status", invoice.StatusPaid, func(o *Options) { o.Watermark.NoStatus = true }, nil, []string{"(PAID)"}},
+		{"horizontal", invoice.StatusDraft, func(o *Options) { o.Watermark.Angle = new(float64) }, []string{"1.00000 0.00000 -0.00000 1.00000 0.00000 0.00000 cm"}, nil},
+		{"diagonal", invoice.StatusDraft, nil, nil, []string{"1.00000 0.00000 -0.00000 1.00000 0.00000 0.00000 cm"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := NewSimpleRenderer()
+			engine.Options = DefaultOptions()
+			if tt.opts != nil {
+				tt.opts(&renderer.Options)
+				tt.opts(&engine.Options)
+			}
+			inv := build(t, tt.status)
+			simple, err := renderer.RenderInvoice(inv)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			html, err := engine.RenderInvoice(inv, "invoice_default.html")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for name, pdf := range map[string][]byte{"simple": simple, "engine": html} {
+				content := pageContents(t, pdf)
+				for _, want := range tt.want {
+					if !strings.Contains(content, want) {
+						t.Errorf("%s: expected %s in the page content", name, want)
+					}
+				}
+				for _, unwanted := range tt.notWant {
+					if strings.Contains(content, unwanted) {
+						t.Errorf("%s: expected no %s in the page content", name, unwanted)
+					}
+				}
+			}
+		})
+	}
+}
+
+// checkTagged checks that the marked-content sequences of a tagged PDF are
+// balanced and that its last update is readable.
+func checkTagged(t *testing.T, pdf []byte) {
+	t.Helper()
+	content := pageContents(t, pdf)
+	begun := strings.Count(content, " BDC") + strings.Count(content, " BMC")
+	if ended := strings.Count(content, "EMC"); begun == 0 || begun != ended {
+		t.Errorf("expected balanced marked content, got %d begun and %d ended", begun, ended)
+	}
+	u, err := pdfupdate.Parse(pdf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	catalog, err := u.Object(u.Root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(catalog, "/StructTreeRoot") {
+		t.Errorf("expected a tagged catalog, got %s", catalog)
+	}
+	for _, want := range []string{"/StructTreeRoot", "/MarkInfo << /Marked true >>", "/StructParents 0"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("expected %s in the PDF", want)
+		}
+	}
+}
+
+func TestMetadataAndTagging(t *testing.T) {
+	inv := testInvoice(t, invoice.NewLineItem("Consulting", 1, invoice.NewMoney(100, "EUR"), 19))
+	inv.Number = "INV-009"
+	inv.Supplier.Name = "Supplier & Co"
+	inv.Notes = "Thank you"
+
+	renderer := NewSimpleRenderer()
+	pdf, err := renderer.RenderInvoice(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"/Lang (en)",
+		"/DisplayDocTitle true",
+		`<rdf:li xml:lang="x-default">Invoice INV-009</rdf:li>`,
+		"<rdf:li>Supplier &amp; Co</rdf:li>",
+		"Invoice INV-009 from Supplier &amp; Co to Customer AG, issued 2025-01-15, due 2025-02-14",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("expected %s in the PDF", want)
+		}
+	}
+	if bytes.Contains(pdf, []byte("/StructTreeRoot")) {
+		t.Error("expected no structure tree unless tagged")
+	}
+
+	renderer.Options.Tagged = true
+	renderer.Options.Footer = Section{Center: "Page {page}"}
+	pdf, err = renderer.RenderInvoice(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTagged(t, pdf)
+	for _, want := range []string{"/S /H1", "/S /H2", "/S /Table", "/S /TR", "/S /TD", "/S /TH /P", "/Scope /Column"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("expected %s in the structure tree", want)
+		}
+	}
+	if bytes.Contains(pdf, []byte("pdfuaid:part>")) {
+		t.Error("expected no PDF/UA identification with the standard PDF fonts")
+	}
+
+	renderer.Options.Fonts = []Font{dejaVu(t)}
+	renderer.Options.FontFamily = "DejaVu"
+	pdf, err = renderer.RenderInvoice(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTagged(t, pdf)
+	for _, want := range []string{"/FontFile2", "<pdfuaid:part>1</pdfuaid:part>"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("expected %s in the PDF", want)
+		}
+	}
+	if bytes.Contains(pdf, []byte("/BaseFont /Helvetica")) {
+		t.Error("expected no standard PDF fonts")
+	}
+
+	engine := NewEngine(template.NewManager(template.NewFSSource(os.DirFS("../templates"))))
+	engine.Options.Tagged = true
+	engine.Options.Language = "de-CH"
+	pdf, err = engine.RenderInvoice(inv, "invoice_default.html")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTagged(t, pdf)
+	for _, want := range []string{"/Lang (de-CH)", "/S /H1", "/S /THead", "/S /TH /P", "/S /TD"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("expected %s in the PDF", want)
+		}
+	}
+	if bytes.Contains(pdf, []byte("pdfuaid:part>")) {
+		t.Error("expected no PDF/UA identification with the standard PDF fonts")
+	}
+}
+
+func TestLayoutStructure(t *testing.T) {
+	src := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t, 40, 20))
+	opts := DefaultOptions()
+	opts.Tagged = true
+	l, _ := layoutString(t, `<h2>Title</h2><ul><li>One</li></ul>
+		<p>Text <img src="`+src+`" alt="Logo"> <img src="`+src+`" alt=""></p>`, opts)
+
+	var tags []string
+	var walk func(e *structElem)
+	walk = func(e *structElem) {
+		tags = append(tags, e.tag)
+		for _, k := range e.kids {
+			if k.elem != nil {
+				walk(k.elem)
+			}
+		}
+	}
+	walk(l.tags.root)
+	if got, want := strings.Join(tags, " "), "Document H2 L LI Lbl LBody P Figure"; got != want {
+		t.Errorf("expected structure %q, got %q", want, got)
+	}
+	if item := textItem(t, l, "One"); item.elem == nil || item.elem.tag != "LBody" {
+		t.Errorf("expected list item text in LBody, got %+v", item.elem)
+	}
+	var figures, decorative int
+	for _, it := range l.items {
+		switch {
+		case it.kind != drawImage:
+		case it.elem == nil:
+			decorative++
+		case it.elem.tag == "Figure" && it.elem.alt == "Logo":
+			figures++
+		}
+	}
+	if figures != 1 || decorative != 1 {
+		t.Errorf("expected 1 figure and 1 decorative image, got %d and %d", figures, decorative)
+	}
+}
+
+func TestSignedPDF(t *testing.T) {
+	inv := testInvoice(t, invoice.NewLineItem("Consulting", 1, invoice.NewMoney(100, "EUR"), 19))
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Supplier GmbH"},
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	signer := &signature.Signer{Key: key, Certificate: cert, Reason: "Invoice issued"}
+
+	renderer := NewSimpleRenderer()
+	renderer.Options.Signer = signer
+	renderer.Options.Tagged = true
+	engine := NewEngine(template.NewManager(template.NewFSSource(os.DirFS("../templates"))))
+	engine.Options.Signer = signer
+	renderers := map[string]func() ([]byte, error){
+		"simple": func() ([]byte, error) { return renderer.RenderInvoice(inv) },
+		"engine": func() ([]byte, error) { return engine.RenderInvoice(inv, "invoice_default.html") },
+	}
+	for name, f := range renderers {
+		pdf, err := f()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		sigs, err := signature.Verify(pdf, signature.VerifyOptions{Roots: roots})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if len(sigs) != 1 || !sigs[0].CoversDocument || sigs[0].Reason != "Invoice issued" {
+			t.Errorf("%s: expected one signature of the whole document, got %+v", name, sigs)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/wiederin/go-invoicer/banking"
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// Section is a page header or footer with text aligned left, centred and
+// right. Lines are separated by "\n". The text may contain placeholders:
+//
+//	{page}, {pages}
+//	{invoice.number}, {invoice.date}, {invoice.due_date}
+//	{supplier.name}, {supplier.address}, {supplier.email}, {supplier.phone},
+//	{supplier.vat_id}, {supplier.iban}, {supplier.bic}
+//	{customer.name}
+type Section struct {
+	Left   string
+	Center string
+	Right  string
+	// FontSize is in points, 8 by default.
+	FontSize float64
+	// Line draws a rule between the section and the page content.
+	Line bool
+}
+
+// IsEmpty returns true if the section has no text.
+func (s Section) IsEmpty() bool {
+	return s.Left == "" && s.Center == "" && s.Right == ""
+}
+
+// sectionGap separates headers and footers from the page content.
+const sectionGap = 4
+
+func (s Section) fontSize() float64 {
+	if s.FontSize > 0 {
+		return s.FontSize
+	}
+	return 8
+}
+
+func (s Section) lineHeight() float64 {
+	return s.fontSize() * mmPerPt * 1.25
+}
+
+// height returns the space taken by the section including the gap to the
+// page content.
+func (s Section) height() float64 {
+	if s.IsEmpty() {
+		return 0
+	}
+	lines := 1
+	for _, text := range []string{s.Left, s.Center, s.Right} {
+		lines = max(lines, strings.Count(text, "\n")+1)
+	}
+	return float64(lines)*s.lineHeight() + sectionGap
+}
+
+// headerHeight returns the space reserved for headers at the top of pages.
+func (o Options) headerHeight() float64 {
+	h := o.Header.height()
+	if o.ContinuationHeader != nil {
+		h = max(h, o.ContinuationHeader.height())
+	}
+	return h
+}
+
+// showsPageCount returns true if a header or footer shows the page count.
+func (o Options) showsPageCount() bool {
+	for _, s := range []Section{o.header(1), o.header(2), o.Footer} {
+		if strings.Contains(s.Left+s.Center+s.Right, "{pages}") {
+			return true
+		}
+	}
+	return false
+}
+
+// header returns the header of a page, numbered from 1.
+func (o Options) header(page int) Section {
+	if page > 1 && o.ContinuationHeader != nil {
+		return *o.ContinuationHeader
+	}
+	return o.Header
+}
+
+// placeholders returns the values of the invoice placeholders of headers
+// and footers.
+func placeholders(inv *invoice.Invoice) map[string]string {
+	if inv == nil {
+		return nil
+	}
+	values := map[string]string{
+		"{invoice.number}":   inv.Number,
+		"{invoice.date}":     inv.IssueDate.Format("2006-01-02"),
+		"{invoice.due_date}": "",
+		"{supplier.name}":    inv.Supplier.Name,
+		"{supplier.address}": strings.Join(inv.Supplier.Address.Lines(), ", "),
+		"{supplier.email}":   inv.Supplier.Email,
+		"{supplier.phone}":   inv.Supplier.Phone,
+		"{supplier.vat_id}":  inv.Supplier.VATID,
+		"{supplier.iban}":    banking.FormatIBAN(inv.Supplier.IBAN),
+		"{supplier.bic}":     inv.Supplier.BIC,
+		"{customer.name}":    inv.Customer.Name,
+	}
+	if !inv.DueDate.IsZero() {
+		values["{invoice.due_date}"] = inv.DueDate.Format("2006-01-02")
+	}
+	return values
+}
+
+// expand replaces the placeholders in text.
+func expand(text string, values map[string]string, page, pages int) string {
+	pairs := []string{"{page}", strconv.Itoa(page), "{pages}", strconv.Itoa(pages)}
+	for k, v := range values {
+		pairs = append(pairs, k, v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// pageSections draws the letterhead, logo, headers, footers and
+// watermarks of the pages of a document. Headers and footers are set
+// within its page margins.
+type pageSections struct {
+	opts       Options
+	fonts      *fontSet
+	values     map[string]string
+	margins    [4]float64
+	logo       *image
+	letterhead [2]*image
+	watermark  []string
+	stamp      []string
+}
+
+// newPageSections returns the sections of the pages of an invoice, which
+// may be nil, with the given page margins.
+func newPageSections(opts Options, fonts *fontSet, inv *invoice.Invoice, margins [4]float64) *pageSections {
+	return &pageSections{
+		opts:      opts,
+		fonts:     fonts,
+		values:    placeholders(inv),
+		margins:   margins,
+		watermark: watermarkLines(opts, inv),
+		stamp:     stampLines(opts, inv),
+	}
+}
+
+// loadImages loads the letterhead of the options and a logo.
+func (s *pageSections) loadImages(images *imageSet, logo string) error {
+	var err error
+	if logo != "" {
+		if s.logo, err = images.load(logo); err != nil {
+			return err
+		}
+	}
+	for i, src := range []string{s.opts.Letterhead.First, s.opts.Letterhead.Next} {
+		if src == "" {
+			continue
+		}
+		if s.letterhead[i], err = images.load(src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drawBackground draws the letterhead of a page, numbered from 1, and the
+// logo on the first page.
+func (s *pageSections) drawBackground(pdf *fpdf.Fpdf, page int) {
+	pageWidth, pageHeight := pdf.GetPageSize()
+	if bg := s.letterhead[min(page, 2)-1]; bg != nil {
+		bg.draw(pdf, 0, 0, pageWidth, pageHeight)
+	}
+	if page != 1 || s.logo == nil {
+		return
+	}
+	logo := s.opts.Logo
+	w, h := s.logo.size(logo.Width, logo.Height)
+	if logo.Width == 0 && logo.Height == 0 {
+		w, h = s.logo.size(0, 20)
+	}
+	x, y := logo.X, logo.Y
+	if x == 0 && y == 0 {
+		x, y = pageWidth-s.margins[right]-w, s.margins[top]+s.opts.Header.height()
+	}
+	s.logo.draw(pdf, x, y, w, h)
+}
+
+// drawHeader draws the header of a page, numbered from 1.
+func (s *pageSections) drawHeader(pdf *fpdf.Fpdf, page, pages int) {
+	s.draw(pdf, s.opts.header(page), s.margins[top], false, page, pages)
+}
+
+// drawFooter draws the footer of a page so that it ends at the bottom
+// margin.
+func (s *pageSections) drawFooter(pdf *fpdf.Fpdf, page, pages int) {
+	footer := s.opts.Footer
+	_, pageHeight := pdf.GetPageSize()
+	s.draw(pdf, footer, pageHeight-s.margins[bottom]-footer.height()+sectionGap, true, page, pages)
+}
+
+// draw draws a section whose first line starts at y. The rule is drawn
+// below headers and above footers. The position of the PDF is kept.
+func (s *pageSections) draw(pdf *fpdf.Fpdf, section Section, y float64, footer bool, page, pages int) {
+	if section.IsEmpty() {
+		return
+	}
+	x, y0 := pdf.GetXY()
+	defer pdf.SetXY(x, y0)
+
+	pageWidth, _ := pdf.GetPageSize()
+	x0, x1 := s.margins[left], pageWidth-s.margins[right]
+	lh := section.lineHeight()
+	if section.Line {
+		ruleY := y + section.height() - sectionGap/2
+		if footer {
+			ruleY = y - sectionGap/2
+		}
+		pdf.SetDrawColor(160, 160, 160)
+		pdf.SetLineWidth(0.2)
+		pdf.Line(x0, ruleY, x1, ruleY)
+		pdf.SetDrawColor(0, 0, 0)
+	}
+
+	pdf.SetTextColor(80, 80, 80)
+	for _, column := range []struct {
+		text  string
+		align string
+	}{{section.Left, "L"}, {section.Center, "C"}, {section.Right, "R"}} {
+		if column.text == "" {
+			continue
+		}
+		for i, line := range strings.Split(expand(column.text, s.values, page, pages), "\n") {
+			family := s.fonts.pickText(s.fonts.primary, line)
+			pdf.SetFont(family, s.fonts.style(family, ""), section.fontSize())
+			pdf.SetXY(x0, y+float64(i)*lh)
+			pdf.CellFormat(x1-x0, lh, s.fonts.encode(family, line), "", 0, column.align, false, 0, "")
+		}
+	}
+	pdf.SetTextColor(0, 0, 0)
+}
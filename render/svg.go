@@ -0,0 +1,803 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// svgImage is an SVG image reduced to filled and stroked paths. Shapes,
+// groups, transforms, presentation attributes, style attributes and class
+// rules of <style> elements are supported; text, clipping, masks and
+// patterns are not. Gradients are drawn in the color of their first stop.
+type svgImage struct {
+	// width and height are the intrinsic size in mm.
+	width, height float64
+	viewBox       [4]float64
+	shapes        []svgShape
+}
+
+// svgShape is a path in the coordinates of the view box.
+type svgShape struct {
+	path          []svgSegment
+	fill, stroke  *rgb
+	strokeWidth   float64
+	fillOpacity   float64
+	strokeOpacity float64
+	evenOdd       bool
+}
+
+// svgSegment is a move ('M'), line ('L'), cubic curve ('C') or close
+// ('Z') command with absolute points.
+type svgSegment struct {
+	cmd byte
+	pts [3]svgPoint
+}
+
+type svgPoint struct {
+	x, y float64
+}
+
+// svgMatrix is an affine transform [a b c d e f].
+type svgMatrix [6]float64
+
+var svgIdentity = svgMatrix{1, 0, 0, 1, 0, 0}
+
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1], m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3], m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4], m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m svgMatrix) apply(p svgPoint) svgPoint {
+	return svgPoint{m[0]*p.x + m[2]*p.y + m[4], m[1]*p.x + m[3]*p.y + m[5]}
+}
+
+// scale returns the factor by which the transform scales lengths.
+func (m svgMatrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// svgStyle holds the inherited presentation properties of an element.
+type svgStyle struct {
+	fill, stroke  string
+	strokeWidth   float64
+	fillRule      string
+	opacity       float64
+	fillOpacity   float64
+	strokeOpacity float64
+	transform     svgMatrix
+	hidden        bool
+	color         string
+}
+
+// svgElement is an element of the SVG document tree.
+type svgElement struct {
+	name     string
+	attrs    map[string]string
+	children []*svgElement
+	text     string
+}
+
+// parseSVG parses an SVG document.
+func parseSVG(data []byte) (*svgImage, error) {
+	root, err := readSVGTree(data)
+	if err != nil {
+		return nil, err
+	}
+	if root.name != "svg" {
+		return nil, errors.New("not an SVG document")
+	}
+
+	img := &svgImage{}
+	if vb := strings.FieldsFunc(root.attrs["viewBox"], isSVGSeparator); len(vb) == 4 {
+		for i, s := range vb {
+			img.viewBox[i], _ = strconv.ParseFloat(s, 64)
+		}
+	}
+	w, wok := svgLength(root.attrs["width"])
+	h, hok := svgLength(root.attrs["height"])
+	vw, vh := img.viewBox[2], img.viewBox[3]
+	switch {
+	case wok && hok:
+	case wok && vw > 0 && vh > 0:
+		h = w * vh / vw
+	case hok && vw > 0 && vh > 0:
+		w = h * vw / vh
+	case vw > 0 && vh > 0:
+		w, h = vw*mmPerPx, vh*mmPerPx
+	}
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("SVG has no size")
+	}
+	img.width, img.height = w, h
+	if vw <= 0 || vh <= 0 {
+		img.viewBox = [4]float64{0, 0, w / mmPerPx, h / mmPerPx}
+	}
+
+	p := &svgParser{img: img, classes: make(map[string]string), gradients: make(map[string]string)}
+	p.collect(root)
+	base := svgStyle{fill: "black", stroke: "none", strokeWidth: 1, opacity: 1, fillOpacity: 1, strokeOpacity: 1, transform: svgIdentity}
+	if err := p.walk(root, base); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// readSVGTree reads the elements of an XML document.
+func readSVGTree(data []byte) (*svgElement, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	var stack []*svgElement
+	var root *svgElement
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &svgElement{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, a := range t.Attr {
+				e.attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("empty SVG document")
+	}
+	return root, nil
+}
+
+type svgParser struct {
+	img *svgImage
+	// classes maps selectors of <style> rules, ".name" or element names,
+	// to their declarations.
+	classes map[string]string
+	// gradients maps gradient ids to the color of their first stop.
+	gradients map[string]string
+}
+
+// collect reads the style rules and gradients of the document.
+func (p *svgParser) collect(e *svgElement) {
+	switch e.name {
+	case "style":
+		rules := e.text
+		for {
+			selectors, rest, ok := strings.Cut(rules, "{")
+			if !ok {
+				break
+			}
+			decls, after, _ := strings.Cut(rest, "}")
+			for _, sel := range strings.Split(selectors, ",") {
+				sel = strings.TrimSpace(sel)
+				p.classes[sel] += ";" + decls
+			}
+			rules = after
+		}
+	case "linearGradient", "radialGradient":
+		for _, stop := range e.children {
+			if stop.name != "stop" {
+				continue
+			}
+			color := stop.attrs["stop-color"]
+			for _, decl := range strings.Split(stop.attrs["style"], ";") {
+				if k, v, ok := strings.Cut(decl, ":"); ok && strings.TrimSpace(k) == "stop-color" {
+					color = v
+				}
+			}
+			if color != "" {
+				p.gradients[e.attrs["id"]] = strings.TrimSpace(color)
+				break
+			}
+		}
+		if href := e.attrs["href"]; href != "" && p.gradients[e.attrs["id"]] == "" {
+			p.gradients[e.attrs["id"]] = p.gradients[strings.TrimPrefix(href, "#")]
+		}
+	}
+	for _, c := range e.children {
+		p.collect(c)
+	}
+}
+
+// properties returns the presentation properties of an element: its
+// attributes, then matching style rules, then its style attribute.
+func (p *svgParser) properties(e *svgElement) map[string]string {
+	props := make(map[string]string)
+	for _, name := range []string{"fill", "stroke", "stroke-width", "fill-rule", "opacity",
+		"fill-opacity", "stroke-opacity", "display", "visibility", "color"} {
+		if v, ok := e.attrs[name]; ok {
+			props[name] = v
+		}
+	}
+	decls := []string{p.classes[e.name]}
+	for _, class := range strings.Fields(e.attrs["class"]) {
+		decls = append(decls, p.classes["."+class], p.classes[e.name+"."+class])
+	}
+	decls = append(decls, e.attrs["style"])
+	for _, d := range decls {
+		for _, decl := range strings.Split(d, ";") {
+			if k, v, ok := strings.Cut(decl, ":"); ok {
+				props[strings.TrimSpace(k)] = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "!important"))
+			}
+		}
+	}
+	return props
+}
+
+// walk converts the shapes of an element and its children.
+func (p *svgParser) walk(e *svgElement, s svgStyle) error {
+	switch e.name {
+	case "defs", "clipPath", "mask", "pattern", "symbol", "marker", "style", "title", "desc",
+		"metadata", "linearGradient", "radialGradient", "text", "image", "use", "foreignObject":
+		return nil
+	}
+	props := p.properties(e)
+	if props["display"] == "none" {
+		return nil
+	}
+	for name, dst := range map[string]*string{"fill": &s.fill, "stroke": &s.stroke, "fill-rule": &s.fillRule, "color": &s.color} {
+		if v, ok := props[name]; ok && v != "inherit" {
+			*dst = v
+		}
+	}
+	if v, ok := props["stroke-width"]; ok {
+		if w, ok := svgNumber(v); ok {
+			s.strokeWidth = w
+		}
+	}
+	for name, dst := range map[string]*float64{"fill-opacity": &s.fillOpacity, "stroke-opacity": &s.strokeOpacity} {
+		if v, ok := svgNumber(props[name]); ok {
+			*dst = min(max(v, 0), 1)
+		}
+	}
+	if v, ok := svgNumber(props["opacity"]); ok {
+		s.opacity *= min(max(v, 0), 1)
+	}
+	if v, ok := props["visibility"]; ok {
+		s.hidden = v == "hidden" || v == "collapse"
+	}
+	if t := e.attrs["transform"]; t != "" {
+		m, err := parseSVGTransform(t)
+		if err != nil {
+			return err
+		}
+		s.transform = s.transform.mul(m)
+	}
+
+	var path []svgSegment
+	var err error
+	switch e.name {
+	case "svg", "g", "a", "switch":
+		for _, c := range e.children {
+			if err := p.walk(c, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "path":
+		path, err = parseSVGPath(e.attrs["d"])
+	case "rect":
+		path = svgRect(svgAttrs(e, "x", "y", "width", "height", "rx", "ry"))
+	case "circle":
+		a := svgAttrs(e, "cx", "cy", "r")
+		path = svgEllipse(a[0], a[1], a[2], a[2])
+	case "ellipse":
+		a := svgAttrs(e, "cx", "cy", "rx", "ry")
+		path = svgEllipse(a[0], a[1], a[2], a[3])
+	case "line":
+		a := svgAttrs(e, "x1", "y1", "x2", "y2")
+		path = []svgSegment{{cmd: 'M', pts: [3]svgPoint{{a[0], a[1]}}}, {cmd: 'L', pts: [3]svgPoint{{a[2], a[3]}}}}
+	case "polyline", "polygon":
+		path, err = parseSVGPath("M" + e.attrs["points"])
+		if err == nil && e.name == "polygon" && len(path) > 0 {
+			path = append(path, svgSegment{cmd: 'Z'})
+		}
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", e.name, err)
+	}
+	if len(path) == 0 || s.hidden {
+		return nil
+	}
+	for i := range path {
+		for j := range path[i].pts {
+			path[i].pts[j] = s.transform.apply(path[i].pts[j])
+		}
+	}
+	shape := svgShape{
+		path:          path,
+		fill:          p.paint(s.fill, s.color),
+		stroke:        p.paint(s.stroke, s.color),
+		strokeWidth:   s.strokeWidth * s.transform.scale(),
+		fillOpacity:   s.fillOpacity * s.opacity,
+		strokeOpacity: s.strokeOpacity * s.opacity,
+		evenOdd:       s.fillRule == "evenodd",
+	}
+	if e.name == "line" || e.name == "polyline" {
+		shape.fill = nil
+	}
+	if shape.fill != nil || shape.stroke != nil {
+		p.img.shapes = append(p.img.shapes, shape)
+	}
+	return nil
+}
+
+// paint returns the color of a fill or stroke, or nil for none.
+func (p *svgParser) paint(v, current string) *rgb {
+	v = strings.TrimSpace(v)
+	if v == "currentColor" {
+		v = current
+	}
+	if strings.HasPrefix(v, "url(") {
+		id, _, _ := strings.Cut(strings.TrimPrefix(v, "url("), ")")
+		v = p.gradients[strings.Trim(strings.TrimPrefix(strings.TrimSpace(id), "#"), `'"`)]
+	}
+	if v == "" || v == "none" || v == "transparent" {
+		return nil
+	}
+	// Colors that cannot be read are drawn black, the initial value.
+	c, _ := parseColor(v)
+	return &c
+}
+
+// svgAttrs returns numeric attributes of an element, 0 if missing.
+func svgAttrs(e *svgElement, names ...string) []float64 {
+	out := make([]float64, len(names))
+	for i, name := range names {
+		out[i], _ = svgNumber(e.attrs[name])
+	}
+	return out
+}
+
+// svgNumber parses a number, ignoring a px unit.
+func svgNumber(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "px"), 64)
+	return v, err == nil
+}
+
+// svgLength parses the width or height of an SVG document in mm.
+// Percentages are treated as missing.
+func svgLength(s string) (float64, bool) {
+	if v, ok := svgNumber(s); ok {
+		return v * mmPerPx, v > 0
+	}
+	if strings.HasSuffix(strings.TrimSpace(s), "%") {
+		return 0, false
+	}
+	l, ok := parseLength(s, 12, 12)
+	return l.value, ok && !l.percent && l.value > 0
+}
+
+func isSVGSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// svgRect returns the path of a rectangle with optionally rounded corners.
+func svgRect(a []float64) []svgSegment {
+	x, y, w, h, rx, ry := a[0], a[1], a[2], a[3], a[4], a[5]
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	if rx <= 0 {
+		rx = ry
+	}
+	if ry <= 0 {
+		ry = rx
+	}
+	rx, ry = min(rx, w/2), min(ry, h/2)
+	if rx <= 0 {
+		return []svgSegment{
+			{cmd: 'M', pts: [3]svgPoint{{x, y}}}, {cmd: 'L', pts: [3]svgPoint{{x + w, y}}},
+			{cmd: 'L', pts: [3]svgPoint{{x + w, y + h}}}, {cmd: 'L', pts: [3]svgPoint{{x, y + h}}}, {cmd: 'Z'},
+		}
+	}
+	const k = 0.5522847498
+	kx, ky := k*rx, k*ry
+	return []svgSegment{
+		{cmd: 'M', pts: [3]svgPoint{{x + rx, y}}},
+		{cmd: 'L', pts: [3]svgPoint{{x + w - rx, y}}},
+		{cmd: 'C', pts: [3]svgPoint{{x + w - rx + kx, y}, {x + w, y + ry - ky}, {x + w, y + ry}}},
+		{cmd: 'L', pts: [3]svgPoint{{x + w, y + h - ry}}},
+		{cmd: 'C', pts: [3]svgPoint{{x + w, y + h - ry + ky}, {x + w - rx + kx, y + h}, {x + w - rx, y + h}}},
+		{cmd: 'L', pts: [3]svgPoint{{x + rx, y + h}}},
+		{cmd: 'C', pts: [3]svgPoint{{x + rx - kx, y + h}, {x, y + h - ry + ky}, {x, y + h - ry}}},
+		{cmd: 'L', pts: [3]svgPoint{{x, y + ry}}},
+		{cmd: 'C', pts: [3]svgPoint{{x, y + ry - ky}, {x + rx - kx, y}, {x + rx, y}}},
+		{cmd: 'Z'},
+	}
+}
+
+// svgEllipse returns the path of an ellipse as four cubic curves.
+func svgEllipse(cx, cy, rx, ry float64) []svgSegment {
+	if rx <= 0 || ry <= 0 {
+		return nil
+	}
+	const k = 0.5522847498
+	kx, ky := k*rx, k*ry
+	return []svgSegment{
+		{cmd: 'M', pts: [3]svgPoint{{cx + rx, cy}}},
+		{cmd: 'C', pts: [3]svgPoint{{cx + rx, cy + ky}, {cx + kx, cy + ry}, {cx, cy + ry}}},
+		{cmd: 'C', pts: [3]svgPoint{{cx - kx, cy + ry}, {cx - rx, cy + ky}, {cx - rx, cy}}},
+		{cmd: 'C', pts: [3]svgPoint{{cx - rx, cy - ky}, {cx - kx, cy - ry}, {cx, cy - ry}}},
+		{cmd: 'C', pts: [3]svgPoint{{cx + kx, cy - ry}, {cx + rx, cy - ky}, {cx + rx, cy}}},
+		{cmd: 'Z'},
+	}
+}
+
+// parseSVGTransform parses a transform list.
+func parseSVGTransform(s string) (svgMatrix, error) {
+	m := svgIdentity
+	for rest := strings.TrimSpace(s); rest != ""; rest = strings.TrimLeft(rest, ", \t\n\r") {
+		name, args, ok := strings.Cut(rest, "(")
+		if !ok {
+			return m, fmt.Errorf("bad transform %q", s)
+		}
+		args, rest, ok = strings.Cut(args, ")")
+		if !ok {
+			return m, fmt.Errorf("bad transform %q", s)
+		}
+		var v []float64
+		for _, f := range strings.FieldsFunc(args, isSVGSeparator) {
+			n, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return m, fmt.Errorf("bad transform %q", s)
+			}
+			v = append(v, n)
+		}
+		arg := func(i int, def float64) float64 {
+			if i < len(v) {
+				return v[i]
+			}
+			return def
+		}
+		var t svgMatrix
+		switch strings.TrimSpace(name) {
+		case "matrix":
+			if len(v) != 6 {
+				return m, fmt.Errorf("bad transform %q", s)
+			}
+			copy(t[:], v)
+		case "translate":
+			t = svgMatrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = svgMatrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = svgMatrix{1, 0, 0, 1, cx, cy}.
+				mul(svgMatrix{math.Cos(a), math.Sin(a), -math.Sin(a), math.Cos(a), 0, 0}).
+				mul(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = svgMatrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = svgMatrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m, fmt.Errorf("bad transform %q", s)
+		}
+		m = m.mul(t)
+	}
+	return m, nil
+}
+
+// svgPathArgs is the number of arguments of each path command.
+var svgPathArgs = map[byte]int{'M': 2, 'L': 2, 'H': 1, 'V': 1, 'C': 6, 'S': 4, 'Q': 4, 'T': 2, 'A': 7, 'Z': 0}
+
+// parseSVGPath parses path data into absolute moves, lines and cubic
+// curves.
+func parseSVGPath(d string) ([]svgSegment, error) {
+	var out []svgSegment
+	var cur, start, ctrl svgPoint
+	var prev byte
+	i := 0
+	skip := func() {
+		for i < len(d) && (d[i] == ' ' || d[i] == ',' || d[i] == '\t' || d[i] == '\n' || d[i] == '\r') {
+			i++
+		}
+	}
+	number := func(flag bool) (float64, error) {
+		skip()
+		j := i
+		if flag && j < len(d) && (d[j] == '0' || d[j] == '1') {
+			i++
+			return float64(d[j] - '0'), nil
+		}
+		if j < len(d) && (d[j] == '+' || d[j] == '-') {
+			j++
+		}
+		dot, digits := false, false
+		for ; j < len(d); j++ {
+			c := d[j]
+			switch {
+			case c >= '0' && c <= '9':
+				digits = true
+			case c == '.' && !dot:
+				dot = true
+			case (c == 'e' || c == 'E') && digits && j+1 < len(d) && (d[j+1] == '-' || d[j+1] == '+' || d[j+1] >= '0' && d[j+1] <= '9'):
+				j++
+				for j+1 < len(d) && d[j+1] >= '0' && d[j+1] <= '9' {
+					j++
+				}
+			default:
+				goto done
+			}
+		}
+	done:
+		v, err := strconv.ParseFloat(d[i:j], 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad number in path at %d", i)
+		}
+		i = j
+		return v, nil
+	}
+
+	var cmd byte
+	for {
+		skip()
+		if i >= len(d) {
+			break
+		}
+		if c := d[i]; c >= 'A' && c <= 'z' && c != 'e' && c != 'E' {
+			cmd = c
+			i++
+		} else if cmd == 0 {
+			return nil, fmt.Errorf("path must start with a command")
+		} else if cmd == 'M' {
+			cmd = 'L'
+		} else if cmd == 'm' {
+			cmd = 'l'
+		}
+		upper := cmd &^ 0x20
+		n, ok := svgPathArgs[upper]
+		if !ok {
+			return nil, fmt.Errorf("unknown path command %q", cmd)
+		}
+		var a [7]float64
+		for k := range n {
+			v, err := number(upper == 'A' && (k == 3 || k == 4))
+			if err != nil {
+				return nil, err
+			}
+			a[k] = v
+		}
+		rel := cmd != upper
+		pt := func(x, y float64) svgPoint {
+			if rel {
+				return svgPoint{cur.x + x, cur.y + y}
+			}
+			return svgPoint{x, y}
+		}
+		switch upper {
+		case 'M':
+			cur = pt(a[0], a[1])
+			start = cur
+			out = append(out, svgSegment{cmd: 'M', pts: [3]svgPoint{cur}})
+		case 'L', 'H', 'V':
+			next := pt(a[0], a[1])
+			if upper == 'H' {
+				next = svgPoint{a[0], cur.y}
+				if rel {
+					next.x += cur.x
+				}
+			} else if upper == 'V' {
+				next = svgPoint{cur.x, a[0]}
+				if rel {
+					next.y += cur.y
+				}
+			}
+			cur = next
+			out = append(out, svgSegment{cmd: 'L', pts: [3]svgPoint{cur}})
+		case 'C', 'S':
+			var c1 svgPoint
+			k := 0
+			if upper == 'C' {
+				c1 = pt(a[0], a[1])
+				k = 2
+			} else if p := prev &^ 0x20; p == 'C' || p == 'S' {
+				c1 = svgPoint{2*cur.x - ctrl.x, 2*cur.y - ctrl.y}
+			} else {
+				c1 = cur
+			}
+			c2, end := pt(a[k], a[k+1]), pt(a[k+2], a[k+3])
+			out = append(out, svgSegment{cmd: 'C', pts: [3]svgPoint{c1, c2, end}})
+			ctrl, cur = c2, end
+		case 'Q', 'T':
+			var q svgPoint
+			var end svgPoint
+			if upper == 'Q' {
+				q, end = pt(a[0], a[1]), pt(a[2], a[3])
+			} else {
+				if p := prev &^ 0x20; p == 'Q' || p == 'T' {
+					q = svgPoint{2*cur.x - ctrl.x, 2*cur.y - ctrl.y}
+				} else {
+					q = cur
+				}
+				end = pt(a[0], a[1])
+			}
+			out = append(out, svgSegment{cmd: 'C', pts: [3]svgPoint{
+				{cur.x + 2.0/3*(q.x-cur.x), cur.y + 2.0/3*(q.y-cur.y)},
+				{end.x + 2.0/3*(q.x-end.x), end.y + 2.0/3*(q.y-end.y)},
+				end,
+			}})
+			ctrl, cur = q, end
+		case 'A':
+			end := pt(a[5], a[6])
+			out = append(out, svgArc(cur, end, a[0], a[1], a[2], a[3] != 0, a[4] != 0)...)
+			cur = end
+		case 'Z':
+			out = append(out, svgSegment{cmd: 'Z'})
+			cur = start
+		}
+		if len(out) > 0 && out[0].cmd != 'M' {
+			return nil, fmt.Errorf("path must start with a move")
+		}
+		prev = cmd
+	}
+	return out, nil
+}
+
+// svgArc converts an elliptical arc to cubic curves, following the
+// endpoint to center conversion of the SVG specification.
+func svgArc(from, to svgPoint, rx, ry, angle float64, large, sweep bool) []svgSegment {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || from == to {
+		return []svgSegment{{cmd: 'L', pts: [3]svgPoint{to}}}
+	}
+	phi := angle * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (from.x-to.x)/2, (from.y-to.y)/2
+	x1 := cos*dx + sin*dy
+	y1 := -sin*dx + cos*dy
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(num, 0) / den)
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (from.x+to.x)/2
+	cy := sin*cx1 + cos*cy1 + (from.y+to.y)/2
+	vecAngle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := vecAngle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := vecAngle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(n)
+	k := 4.0 / 3 * math.Tan(step/4)
+	point := func(t float64) (svgPoint, svgPoint) {
+		ex, ey := rx*math.Cos(t), ry*math.Sin(t)
+		tx, ty := -rx*math.Sin(t), ry*math.Cos(t)
+		return svgPoint{cos*ex - sin*ey + cx, sin*ex + cos*ey + cy}, svgPoint{cos*tx - sin*ty, sin*tx + cos*ty}
+	}
+	out := make([]svgSegment, 0, n)
+	for i := range n {
+		t1, t2 := theta+float64(i)*step, theta+float64(i+1)*step
+		p1, d1 := point(t1)
+		p2, d2 := point(t2)
+		if i == n-1 {
+			p2 = to
+		}
+		out = append(out, svgSegment{cmd: 'C', pts: [3]svgPoint{
+			{p1.x + k*d1.x, p1.y + k*d1.y}, {p2.x - k*d2.x, p2.y - k*d2.y}, p2,
+		}})
+	}
+	return out
+}
+
+// draw draws the image into a rectangle, keeping its aspect ratio and
+// centering it as preserveAspectRatio="xMidYMid meet" does.
+func (img *svgImage) draw(pdf *fpdf.Fpdf, x, y, w, h float64) {
+	vb := img.viewBox
+	scale := min(w/vb[2], h/vb[3])
+	ox := x + (w-vb[2]*scale)/2 - vb[0]*scale
+	oy := y + (h-vb[3]*scale)/2 - vb[1]*scale
+	at := func(p svgPoint) (float64, float64) {
+		return ox + p.x*scale, oy + p.y*scale
+	}
+
+	dr, dg, db := pdf.GetDrawColor()
+	fr, fg, fb := pdf.GetFillColor()
+	lw := pdf.GetLineWidth()
+	alpha, blend := pdf.GetAlpha()
+	current := alpha
+	for _, s := range img.shapes {
+		type paintOp struct {
+			style string
+			alpha float64
+		}
+		fill := "F"
+		if s.evenOdd {
+			fill = "F*"
+		}
+		var ops []paintOp
+		if s.fill != nil && s.stroke != nil && s.fillOpacity == s.strokeOpacity {
+			ops = append(ops, paintOp{strings.Replace(fill, "F", "FD", 1), s.fillOpacity})
+		} else {
+			if s.fill != nil {
+				ops = append(ops, paintOp{fill, s.fillOpacity})
+			}
+			if s.stroke != nil {
+				ops = append(ops, paintOp{"D", s.strokeOpacity})
+			}
+		}
+		if s.fill != nil {
+			pdf.SetFillColor(s.fill.r, s.fill.g, s.fill.b)
+		}
+		if s.stroke != nil {
+			pdf.SetDrawColor(s.stroke.r, s.stroke.g, s.stroke.b)
+			pdf.SetLineWidth(s.strokeWidth * scale)
+		}
+		for _, op := range ops {
+			if op.alpha <= 0 {
+				continue
+			}
+			if op.alpha != current {
+				pdf.SetAlpha(op.alpha, "Normal")
+				current = op.alpha
+			}
+			for _, seg := range s.path {
+				switch seg.cmd {
+				case 'M':
+					pdf.MoveTo(at(seg.pts[0]))
+				case 'L':
+					pdf.LineTo(at(seg.pts[0]))
+				case 'C':
+					x1, y1 := at(seg.pts[0])
+					x2, y2 := at(seg.pts[1])
+					x3, y3 := at(seg.pts[2])
+					pdf.CurveBezierCubicTo(x1, y1, x2, y2, x3, y3)
+				case 'Z':
+					pdf.ClosePath()
+				}
+			}
+			pdf.DrawPath(op.style)
+		}
+	}
+	if current != alpha {
+		pdf.SetAlpha(alpha, blend)
+	}
+	pdf.SetDrawColor(dr, dg, db)
+	pdf.SetFillColor(fr, fg, fb)
+	pdf.SetLineWidth(lw)
+}
@@ -0,0 +1,286 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/wiederin/go-invoicer/internal/pdfupdate"
+)
+
+// structElem is an element of the logical structure of a tagged PDF, such
+// as a heading, a paragraph or a table cell.
+type structElem struct {
+	tag    string
+	parent *structElem
+	kids   []structKid
+	// alt is the alternative text of figures.
+	alt string
+	// scope is "Row" or "Column" for table header cells.
+	scope string
+}
+
+// structKid is a child element or a marked-content sequence on a page,
+// numbered from 0.
+type structKid struct {
+	elem       *structElem
+	page, mcid int
+}
+
+// add appends a child element. It returns nil for a nil element, so that
+// untagged documents need no checks.
+func (e *structElem) add(tag string) *structElem {
+	if e == nil {
+		return nil
+	}
+	child := &structElem{tag: tag, parent: e}
+	e.kids = append(e.kids, structKid{elem: child})
+	return child
+}
+
+// hasContent returns true if the element or one of its descendants owns
+// marked content.
+func (e *structElem) hasContent() bool {
+	for _, k := range e.kids {
+		if k.elem == nil || k.elem.hasContent() {
+			return true
+		}
+	}
+	return false
+}
+
+// structTree records the structure of a tagged PDF while its pages are
+// drawn. Content is wrapped in marked-content sequences that belong to an
+// element or are artifacts, such as page furniture and decoration. The
+// methods do nothing on a nil tree.
+type structTree struct {
+	root *structElem
+	// current is the element new elements of the document are added to.
+	current *structElem
+	// pages holds the owners of the marked content of each page by MCID.
+	pages [][]*structElem
+	// open is the element of the open sequence, or nil for an artifact,
+	// and depth the number of sequences begun within it.
+	open  *structElem
+	depth int
+	// suspended is the depth of a sequence interrupted by a page break.
+	suspended int
+}
+
+func newStructTree() *structTree {
+	root := &structElem{tag: "Document"}
+	return &structTree{root: root, current: root}
+}
+
+// begin starts a marked-content sequence for the content of an element,
+// or an artifact if elem is nil. Sequences do not nest: content begun
+// within a sequence is part of it.
+func (t *structTree) begin(pdf *fpdf.Fpdf, elem *structElem) {
+	if t == nil {
+		return
+	}
+	t.depth++
+	if t.depth > 1 {
+		return
+	}
+	t.open = elem
+	if elem == nil {
+		pdf.RawWriteStr("/Artifact BMC")
+		return
+	}
+	page := pdf.PageNo() - 1
+	for len(t.pages) <= page {
+		t.pages = append(t.pages, nil)
+	}
+	mcid := len(t.pages[page])
+	t.pages[page] = append(t.pages[page], elem)
+	elem.kids = append(elem.kids, structKid{page: page, mcid: mcid})
+	pdf.RawWriteStr(fmt.Sprintf("/%s <</MCID %d>> BDC", elem.tag, mcid))
+}
+
+// end ends the sequence started by the matching call of begin.
+func (t *structTree) end(pdf *fpdf.Fpdf) {
+	if t == nil {
+		return
+	}
+	t.depth--
+	if t.depth == 0 {
+		pdf.RawWriteStr("EMC")
+	}
+}
+
+// suspend ends the open sequence at the end of a page. The page footer
+// may then begin artifacts.
+func (t *structTree) suspend(pdf *fpdf.Fpdf) {
+	if t == nil || t.depth == 0 {
+		return
+	}
+	pdf.RawWriteStr("EMC")
+	t.suspended, t.depth = t.depth, 0
+}
+
+// resume continues the sequence suspended at the end of the previous page.
+func (t *structTree) resume(pdf *fpdf.Fpdf) {
+	if t == nil || t.suspended == 0 {
+		return
+	}
+	depth := t.suspended
+	t.suspended = 0
+	t.begin(pdf, t.open)
+	t.depth = depth
+}
+
+// tag groups the content drawn by f in a structure element, if the
+// document is tagged.
+func (d *document) tag(tag string, f func()) {
+	if d.tags == nil {
+		f()
+		return
+	}
+	parent := d.tags.current
+	d.tags.current = parent.add(tag)
+	f()
+	d.tags.current = parent
+}
+
+// mark tags the content drawn by f as a structure element without
+// children, such as a paragraph or a table cell.
+func (d *document) mark(tag string, f func()) {
+	var elem *structElem
+	if d.tags != nil {
+		elem = d.tags.current.add(tag)
+	}
+	d.tags.begin(d.Fpdf, elem)
+	f()
+	d.tags.end(d.Fpdf)
+}
+
+// artifact marks the content drawn by f as an artifact.
+func (d *document) artifact(f func()) {
+	d.tags.begin(d.Fpdf, nil)
+	f()
+	d.tags.end(d.Fpdf)
+}
+
+// structTag returns the structure type of the content of an HTML element,
+// or "" if its content belongs to the parent element.
+func structTag(tag string, s *style) string {
+	switch s.display {
+	case "table", "inline-table":
+		return "Table"
+	case "table-header-group":
+		return "THead"
+	case "table-row-group":
+		return "TBody"
+	case "table-footer-group":
+		return "TFoot"
+	case "table-row":
+		return "TR"
+	case "table-cell":
+		if tag == "th" {
+			return "TH"
+		}
+		return "TD"
+	case "list-item":
+		return "LI"
+	case "inline", "inline-block":
+		return ""
+	}
+	switch tag {
+	case "html", "body":
+		return ""
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return strings.ToUpper(tag)
+	case "p", "pre", "address":
+		return "P"
+	case "ul", "ol", "dl":
+		return "L"
+	case "blockquote":
+		return "BlockQuote"
+	case "caption":
+		return "Caption"
+	case "section", "article":
+		return "Sect"
+	}
+	return "Div"
+}
+
+// write adds the structure tree to a PDF and the structure parents to its
+// pages. It returns the entries that mark the catalog as tagged.
+func (t *structTree) write(u *pdfupdate.Update) (string, error) {
+	pages, err := u.Pages()
+	if err != nil {
+		return "", err
+	}
+	if len(t.pages) > len(pages) {
+		return "", fmt.Errorf("%w: marked content on %d pages of %d", pdfupdate.ErrMalformed, len(t.pages), len(pages))
+	}
+
+	rootNum := u.Reserve()
+	nums := make(map[*structElem]int)
+	var number func(e *structElem)
+	number = func(e *structElem) {
+		nums[e] = u.Reserve()
+		for _, k := range e.kids {
+			if k.elem != nil && k.elem.hasContent() {
+				number(k.elem)
+			}
+		}
+	}
+	number(t.root)
+
+	for e, n := range nums {
+		parent := rootNum
+		if e.parent != nil {
+			parent = nums[e.parent]
+		}
+		var body strings.Builder
+		fmt.Fprintf(&body, "<< /Type /StructElem /S /%s /P %d 0 R /K [", e.tag, parent)
+		for _, k := range e.kids {
+			switch {
+			case k.elem == nil:
+				fmt.Fprintf(&body, "<< /Type /MCR /Pg %d 0 R /MCID %d >> ", pages[k.page], k.mcid)
+			case nums[k.elem] != 0:
+				fmt.Fprintf(&body, "%d 0 R ", nums[k.elem])
+			}
+		}
+		body.WriteString("]")
+		if e.tag == "Figure" {
+			fmt.Fprintf(&body, " /Alt %s", pdfupdate.Text(e.alt))
+		}
+		if e.tag == "TH" {
+			scope := e.scope
+			if scope == "" {
+				scope = "Column"
+			}
+			fmt.Fprintf(&body, " /A << /O /Table /Scope /%s >>", scope)
+		}
+		body.WriteString(" >>")
+		u.Set(n, body.String())
+	}
+
+	var parentTree strings.Builder
+	parentTree.WriteString("<< /Nums [")
+	for i, page := range pages {
+		body, err := u.Extend(page, fmt.Sprintf("/StructParents %d /Tabs /S", i))
+		if err != nil {
+			return "", err
+		}
+		u.Set(page, body)
+		fmt.Fprintf(&parentTree, "%d [", i)
+		if i < len(t.pages) {
+			for _, e := range t.pages[i] {
+				fmt.Fprintf(&parentTree, "%d 0 R ", nums[e])
+			}
+		}
+		parentTree.WriteString("] ")
+	}
+	parentTree.WriteString("] >>")
+	parentTreeNum := u.Reserve()
+	u.Set(parentTreeNum, parentTree.String())
+	u.Set(rootNum, fmt.Sprintf("<< /Type /StructTreeRoot /K %d 0 R /ParentTree %d 0 R /ParentTreeNextKey %d >>",
+		nums[t.root], parentTreeNum, len(pages)))
+
+	return fmt.Sprintf(" /MarkInfo << /Marked true >> /StructTreeRoot %d 0 R", rootNum), nil
+}
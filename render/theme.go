@@ -0,0 +1,257 @@
+package render
+
+// Color is an RGB color with components from 0 to 255.
+type Color struct {
+	R, G, B int
+}
+
+func (c Color) rgb() (int, int, int) {
+	return c.R, c.G, c.B
+}
+
+// Block is a part of the invoice layout.
+type Block string
+
+// Invoice blocks.
+const (
+	BlockTitle    Block = "title"
+	BlockParties  Block = "parties"
+	BlockItems    Block = "items"
+	BlockTotals   Block = "totals"
+	BlockSchedule Block = "schedule"
+	BlockNotes    Block = "notes"
+)
+
+// Theme is the design of documents rendered by the SimpleRenderer. Widths
+// and positions follow from the page size and margins.
+type Theme struct {
+	// FontFamily is the family of the text and HeadingFontFamily that of
+	// titles. If empty, Options.FontFamily is used.
+	FontFamily        string
+	HeadingFontFamily string
+
+	// Font sizes are in points.
+	TitleSize float64
+	TextSize  float64
+	TableSize float64
+	TotalSize float64
+
+	TitleColor       Color
+	HeadingColor     Color
+	TextColor        Color
+	TableHeaderColor Color
+	TableHeaderFill  Color
+	BorderColor      Color
+	// Rules draws horizontal rules between table rows instead of a grid.
+	Rules bool
+
+	// LineSpacing is the height of lines as a multiple of the font size.
+	LineSpacing float64
+	// CellPadding is the space above and below the text of table cells
+	// in mm.
+	CellPadding float64
+	// SectionSpacing is the space between blocks in mm.
+	SectionSpacing float64
+	// TotalsWidth is the width of the totals in mm, at most the width
+	// between the margins.
+	TotalsWidth float64
+
+	Columns []Column
+	// Layout is the order of the blocks of invoices.
+	Layout []Block
+}
+
+// ClassicTheme returns the default theme: black text and a grid with a
+// grey header row.
+func ClassicTheme() Theme {
+	return Theme{
+		TitleSize:       24,
+		TextSize:        10,
+		TableSize:       9,
+		TotalSize:       11,
+		TableHeaderFill: Color{240, 240, 240},
+		LineSpacing:     1.5,
+		CellPadding:     1.25,
+		SectionSpacing:  10,
+		TotalsWidth:     70,
+		Columns: []Column{
+			ColumnFor(FieldDescription),
+			ColumnFor(FieldQuantity),
+			ColumnFor(FieldUnitPrice),
+			ColumnFor(FieldTaxRate),
+			ColumnFor(FieldAmount),
+		},
+		Layout: []Block{BlockTitle, BlockParties, BlockItems, BlockTotals, BlockSchedule, BlockNotes},
+	}
+}
+
+// ModernTheme returns a theme with blue accents and ruled tables, matching
+// the default HTML templates.
+func ModernTheme() Theme {
+	t := ClassicTheme()
+	accent := Color{59, 130, 246}
+	t.TitleColor = accent
+	t.HeadingColor = accent
+	t.TextColor = Color{51, 51, 51}
+	t.TableHeaderColor = Color{255, 255, 255}
+	t.TableHeaderFill = accent
+	t.BorderColor = Color{229, 231, 235}
+	t.Rules = true
+	t.TitleSize = 28
+	return t
+}
+
+// CompactTheme returns a theme with small type and little spacing, which
+// fits long invoices on fewer pages.
+func CompactTheme() Theme {
+	t := ClassicTheme()
+	t.TitleSize = 16
+	t.TextSize = 8
+	t.TableSize = 7.5
+	t.TotalSize = 9
+	t.LineSpacing = 1.3
+	t.CellPadding = 0.6
+	t.SectionSpacing = 5
+	t.TotalsWidth = 60
+	t.Rules = true
+	t.BorderColor = Color{200, 200, 200}
+	return t
+}
+
+// line returns the height of a line of text in the given size.
+func (t Theme) line(size float64) float64 {
+	return size * mmPerPt * t.LineSpacing
+}
+
+// contentWidth returns the width between the margins.
+func (d *document) contentWidth() float64 {
+	pageWidth, _ := d.GetPageSize()
+	left, _, right, _ := d.GetMargins()
+	return pageWidth - left - right
+}
+
+// columnWidths divides the width between the margins into columns in
+// proportion to their relative widths, or evenly if they are all zero.
+func (d *document) columnWidths(relative ...float64) []float64 {
+	total := 0.0
+	for _, w := range relative {
+		total += w
+	}
+	widths := make([]float64, len(relative))
+	for i, w := range relative {
+		if total > 0 {
+			widths[i] = d.contentWidth() * w / total
+		} else {
+			widths[i] = d.contentWidth() / float64(len(relative))
+		}
+	}
+	return widths
+}
+
+type rowStyle int
+
+const (
+	bodyRow rowStyle = iota
+	headerRow
+	totalRow
+)
+
+// tableRow is a row of a table with the text of its cells broken into
+// lines.
+type tableRow struct {
+	style  rowStyle
+	lines  [][]string
+	height float64
+}
+
+// tableFont sets the font and text color of a table row.
+func (d *document) tableFont(style rowStyle) {
+	switch style {
+	case headerRow:
+		d.setFont("B", d.theme.TableSize)
+		d.SetTextColor(d.theme.TableHeaderColor.rgb())
+	case totalRow:
+		d.setFont("B", d.theme.TableSize)
+		d.SetTextColor(d.theme.TextColor.rgb())
+	default:
+		d.setFont("", d.theme.TableSize)
+		d.SetTextColor(d.theme.TextColor.rgb())
+	}
+}
+
+// row breaks the texts of a table row into lines that fit the columns.
+func (d *document) row(widths []float64, texts []string, style rowStyle) tableRow {
+	d.tableFont(style)
+	row := tableRow{style: style, lines: make([][]string, len(texts))}
+	n := 1
+	for i, text := range texts {
+		row.lines[i] = d.splitText(text, widths[i]-2*d.GetCellMargin())
+		n = max(n, len(row.lines[i]))
+	}
+	row.height = float64(n)*d.theme.line(d.theme.TableSize) + 2*d.theme.CellPadding
+	return row
+}
+
+// drawRow draws a table row at the current position, with the lines of
+// each cell centred vertically, and moves below it. In tagged documents
+// the cells of header rows are header cells and the grid is an artifact.
+func (d *document) drawRow(widths []float64, aligns []string, row tableRow) {
+	t := d.theme
+	x, y := d.GetXY()
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	d.artifact(func() {
+		if row.style == headerRow {
+			d.SetFillColor(t.TableHeaderFill.rgb())
+			d.Rect(x, y, total, row.height, "F")
+		}
+		cx := x
+		for _, w := range widths {
+			if !t.Rules {
+				d.Rect(cx, y, w, row.height, "D")
+			}
+			cx += w
+		}
+		if t.Rules {
+			d.Line(x, y+row.height, x+total, y+row.height)
+		}
+	})
+
+	cellTag := "TD"
+	if row.style == headerRow {
+		cellTag = "TH"
+	}
+	d.tableFont(row.style)
+	lh := t.line(t.TableSize)
+	cx := x
+	d.tag("TR", func() {
+		for i, w := range widths {
+			top := y + (row.height-float64(len(row.lines[i]))*lh)/2
+			d.mark(cellTag, func() {
+				for j, line := range row.lines[i] {
+					d.SetXY(cx, top+float64(j)*lh)
+					d.CellFormat(w, lh, line, "", 0, aligns[i], false, 0, "")
+				}
+			})
+			cx += w
+		}
+	})
+	d.SetTextColor(t.TextColor.rgb())
+	d.SetXY(x, y+row.height)
+}
+
+// totalsRow draws a label and an amount at the right margin.
+func (d *document) totalsRow(label, value, style string, size float64) {
+	left, _, _, _ := d.GetMargins()
+	w := min(d.theme.TotalsWidth, d.contentWidth())
+	h := d.theme.line(size)
+	d.SetX(left + d.contentWidth() - w)
+	d.setFont(style, size)
+	d.mark("P", func() {
+		d.CellFormat(w*4/7, h, label, "", 0, "", false, 0, "")
+		d.CellFormat(w*3/7, h, value, "", 0, "R", false, 0, "")
+	})
+	d.Ln(h)
+}
@@ -0,0 +1,141 @@
+package render
+
+import (
+	"math"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// Watermark is text printed diagonally across every page.
+type Watermark struct {
+	// Text replaces the text for the invoice status.
+	Text string
+	// NoStatus disables the watermarks and stamps for the invoice status.
+	NoStatus bool
+	Color    Color
+	// Opacity is between 0 and 1, 0.15 by default.
+	Opacity float64
+	// Angle is the rotation in degrees counterclockwise. If it is nil,
+	// the text runs along the diagonal of the page.
+	Angle *float64
+	// FontSize is in points. If it is zero, the text spans two thirds of
+	// the diagonal of the page.
+	FontSize float64
+}
+
+// paidColor is the color of PAID stamps.
+var paidColor = Color{22, 128, 61}
+
+// watermarkLines returns the lines of the watermark of an invoice: the
+// text of the options or the status, followed by the copy mark.
+func watermarkLines(opts Options, inv *invoice.Invoice) []string {
+	var lines []string
+	switch {
+	case opts.Watermark.Text != "":
+		lines = append(lines, opts.Watermark.Text)
+	case inv == nil || opts.Watermark.NoStatus:
+	case inv.Status == invoice.StatusDraft:
+		lines = append(lines, "DRAFT")
+	case inv.Status == invoice.StatusCancelled:
+		lines = append(lines, "CANCELLED")
+	}
+	if opts.Copy != "" {
+		lines = append(lines, opts.Copy)
+	}
+	return lines
+}
+
+// stampLines returns the lines of the stamp on the first page of an
+// invoice: PAID with the payment date for paid invoices.
+func stampLines(opts Options, inv *invoice.Invoice) []string {
+	if inv == nil || opts.Watermark.NoStatus || inv.Status != invoice.StatusPaid {
+		return nil
+	}
+	if inv.PaidDate.IsZero() {
+		return []string{"PAID"}
+	}
+	return []string{"PAID", inv.PaidDate.Format("2006-01-02")}
+}
+
+// drawWatermark draws the watermark across a page, numbered from 1, and
+// the stamp on the first page.
+func (s *pageSections) drawWatermark(pdf *fpdf.Fpdf, page int) {
+	x, y := pdf.GetXY()
+	defer pdf.SetXY(x, y)
+	pageWidth, pageHeight := pdf.GetPageSize()
+
+	if len(s.watermark) > 0 {
+		w := s.opts.Watermark
+		angle := math.Atan2(pageHeight, pageWidth) * 180 / math.Pi
+		if w.Angle != nil {
+			angle = *w.Angle
+		}
+		size := w.FontSize
+		if size == 0 {
+			// Fit the longest line into two thirds of the diagonal.
+			longest := ""
+			for _, line := range s.watermark {
+				if len([]rune(line)) > len([]rune(longest)) {
+					longest = line
+				}
+			}
+			family := s.fonts.pickText(s.fonts.primary, longest)
+			pdf.SetFont(family, s.fonts.style(family, "B"), 100)
+			size = 100 * math.Hypot(pageWidth, pageHeight) * 2 / 3 / pdf.GetStringWidth(s.fonts.encode(family, longest))
+			size = min(size, 150)
+		}
+		opacity := w.Opacity
+		if opacity == 0 {
+			opacity = 0.15
+		}
+		s.drawRotated(pdf, s.watermark, pageWidth/2, pageHeight/2, angle, size, opacity, w.Color, false)
+	}
+	if page == 1 && len(s.stamp) > 0 {
+		s.drawRotated(pdf, s.stamp, pageWidth*0.7, pageHeight*0.3, 15, 28, 0.6, paidColor, true)
+	}
+}
+
+// drawRotated draws lines of bold text centred on a point and rotated
+// around it, with a border if framed.
+func (s *pageSections) drawRotated(pdf *fpdf.Fpdf, lines []string, cx, cy, angle, size, opacity float64, c Color, framed bool) {
+	pdf.TransformBegin()
+	pdf.TransformRotate(angle, cx, cy)
+	pdf.SetAlpha(opacity, "Normal")
+	pdf.SetTextColor(c.rgb())
+	pdf.SetDrawColor(c.rgb())
+
+	// Lines after the first are half the size.
+	sizes := make([]float64, len(lines))
+	height := 0.0
+	for i := range lines {
+		sizes[i] = size
+		if i > 0 {
+			sizes[i] = size / 2
+		}
+		height += sizes[i] * mmPerPt * 1.1
+	}
+	top := cy - height/2
+	y, width := top, 0.0
+	for i, line := range lines {
+		family := s.fonts.pickText(s.fonts.primary, line)
+		pdf.SetFont(family, s.fonts.style(family, "B"), sizes[i])
+		text := s.fonts.encode(family, line)
+		w := pdf.GetStringWidth(text)
+		width = max(width, w)
+		pdf.Text(cx-w/2, y+sizes[i]*mmPerPt*0.85, text)
+		y += sizes[i] * mmPerPt * 1.1
+	}
+	if framed {
+		pad := size * mmPerPt * 0.3
+		pdf.SetLineWidth(0.8)
+		pdf.Rect(cx-width/2-pad, top-pad, width+2*pad, height+2*pad, "D")
+	}
+
+	pdf.SetAlpha(1, "Normal")
+	pdf.TransformEnd()
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetLineWidth(0.2)
+}
@@ -0,0 +1,72 @@
+package report
+
+import "strings"
+
+// euMemberStates lists the ISO codes of EU member states. Greece is listed
+// under its ISO code GR.
+var euMemberStates = map[string]bool{
+	"AT": true, "BE": true, "BG": true, "CY": true, "CZ": true, "DE": true,
+	"DK": true, "EE": true, "ES": true, "FI": true, "FR": true, "GR": true,
+	"HR": true, "HU": true, "IE": true, "IT": true, "LT": true, "LU": true,
+	"LV": true, "MT": true, "NL": true, "PL": true, "PT": true, "RO": true,
+	"SE": true, "SI": true, "SK": true,
+}
+
+// IsEU returns true if the ISO country code is an EU member state.
+func IsEU(code string) bool {
+	return euMemberStates[code]
+}
+
+// countryNames maps common English and native country names to ISO codes,
+// since party addresses often carry the country name rather than its code.
+var countryNames = map[string]string{
+	"AUSTRIA": "AT", "ÖSTERREICH": "AT",
+	"BELGIUM": "BE", "BELGIQUE": "BE", "BELGIË": "BE",
+	"BULGARIA": "BG",
+	"CYPRUS":   "CY",
+	"CZECHIA":  "CZ", "CZECH REPUBLIC": "CZ",
+	"GERMANY": "DE", "DEUTSCHLAND": "DE",
+	"DENMARK": "DK", "DANMARK": "DK",
+	"ESTONIA": "EE",
+	"SPAIN":   "ES", "ESPAÑA": "ES",
+	"FINLAND": "FI", "SUOMI": "FI",
+	"FRANCE":  "FR",
+	"GREECE":  "GR",
+	"CROATIA": "HR", "HRVATSKA": "HR",
+	"HUNGARY": "HU", "MAGYARORSZÁG": "HU",
+	"IRELAND": "IE",
+	"ITALY":   "IT", "ITALIA": "IT",
+	"LITHUANIA":   "LT",
+	"LUXEMBOURG":  "LU",
+	"LATVIA":      "LV",
+	"MALTA":       "MT",
+	"NETHERLANDS": "NL", "THE NETHERLANDS": "NL", "NEDERLAND": "NL",
+	"POLAND": "PL", "POLSKA": "PL",
+	"PORTUGAL": "PT",
+	"ROMANIA":  "RO", "ROMÂNIA": "RO",
+	"SWEDEN": "SE", "SVERIGE": "SE",
+	"SLOVENIA": "SI", "SLOVENIJA": "SI",
+	"SLOVAKIA": "SK", "SLOVENSKO": "SK",
+	"SWITZERLAND": "CH", "SCHWEIZ": "CH", "SUISSE": "CH", "SVIZZERA": "CH",
+	"LIECHTENSTEIN":  "LI",
+	"UNITED KINGDOM": "GB", "GREAT BRITAIN": "GB",
+	"NORWAY": "NO", "NORGE": "NO",
+	"UNITED STATES": "US", "UNITED STATES OF AMERICA": "US", "USA": "US",
+	"CANADA": "CA",
+}
+
+// CountryCode returns the ISO 3166 alpha-2 code for a country code or
+// name, or "" if it is not recognized. The VAT prefix "EL" maps to "GR" and "UK" to "GB".
+func CountryCode(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch s {
+	case "EL":
+		return "GR"
+	case "UK":
+		return "GB"
+	}
+	if len(s) == 2 {
+		return s
+	}
+	return countryNames[s]
+}
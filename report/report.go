@@ -0,0 +1,254 @@
+// Package report aggregates invoices and credit notes into VAT figures per
+// period, country and tax rate, and derives box-level totals for common
+// VAT returns with CSV and JSON output.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/wiederin/go-invoicer/invoice"
+	"github.com/wiederin/go-invoicer/tax"
+)
+
+// Periodicity determines how tax point dates are grouped into periods.
+type Periodicity string
+
+// Supported periodicities.
+const (
+	Monthly   Periodicity = "monthly"
+	Quarterly Periodicity = "quarterly"
+	Yearly    Periodicity = "yearly"
+)
+
+// PeriodOf returns the period label for a date, e.g. "2025-03" (monthly),
+// "2025-Q1" (quarterly) or "2025" (yearly).
+func PeriodOf(t time.Time, p Periodicity) string {
+	switch p {
+	case Monthly:
+		return t.Format("2006-01")
+	case Yearly:
+		return t.Format("2006")
+	default:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	}
+}
+
+// Supply classifies where and to whom a supply was made.
+type Supply string
+
+// Supply classifications.
+const (
+	SupplyDomestic   Supply = "domestic"
+	SupplyIntraEUB2B Supply = "intra_eu_b2b"
+	SupplyIntraEUB2C Supply = "intra_eu_b2c"
+	SupplyExport     Supply = "export"
+)
+
+// Line is an aggregated group of line items sharing the same period,
+// country, supply type, tax category, rate and currency. Country is the
+// supplier's taxing country and CustomerCountry the country of the
+// customer (the member state of consumption for OSS).
+type Line struct {
+	Period          string          `json:"period"`
+	Country         string          `json:"country"`
+	CustomerCountry string          `json:"customer_country"`
+	Supply          Supply          `json:"supply"`
+	Category        tax.Category    `json:"category"`
+	Rate            decimal.Decimal `json:"rate"`
+	Currency        string          `json:"currency"`
+	Net             decimal.Decimal `json:"net"`
+	Tax             decimal.Decimal `json:"tax"`
+	Documents       int             `json:"documents"`
+}
+
+type lineKey struct {
+	period, country, customerCountry string
+	supply                           Supply
+	category                         tax.Category
+	rate                             string
+	currency                         string
+}
+
+// Aggregator accumulates invoices and credit notes into report lines.
+type Aggregator struct {
+	periodicity Periodicity
+	lines       map[lineKey]*Line
+	documents   map[lineKey]map[string]bool
+}
+
+// New creates an aggregator grouping by the given periodicity.
+func New(periodicity Periodicity) *Aggregator {
+	return &Aggregator{
+		periodicity: periodicity,
+		lines:       make(map[lineKey]*Line),
+		documents:   make(map[lineKey]map[string]bool),
+	}
+}
+
+// Add adds invoices and credit notes to the report. Credit note amounts are
+// subtracted. Draft and cancelled documents are ignored.
+func (a *Aggregator) Add(invoices ...*invoice.Invoice) error {
+	for _, inv := range invoices {
+		if inv.Status == invoice.StatusDraft || inv.Status == invoice.StatusCancelled {
+			continue
+		}
+		if err := a.add(inv); err != nil {
+			return fmt.Errorf("invoice %s: %w", inv.Number, err)
+		}
+	}
+	return nil
+}
+
+func (a *Aggregator) add(inv *invoice.Invoice) error {
+	country := CountryCode(inv.CountryCode)
+	if country == "" {
+		country = CountryCode(inv.Supplier.Address.Country)
+	}
+	if country == "" {
+		return fmt.Errorf("taxing country is unknown")
+	}
+	customerCountry := CountryCode(inv.Customer.Address.Country)
+	if customerCountry == "" {
+		customerCountry = country
+	}
+	supply := classifySupply(country, customerCountry, inv.Customer.VATID != "")
+
+	sign := decimal.NewFromInt(1)
+	if inv.IsCreditNote() {
+		sign = sign.Neg()
+	}
+	period := PeriodOf(inv.TaxPoint(), a.periodicity)
+
+	for _, item := range inv.LineItems {
+		rateCountry := country
+		if supply == SupplyIntraEUB2C {
+			rateCountry = customerCountry
+		}
+		key := lineKey{
+			period:          period,
+			country:         country,
+			customerCountry: customerCountry,
+			supply:          supply,
+			category:        tax.CategoryFor(rateCountry, item.TaxRate),
+			rate:            item.TaxRate.String(),
+			currency:        item.UnitPrice.Currency,
+		}
+		line, ok := a.lines[key]
+		if !ok {
+			line = &Line{
+				Period:          key.period,
+				Country:         key.country,
+				CustomerCountry: key.customerCountry,
+				Supply:          key.supply,
+				Category:        key.category,
+				Rate:            item.TaxRate,
+				Currency:        key.currency,
+			}
+			a.lines[key] = line
+			a.documents[key] = make(map[string]bool)
+		}
+		line.Net = line.Net.Add(item.NetAmount().Amount.Round(2).Mul(sign))
+		line.Tax = line.Tax.Add(item.TaxAmount().Amount.Round(2).Mul(sign))
+		if !a.documents[key][inv.Number] {
+			a.documents[key][inv.Number] = true
+			line.Documents++
+		}
+	}
+	return nil
+}
+
+func classifySupply(country, customerCountry string, customerHasVATID bool) Supply {
+	switch {
+	case country == customerCountry:
+		return SupplyDomestic
+	case IsEU(country) && IsEU(customerCountry):
+		if customerHasVATID {
+			return SupplyIntraEUB2B
+		}
+		return SupplyIntraEUB2C
+	default:
+		return SupplyExport
+	}
+}
+
+// Lines returns the aggregated lines sorted by period, country, customer
+// country, supply, rate and currency.
+func (a *Aggregator) Lines() []Line {
+	lines := make([]Line, 0, len(a.lines))
+	for _, l := range a.lines {
+		lines = append(lines, *l)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		x, y := lines[i], lines[j]
+		if x.Period != y.Period {
+			return x.Period < y.Period
+		}
+		if x.Country != y.Country {
+			return x.Country < y.Country
+		}
+		if x.CustomerCountry != y.CustomerCountry {
+			return x.CustomerCountry < y.CustomerCountry
+		}
+		if x.Supply != y.Supply {
+			return x.Supply < y.Supply
+		}
+		if !x.Rate.Equal(y.Rate) {
+			return x.Rate.GreaterThan(y.Rate)
+		}
+		return x.Currency < y.Currency
+	})
+	return lines
+}
+
+// Filter returns the lines for the given period and taxing country.
+func (a *Aggregator) Filter(period, country string) []Line {
+	var out []Line
+	for _, l := range a.Lines() {
+		if l.Period == period && l.Country == country {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// WriteCSV writes the aggregated lines as CSV with a header row.
+func (a *Aggregator) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"period", "country", "customer_country", "supply", "category", "rate", "currency", "net", "tax", "documents"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, l := range a.Lines() {
+		record := []string{
+			l.Period,
+			l.Country,
+			l.CustomerCountry,
+			string(l.Supply),
+			string(l.Category),
+			l.Rate.String(),
+			l.Currency,
+			l.Net.StringFixed(2),
+			l.Tax.StringFixed(2),
+			fmt.Sprint(l.Documents),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the aggregated lines as a JSON array.
+func (a *Aggregator) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a.Lines())
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+func testInvoice(number string, date time.Time, customer invoice.Party, items ...invoice.LineItem) *invoice.Invoice {
+	return &invoice.Invoice{
+		Number:      number,
+		IssueDate:   date,
+		Currency:    "EUR",
+		CountryCode: "DE",
+		Status:      invoice.StatusIssued,
+		Supplier:    invoice.Party{Name: "S", Address: invoice.Address{Country: "Germany"}},
+		Customer:    customer,
+		LineItems:   items,
+	}
+}
+
+func TestAggregateAndUStVA(t *testing.T) {
+	q1 := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+	domestic := invoice.Party{Name: "D", Address: invoice.Address{Country: "DE"}}
+	french := invoice.Party{Name: "F", Address: invoice.Address{Country: "France"}, VATID: "FR40303265045"}
+
+	inv1 := testInvoice("1", q1, domestic,
+		invoice.NewLineItem("A", 1, invoice.NewMoney(1000, "EUR"), 19),
+		invoice.NewLineItem("B", 1, invoice.NewMoney(100, "EUR"), 7))
+	inv2 := testInvoice("2", q1, french, invoice.NewLineItem("C", 1, invoice.NewMoney(500, "EUR"), 0))
+	credit := testInvoice("3", q1, domestic, invoice.NewLineItem("A", 1, invoice.NewMoney(200, "EUR"), 19))
+	credit.Type = invoice.TypeCreditNote
+	draft := testInvoice("4", q1, domestic, invoice.NewLineItem("A", 1, invoice.NewMoney(999, "EUR"), 19))
+	draft.Status = invoice.StatusDraft
+
+	agg := New(Quarterly)
+	if err := agg.Add(inv1, inv2, credit, draft); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(agg.Lines()); n != 3 {
+		t.Fatalf("expected 3 lines, got %d", n)
+	}
+
+	ret, err := agg.UStVA("2025-Q1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checks := map[string]float64{"81": 800, "86": 100, "41": 500, "83": 159}
+	for code, want := range checks {
+		box, _ := ret.Box(code)
+		if got := box.Amount.InexactFloat64(); got != want {
+			t.Errorf("box %s: expected %v, got %v", code, want, got)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := ret.WriteCSV(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "81,Steuerpflichtige Umsätze zum Steuersatz von 19 %,800.00,152.00") {
+		t.Errorf("unexpected CSV output:\n%s", buf.String())
+	}
+}
+
+func TestOSSAndMWST(t *testing.T) {
+	date := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	consumer := invoice.Party{Name: "F", Address: invoice.Address{Country: "FR"}}
+
+	agg := New(Quarterly)
+	_ = agg.Add(testInvoice("1", date, consumer, invoice.NewLineItem("E-book", 2, invoice.NewMoney(50, "EUR"), 20)))
+
+	oss, err := agg.OSS("2025-Q2", "DE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	box, ok := oss.Box("FR 20%")
+	if !ok || box.Amount.InexactFloat64() != 100 || box.Tax.InexactFloat64() != 20 {
+		t.Errorf("unexpected OSS box %+v", box)
+	}
+
+	swiss := testInvoice("CH-1", date, invoice.Party{Name: "C", Address: invoice.Address{Country: "CH"}},
+		invoice.NewLineItem("Service", 1, invoice.NewMoney(1000, "CHF"), 8.1))
+	swiss.CountryCode = "CH"
+	swiss.Currency = "CHF"
+	_ = agg.Add(swiss)
+
+	mwst, err := agg.MWST("2025-Q2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if box, _ := mwst.Box("303"); box.Tax.InexactFloat64() != 81 {
+		t.Errorf("expected 81 tax in box 303, got %v", box.Tax)
+	}
+}
+
+func TestPeriodOf(t *testing.T) {
+	d := time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC)
+	if PeriodOf(d, Monthly) != "2025-11" || PeriodOf(d, Quarterly) != "2025-Q4" || PeriodOf(d, Yearly) != "2025" {
+		t.Error("unexpected period labels")
+	}
+}
@@ -0,0 +1,223 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/wiederin/go-invoicer/tax"
+)
+
+// Box is a single box (Kennzahl, Ziffer) of a VAT return. Amount holds the
+// taxable base or value of the box and Tax the tax amount for boxes that
+// carry one.
+type Box struct {
+	Code   string          `json:"code"`
+	Label  string          `json:"label"`
+	Amount decimal.Decimal `json:"amount"`
+	Tax    decimal.Decimal `json:"tax,omitzero"`
+}
+
+// Return holds the box-level totals of a VAT return for one period.
+// Only output tax from the aggregated sales documents is included; input
+// tax must be added from purchase records.
+type Return struct {
+	Form     string `json:"form"`
+	Country  string `json:"country"`
+	Period   string `json:"period"`
+	Currency string `json:"currency"`
+	Boxes    []Box  `json:"boxes"`
+}
+
+// Box returns the box with the given code.
+func (r Return) Box(code string) (Box, bool) {
+	for _, b := range r.Boxes {
+		if b.Code == code {
+			return b, true
+		}
+	}
+	return Box{}, false
+}
+
+// WriteCSV writes the return's boxes as CSV with a header row.
+func (r Return) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"form", "period", "currency", "code", "label", "amount", "tax"}); err != nil {
+		return err
+	}
+	for _, b := range r.Boxes {
+		record := []string{r.Form, r.Period, r.Currency, b.Code, b.Label, b.Amount.StringFixed(2), b.Tax.StringFixed(2)}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the return as a JSON object.
+func (r Return) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// newReturn collects the lines for a period and taxing countries and checks
+// that they share a single currency.
+func (a *Aggregator) newReturn(form, period string, countries ...string) (Return, []Line, error) {
+	var lines []Line
+	for _, c := range countries {
+		lines = append(lines, a.Filter(period, c)...)
+	}
+	r := Return{Form: form, Country: countries[0], Period: period}
+	for _, l := range lines {
+		if r.Currency == "" {
+			r.Currency = l.Currency
+		} else if l.Currency != r.Currency {
+			return Return{}, nil, fmt.Errorf("%s %s: documents in %s and %s cannot be combined", form, period, r.Currency, l.Currency)
+		}
+	}
+	return r, lines, nil
+}
+
+func sumLines(lines []Line, match func(Line) bool) (net, taxAmount decimal.Decimal) {
+	for _, l := range lines {
+		if match(l) {
+			net = net.Add(l.Net)
+			taxAmount = taxAmount.Add(l.Tax)
+		}
+	}
+	return net, taxAmount
+}
+
+func domesticRate(rate float64) func(Line) bool {
+	r := decimal.NewFromFloat(rate)
+	return func(l Line) bool {
+		return l.Supply == SupplyDomestic && l.Rate.Equal(r)
+	}
+}
+
+// UStVA returns the German advance VAT return (Umsatzsteuer-Voranmeldung)
+// for the period. Intra-community B2B supplies are reported in Kz. 41 and
+// B2C distance sales are left to the OSS return.
+func (a *Aggregator) UStVA(period string) (Return, error) {
+	r, lines, err := a.newReturn("DE UStVA", period, "DE")
+	if err != nil {
+		return r, err
+	}
+	net19, tax19 := sumLines(lines, domesticRate(19))
+	net7, tax7 := sumLines(lines, domesticRate(7))
+	netOther, taxOther := sumLines(lines, func(l Line) bool {
+		return l.Supply == SupplyDomestic && !l.Rate.IsZero() && !l.Rate.Equal(decimal.NewFromInt(19)) && !l.Rate.Equal(decimal.NewFromInt(7))
+	})
+	netExempt, _ := sumLines(lines, func(l Line) bool { return l.Supply == SupplyDomestic && l.Rate.IsZero() })
+	netIntra, _ := sumLines(lines, func(l Line) bool { return l.Supply == SupplyIntraEUB2B })
+	netExport, _ := sumLines(lines, func(l Line) bool { return l.Supply == SupplyExport })
+
+	r.Boxes = []Box{
+		{Code: "41", Label: "Innergemeinschaftliche Lieferungen an Abnehmer mit USt-IdNr.", Amount: netIntra},
+		{Code: "43", Label: "Weitere steuerfreie Umsätze mit Vorsteuerabzug (Ausfuhren)", Amount: netExport},
+		{Code: "48", Label: "Steuerfreie Umsätze ohne Vorsteuerabzug", Amount: netExempt},
+		{Code: "81", Label: "Steuerpflichtige Umsätze zum Steuersatz von 19 %", Amount: net19, Tax: tax19},
+		{Code: "86", Label: "Steuerpflichtige Umsätze zum Steuersatz von 7 %", Amount: net7, Tax: tax7},
+		{Code: "35", Label: "Umsätze, die anderen Steuersätzen unterliegen", Amount: netOther, Tax: taxOther},
+		{Code: "83", Label: "Verbleibende Umsatzsteuer-Vorauszahlung", Amount: tax19.Add(tax7).Add(taxOther)},
+	}
+	return r, nil
+}
+
+// MWST returns the Swiss VAT return (MWST-Abrechnung, effective method)
+// for the period.
+func (a *Aggregator) MWST(period string) (Return, error) {
+	r, lines, err := a.newReturn("CH MWST", period, "CH")
+	if err != nil {
+		return r, err
+	}
+	all := func(Line) bool { return true }
+	total, _ := sumLines(lines, all)
+	exports, _ := sumLines(lines, func(l Line) bool { return l.Supply != SupplyDomestic })
+	exempt, _ := sumLines(lines, func(l Line) bool { return l.Supply == SupplyDomestic && l.Rate.IsZero() })
+	netAccommodation, taxAccommodation := sumLines(lines, domesticRate(3.8))
+	netReduced, taxReduced := sumLines(lines, func(l Line) bool {
+		return l.Supply == SupplyDomestic && l.Category == tax.CategoryReduced && !l.Rate.Equal(decimal.NewFromFloat(3.8))
+	})
+	netStandard, taxStandard := sumLines(lines, func(l Line) bool {
+		return l.Supply == SupplyDomestic && l.Category == tax.CategoryStandard
+	})
+	deductions := exports.Add(exempt)
+	totalTax := taxStandard.Add(taxReduced).Add(taxAccommodation)
+
+	r.Boxes = []Box{
+		{Code: "200", Label: "Total der vereinbarten bzw. vereinnahmten Entgelte", Amount: total},
+		{Code: "220", Label: "Von der Steuer befreite Leistungen (u.a. Exporte)", Amount: exports},
+		{Code: "230", Label: "Von der Steuer ausgenommene Inlandleistungen", Amount: exempt},
+		{Code: "289", Label: "Total Abzüge", Amount: deductions},
+		{Code: "299", Label: "Steuerbarer Gesamtumsatz", Amount: total.Sub(deductions)},
+		{Code: "303", Label: "Leistungen zum Normalsatz 8,1 %", Amount: netStandard, Tax: taxStandard},
+		{Code: "313", Label: "Leistungen zum reduzierten Satz 2,6 %", Amount: netReduced, Tax: taxReduced},
+		{Code: "343", Label: "Leistungen zum Beherbergungssatz 3,8 %", Amount: netAccommodation, Tax: taxAccommodation},
+		{Code: "399", Label: "Total geschuldete Steuer", Amount: totalTax},
+		{Code: "500", Label: "Zu bezahlender Betrag", Amount: totalTax},
+	}
+	return r, nil
+}
+
+// VAT100 returns the UK VAT return (VAT100) for the period. Box 8 covers
+// goods supplied from Northern Ireland (XI) to EU customers.
+func (a *Aggregator) VAT100(period string) (Return, error) {
+	r, lines, err := a.newReturn("UK VAT100", period, "GB", "XI")
+	if err != nil {
+		return r, err
+	}
+	sales, vatDue := sumLines(lines, func(Line) bool { return true })
+	euSupplies, _ := sumLines(lines, func(l Line) bool { return l.Country == "XI" && IsEU(l.CustomerCountry) })
+
+	r.Boxes = []Box{
+		{Code: "1", Label: "VAT due on sales and other outputs", Amount: vatDue},
+		{Code: "2", Label: "VAT due on acquisitions from EU member states", Amount: decimal.Zero},
+		{Code: "3", Label: "Total VAT due", Amount: vatDue},
+		{Code: "4", Label: "VAT reclaimed on purchases", Amount: decimal.Zero},
+		{Code: "5", Label: "Net VAT to pay to HMRC", Amount: vatDue},
+		{Code: "6", Label: "Total value of sales excluding VAT", Amount: sales.Round(0)},
+		{Code: "7", Label: "Total value of purchases excluding VAT", Amount: decimal.Zero},
+		{Code: "8", Label: "Total value of goods supplied to EU member states", Amount: euSupplies.Round(0)},
+		{Code: "9", Label: "Total value of goods acquired from EU member states", Amount: decimal.Zero},
+	}
+	return r, nil
+}
+
+// OSS returns the EU One-Stop-Shop (union scheme) return of a supplier
+// identified in the given member state, with one box per member state of
+// consumption and rate, e.g. "FR 20%".
+func (a *Aggregator) OSS(period, identificationCountry string) (Return, error) {
+	r, lines, err := a.newReturn("EU OSS", period, CountryCode(identificationCountry))
+	if err != nil {
+		return r, err
+	}
+	var total decimal.Decimal
+	for _, l := range lines {
+		if l.Supply != SupplyIntraEUB2C {
+			continue
+		}
+		code := fmt.Sprintf("%s %s%%", l.CustomerCountry, l.Rate.String())
+		found := false
+		for i := range r.Boxes {
+			if r.Boxes[i].Code == code {
+				r.Boxes[i].Amount = r.Boxes[i].Amount.Add(l.Net)
+				r.Boxes[i].Tax = r.Boxes[i].Tax.Add(l.Tax)
+				found = true
+			}
+		}
+		if !found {
+			r.Boxes = append(r.Boxes, Box{Code: code, Label: string(l.Category) + " rate", Amount: l.Net, Tax: l.Tax})
+		}
+		total = total.Add(l.Tax)
+	}
+	sort.Slice(r.Boxes, func(i, j int) bool { return r.Boxes[i].Code < r.Boxes[j].Code })
+	r.Boxes = append(r.Boxes, Box{Code: "total", Label: "Total VAT due", Amount: total})
+	return r, nil
+}
@@ -0,0 +1,496 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	oidData                 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidTimeStampToken       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 14}
+	oidTSTInfo              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidRSAPSS               = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidEd25519              = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// hashes are the digest algorithms by object identifier.
+var hashes = []struct {
+	oid  asn1.ObjectIdentifier
+	hash crypto.Hash
+}{
+	{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}, crypto.SHA256},
+	{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}, crypto.SHA384},
+	{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}, crypto.SHA512},
+}
+
+// hashOID returns the object identifier of a digest algorithm.
+func hashOID(h crypto.Hash) asn1.ObjectIdentifier {
+	for _, e := range hashes {
+		if e.hash == h {
+			return e.oid
+		}
+	}
+	return nil
+}
+
+// hashFor returns the digest algorithm of an algorithm identifier.
+func hashFor(alg pkix.AlgorithmIdentifier) (crypto.Hash, error) {
+	for _, e := range hashes {
+		if e.oid.Equal(alg.Algorithm) {
+			return e.hash, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: digest algorithm %v", ErrInvalidSignature, alg.Algorithm)
+}
+
+// digest returns the digest of data.
+func digest(h crypto.Hash, data ...[]byte) []byte {
+	d := h.New()
+	for _, b := range data {
+		d.Write(b)
+	}
+	return d.Sum(nil)
+}
+
+// algorithms returns the digest and signature algorithms used with a key.
+func algorithms(key crypto.PublicKey) (crypto.Hash, pkix.AlgorithmIdentifier, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return crypto.SHA256, pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11},
+			Parameters: asn1.NullRawValue,
+		}, nil
+	case *ecdsa.PublicKey:
+		return crypto.SHA256, pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}}, nil
+	case ed25519.PublicKey:
+		// RFC 8419 pairs Ed25519 with SHA-512.
+		return crypto.SHA512, pkix.AlgorithmIdentifier{Algorithm: oidEd25519}, nil
+	}
+	return 0, pkix.AlgorithmIdentifier{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+}
+
+// x509Algorithm returns the algorithm to check a signature with.
+func x509Algorithm(key crypto.PublicKey, h crypto.Hash, alg pkix.AlgorithmIdentifier) (x509.SignatureAlgorithm, error) {
+	var algs map[crypto.Hash]x509.SignatureAlgorithm
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if alg.Algorithm.Equal(oidRSAPSS) {
+			algs = map[crypto.Hash]x509.SignatureAlgorithm{
+				crypto.SHA256: x509.SHA256WithRSAPSS, crypto.SHA384: x509.SHA384WithRSAPSS, crypto.SHA512: x509.SHA512WithRSAPSS,
+			}
+		} else {
+			algs = map[crypto.Hash]x509.SignatureAlgorithm{
+				crypto.SHA256: x509.SHA256WithRSA, crypto.SHA384: x509.SHA384WithRSA, crypto.SHA512: x509.SHA512WithRSA,
+			}
+		}
+	case *ecdsa.PublicKey:
+		algs = map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA256: x509.ECDSAWithSHA256, crypto.SHA384: x509.ECDSAWithSHA384, crypto.SHA512: x509.ECDSAWithSHA512,
+		}
+	case ed25519.PublicKey:
+		return x509.PureEd25519, nil
+	}
+	if a, ok := algs[h]; ok {
+		return a, nil
+	}
+	return 0, fmt.Errorf("%w: signature algorithm %v with %T", ErrInvalidSignature, alg.Algorithm, key)
+}
+
+// attribute is a signed or unsigned attribute of a CMS signer.
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// newAttribute returns an attribute with a single value.
+func newAttribute(oid asn1.ObjectIdentifier, value any) (attribute, error) {
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		return attribute{}, err
+	}
+	return attribute{Type: oid, Values: []asn1.RawValue{{FullBytes: der}}}, nil
+}
+
+// attributeSet encodes attributes as a SET with an implicit context tag.
+func attributeSet(attrs []attribute, tag int) (asn1.RawValue, error) {
+	der, err := asn1.MarshalWithParams(attrs, "set")
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	var set asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &set); err != nil {
+		return asn1.RawValue{}, err
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: set.Bytes}, nil
+}
+
+// contentInfo is the outer structure of CMS messages (RFC 5652).
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"optional,explicit,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerial
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional"`
+}
+
+type issuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// signingCertificateV2 identifies the certificate of the signer (RFC 5035),
+// which binds it to the signature.
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+type essCertIDv2 struct {
+	// HashAlgorithm is omitted for the default, SHA-256.
+	HashAlgorithm pkix.AlgorithmIdentifier `asn1:"optional"`
+	CertHash      []byte
+	IssuerSerial  issuerSerial `asn1:"optional"`
+}
+
+type issuerSerial struct {
+	Issuer       []asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// newSignerInfo signs content, which is of the given type, with the key of
+// the certificate. The signed attributes bind the digest of the content
+// and the certificate to the signature.
+func newSignerInfo(key crypto.Signer, cert *x509.Certificate, content []byte, contentType asn1.ObjectIdentifier) (signerInfo, error) {
+	h, sigAlg, err := algorithms(key.Public())
+	if err != nil {
+		return signerInfo{}, err
+	}
+	ess := signingCertificateV2{Certs: []essCertIDv2{{
+		CertHash: digest(crypto.SHA256, cert.Raw),
+		IssuerSerial: issuerSerial{
+			Issuer:       []asn1.RawValue{{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true, Bytes: cert.RawIssuer}},
+			SerialNumber: cert.SerialNumber,
+		},
+	}}}
+	var attrs []attribute
+	for _, a := range []struct {
+		oid   asn1.ObjectIdentifier
+		value any
+	}{
+		{oidContentType, contentType},
+		{oidMessageDigest, digest(h, content)},
+		{oidSigningCertificateV2, ess},
+	} {
+		attr, err := newAttribute(a.oid, a.value)
+		if err != nil {
+			return signerInfo{}, err
+		}
+		attrs = append(attrs, attr)
+	}
+	signed, err := asn1.MarshalWithParams(attrs, "set")
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	var sig []byte
+	if sigAlg.Algorithm.Equal(oidEd25519) {
+		sig, err = key.Sign(rand.Reader, signed, crypto.Hash(0))
+	} else {
+		sig, err = key.Sign(rand.Reader, digest(h, signed), h)
+	}
+	if err != nil {
+		return signerInfo{}, fmt.Errorf("signing: %w", err)
+	}
+	signedAttrs, err := attributeSet(attrs, 0)
+	if err != nil {
+		return signerInfo{}, err
+	}
+	return signerInfo{
+		Version:            1,
+		SID:                issuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber},
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: hashOID(h)},
+		SignedAttrs:        signedAttrs,
+		SignatureAlgorithm: sigAlg,
+		Signature:          sig,
+	}, nil
+}
+
+// marshalSignedData returns the CMS SignedData of a signer with the
+// certificates included. The content is only included if encapsulated.
+func marshalSignedData(si signerInfo, certs []*x509.Certificate, content []byte, contentType asn1.ObjectIdentifier, encapsulate bool) ([]byte, error) {
+	var raw []byte
+	for _, c := range certs {
+		raw = append(raw, c.Raw...)
+	}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{si.DigestAlgorithm},
+		EncapContentInfo: encapContentInfo{EContentType: contentType},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      []signerInfo{si},
+	}
+	if encapsulate {
+		sd.EncapContentInfo.EContent = content
+	}
+	if !contentType.Equal(oidData) {
+		// RFC 5652 requires version 3 for other encapsulated content types.
+		sd.Version = 3
+	}
+	der, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		// encoding/asn1 does not add the explicit tag to raw values.
+		Content: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der},
+	})
+}
+
+// rawElement keeps an element of which only the encoding is needed.
+type rawElement struct {
+	Raw asn1.RawContent
+}
+
+// The parsed forms of the CMS structures, which tolerate the optional
+// elements written by other implementations.
+type (
+	parsedSignedData struct {
+		Version          int
+		DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+		EncapContentInfo encapContentInfo
+		Certificates     rawElement         `asn1:"optional,tag:0"`
+		CRLs             rawElement         `asn1:"optional,tag:1"`
+		SignerInfos      []parsedSignerInfo `asn1:"set"`
+	}
+
+	parsedSignerInfo struct {
+		Version            int
+		SID                asn1.RawValue
+		DigestAlgorithm    pkix.AlgorithmIdentifier
+		SignedAttrs        rawElement `asn1:"optional,tag:0"`
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          []byte
+		UnsignedAttrs      rawElement `asn1:"optional,tag:1"`
+	}
+)
+
+// verifiedData is a CMS SignedData whose signature has been checked.
+type verifiedData struct {
+	certificate *x509.Certificate
+	// certificates are all certificates included, with the signer's.
+	certificates []*x509.Certificate
+	signature    []byte
+	contentType  asn1.ObjectIdentifier
+	content      []byte
+	signed       []attribute
+	unsigned     []attribute
+}
+
+// verifySignedData checks the signature of a CMS SignedData over its
+// encapsulated content or, if detached, over content.
+func verifySignedData(der, content []byte) (*verifiedData, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	} else if len(bytes.Trim(rest, "\x00")) > 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidSignature)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("%w: content type %v", ErrInvalidSignature, ci.ContentType)
+	}
+	var sd parsedSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("%w: %d signers", ErrInvalidSignature, len(sd.SignerInfos))
+	}
+	si := sd.SignerInfos[0]
+	v := &verifiedData{
+		signature:   si.Signature,
+		contentType: sd.EncapContentInfo.EContentType,
+		content:     content,
+	}
+	if sd.EncapContentInfo.EContent != nil {
+		v.content = sd.EncapContentInfo.EContent
+	}
+	if v.content == nil {
+		return nil, fmt.Errorf("%w: no content", ErrInvalidSignature)
+	}
+
+	if len(sd.Certificates.Raw) > 0 {
+		var certs asn1.RawValue
+		if _, err := asn1.Unmarshal(sd.Certificates.Raw, &certs); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+		}
+		var err error
+		if v.certificates, err = x509.ParseCertificates(certs.Bytes); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+		}
+	}
+	var err error
+	if v.certificate, err = signerCertificate(si.SID, v.certificates); err != nil {
+		return nil, err
+	}
+	if v.signed, err = parseAttributes(si.SignedAttrs); err != nil {
+		return nil, err
+	}
+	if v.unsigned, err = parseAttributes(si.UnsignedAttrs); err != nil {
+		return nil, err
+	}
+
+	h, err := hashFor(si.DigestAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	var contentType asn1.ObjectIdentifier
+	if err := v.attribute(oidContentType, &contentType); err != nil {
+		return nil, err
+	}
+	if !contentType.Equal(v.contentType) {
+		return nil, fmt.Errorf("%w: content type attribute %v", ErrInvalidSignature, contentType)
+	}
+	var md []byte
+	if err := v.attribute(oidMessageDigest, &md); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(md, digest(h, v.content)) {
+		return nil, fmt.Errorf("%w: content digest does not match", ErrInvalidSignature)
+	}
+
+	// The signature is over the DER encoding of the signed attributes,
+	// tagged as a SET rather than with their implicit tag.
+	signed := bytes.Clone(si.SignedAttrs.Raw)
+	signed[0] = 0x31
+	alg, err := x509Algorithm(v.certificate.PublicKey, h, si.SignatureAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.certificate.CheckSignature(alg, signed, si.Signature); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	// A signing certificate attribute must name the certificate used.
+	var ess signingCertificateV2
+	if err := v.attribute(oidSigningCertificateV2, &ess); err == nil {
+		if len(ess.Certs) == 0 {
+			return nil, fmt.Errorf("%w: empty signing certificate attribute", ErrInvalidSignature)
+		}
+		id := ess.Certs[0]
+		h := crypto.SHA256
+		if len(id.HashAlgorithm.Algorithm) > 0 {
+			if h, err = hashFor(id.HashAlgorithm); err != nil {
+				return nil, err
+			}
+		}
+		if !bytes.Equal(id.CertHash, digest(h, v.certificate.Raw)) {
+			return nil, fmt.Errorf("%w: signing certificate does not match", ErrInvalidSignature)
+		}
+	} else if !errors.Is(err, errNoAttribute) {
+		return nil, err
+	}
+	return v, nil
+}
+
+// signerCertificate returns the certificate identified by a signer
+// identifier.
+func signerCertificate(sid asn1.RawValue, certs []*x509.Certificate) (*x509.Certificate, error) {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, c := range certs {
+			if bytes.Equal(c.SubjectKeyId, sid.Bytes) {
+				return c, nil
+			}
+		}
+	} else {
+		var id issuerAndSerial
+		if _, err := asn1.Unmarshal(sid.FullBytes, &id); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+		}
+		for _, c := range certs {
+			if bytes.Equal(c.RawIssuer, id.Issuer.FullBytes) && c.SerialNumber.Cmp(id.SerialNumber) == 0 {
+				return c, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: certificate of the signer not included", ErrInvalidSignature)
+}
+
+// parseAttributes reads a SET of attributes.
+func parseAttributes(set rawElement) ([]attribute, error) {
+	if len(set.Raw) == 0 {
+		return nil, nil
+	}
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(set.Raw, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	var attrs []attribute
+	for rest := raw.Bytes; len(rest) > 0; {
+		var a attribute
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &a); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs, nil
+}
+
+// errNoAttribute is returned for missing attributes.
+var errNoAttribute = fmt.Errorf("%w: missing attribute", ErrInvalidSignature)
+
+// attribute reads the value of a signed attribute.
+func (v *verifiedData) attribute(oid asn1.ObjectIdentifier, value any) error {
+	return attributeValue(v.signed, oid, value)
+}
+
+// attributeValue reads the single value of an attribute.
+func attributeValue(attrs []attribute, oid asn1.ObjectIdentifier, value any) error {
+	for _, a := range attrs {
+		if !a.Type.Equal(oid) {
+			continue
+		}
+		if len(a.Values) != 1 {
+			return fmt.Errorf("%w: attribute %v has %d values", ErrInvalidSignature, oid, len(a.Values))
+		}
+		if _, err := asn1.Unmarshal(a.Values[0].FullBytes, value); err != nil {
+			return fmt.Errorf("%w: attribute %v: %w", ErrInvalidSignature, oid, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w %v", errNoAttribute, oid)
+}
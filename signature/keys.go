@@ -0,0 +1,85 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// LoadPKCS12 reads the key, certificate and chain of a signer from a
+// PKCS#12 (.p12 or .pfx) file.
+func LoadPKCS12(data []byte, password string) (*Signer, error) {
+	key, cert, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("reading PKCS#12: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+	}
+	return &Signer{Key: signer, Certificate: cert, Chain: chain}, nil
+}
+
+// LoadPEM reads a signer from PEM-encoded certificates, the first of which
+// is the signer's and the others its chain, and an unencrypted private key
+// in PKCS #8, PKCS #1 or SEC 1 form. Both may be in the same file.
+func LoadPEM(certPEM, keyPEM []byte) (*Signer, error) {
+	s := &Signer{}
+	for rest := certPEM; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("reading certificate: %w", err)
+		}
+		if s.Certificate == nil {
+			s.Certificate = cert
+		} else {
+			s.Chain = append(s.Chain, cert)
+		}
+	}
+	if s.Certificate == nil {
+		return nil, ErrNoCertificate
+	}
+
+	for rest := keyPEM; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		var key any
+		var err error
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		default:
+			if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+				return nil, fmt.Errorf("%w: %s", ErrUnsupportedKey, strings.ToLower(block.Type))
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading private key: %w", err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+		}
+		s.Key = signer
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w: no private key", ErrUnsupportedKey)
+}
@@ -0,0 +1,227 @@
+// Package signature signs PDFs with PAdES baseline signatures (ETSI EN 319
+// 142-1), which are CAdES detached signatures in an incremental update, and
+// verifies them.
+//
+// Signatures are B-B, or B-T when a Timestamper adds a signature timestamp.
+// Keys are used through crypto.Signer, so that keys kept in hardware
+// security modules can sign as well as keys loaded from PKCS#12 or PEM
+// files.
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wiederin/go-invoicer/internal/pdfupdate"
+)
+
+var (
+	// ErrUnsupportedKey is returned for keys other than RSA, ECDSA and
+	// Ed25519 keys.
+	ErrUnsupportedKey = errors.New("unsupported key")
+	// ErrKeyMismatch is returned if the key is not that of the certificate.
+	ErrKeyMismatch = errors.New("key does not match certificate")
+	// ErrNoCertificate is returned if no certificate is given or found.
+	ErrNoCertificate = errors.New("no certificate")
+	// ErrUnsupportedPDF is returned for PDFs that cannot be signed, such as
+	// those with cross-reference streams.
+	ErrUnsupportedPDF = errors.New("unsupported PDF")
+	// ErrSignatureSize is returned if the signature does not fit into the
+	// space reserved for it, even after signing again with more space.
+	ErrSignatureSize = errors.New("signature does not fit")
+)
+
+// Signer signs PDFs with a certificate and its private key.
+type Signer struct {
+	// Key is the private key of the certificate, an RSA, ECDSA or Ed25519
+	// key.
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+	// Chain are the intermediate certificates up to a trusted root, which
+	// are included in the signature.
+	Chain []*x509.Certificate
+	// Timestamper, if set, adds a signature timestamp.
+	Timestamper Timestamper
+	// Name is the name of the signer; the common name of the certificate
+	// if empty. Reason, Location and ContactInfo are optional.
+	Name        string
+	Reason      string
+	Location    string
+	ContactInfo string
+	// Time is the claimed signing time; the current time if zero.
+	Time time.Time
+}
+
+// byteRangePlaceholder is replaced by the byte range once the offsets are
+// known. It is wide enough for files up to 10 GB.
+const byteRangePlaceholder = "/ByteRange [0 0000000000 0000000000 0000000000]"
+
+// Sign returns the PDF with a signature added as an incremental update. The
+// signature is invisible and covers the whole file.
+func (s *Signer) Sign(pdf []byte) ([]byte, error) {
+	if s.Key == nil || s.Certificate == nil {
+		return nil, fmt.Errorf("%w: a key and its certificate are required", ErrNoCertificate)
+	}
+	if pub, ok := s.Key.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(s.Certificate.PublicKey) {
+		return nil, ErrKeyMismatch
+	}
+	if _, _, err := algorithms(s.Key.Public()); err != nil {
+		return nil, err
+	}
+
+	// The signature is written into space reserved before signing. If the
+	// estimate is short, which depends on the size of the timestamp token,
+	// the document is signed again with the space needed.
+	size := 4096
+	for _, c := range append([]*x509.Certificate{s.Certificate}, s.Chain...) {
+		size += len(c.Raw)
+	}
+	if s.Timestamper != nil {
+		size += 8192
+	}
+	data, needed, err := s.sign(pdf, size)
+	if err == nil && data == nil {
+		size = needed + 1024
+		data, needed, err = s.sign(pdf, size)
+	}
+	if err == nil && data == nil {
+		return nil, fmt.Errorf("%w: %d bytes reserved, %d needed", ErrSignatureSize, size, needed)
+	}
+	return data, err
+}
+
+// sign signs the PDF with size bytes reserved for the signature. If they
+// are not enough, it returns the size needed and no PDF.
+func (s *Signer) sign(pdf []byte, size int) ([]byte, int, error) {
+	u, err := pdfupdate.Parse(pdf)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrUnsupportedPDF, err)
+	}
+	pages, err := u.Pages()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrUnsupportedPDF, err)
+	}
+	if len(pages) == 0 {
+		return nil, 0, fmt.Errorf("%w: no pages", ErrUnsupportedPDF)
+	}
+	catalog, err := u.Object(u.Root)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrUnsupportedPDF, err)
+	}
+	page, err := u.Object(pages[0])
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrUnsupportedPDF, err)
+	}
+
+	sigNum, fieldNum := u.Reserve(), u.Reserve()
+	ref := fmt.Sprintf("%d 0 R", fieldNum)
+
+	// The field is added to the form of the document, which only exists if
+	// it has been signed before.
+	fields := 0
+	if before, after, ok := strings.Cut(catalog, "/AcroForm << /Fields ["); ok {
+		list, _, _ := strings.Cut(after, "]")
+		fields = strings.Count(list, " R")
+		catalog = before + "/AcroForm << /Fields [" + ref + " " + after
+	} else if strings.Contains(catalog, "/AcroForm") {
+		return nil, 0, fmt.Errorf("%w: existing form", ErrUnsupportedPDF)
+	} else if catalog, err = u.Extend(u.Root, " /AcroForm << /Fields ["+ref+"] /SigFlags 3 >>"); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrUnsupportedPDF, err)
+	}
+	u.Set(u.Root, catalog)
+
+	if before, after, ok := strings.Cut(page, "/Annots ["); ok {
+		page = before + "/Annots [" + ref + " " + after
+	} else if strings.Contains(page, "/Annots") {
+		return nil, 0, fmt.Errorf("%w: indirect annotations", ErrUnsupportedPDF)
+	} else if page, err = u.Extend(pages[0], " /Annots ["+ref+"]"); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrUnsupportedPDF, err)
+	}
+	u.Set(pages[0], page)
+
+	// The signature field is merged with its widget, which has no area
+	// and is printed but never shown.
+	u.Set(fieldNum, fmt.Sprintf("<< /Type /Annot /Subtype /Widget /FT /Sig /T %s /V %d 0 R /F 132 /Rect [0 0 0 0] /P %d 0 R >>",
+		pdfupdate.Text(fmt.Sprintf("Signature%d", fields+1)), sigNum, pages[0]))
+
+	t := s.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	name := s.Name
+	if name == "" {
+		name = s.Certificate.Subject.CommonName
+	}
+	var dict strings.Builder
+	fmt.Fprintf(&dict, "<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached\n%s\n/Contents <%s>\n/M (%s)",
+		byteRangePlaceholder, strings.Repeat("0", 2*size), pdfDate(t))
+	for _, e := range []struct{ key, value string }{
+		{"Name", name}, {"Reason", s.Reason}, {"Location", s.Location}, {"ContactInfo", s.ContactInfo},
+	} {
+		if e.value != "" {
+			fmt.Fprintf(&dict, " /%s %s", e.key, pdfupdate.Text(e.value))
+		}
+	}
+	dict.WriteString(" >>")
+	u.Set(sigNum, dict.String())
+
+	data := u.Bytes()
+	update := data[len(pdf):]
+	start := len(pdf) + bytes.Index(update, []byte("/Contents <")) + len("/Contents ")
+	end := start + 2*size + 2
+	byteRange := fmt.Sprintf("/ByteRange [0 %d %d %d]", start, end, len(data)-end)
+	copy(data[len(pdf)+bytes.Index(update, []byte(byteRangePlaceholder)):],
+		fmt.Sprintf("%-*s", len(byteRangePlaceholder), byteRange))
+
+	content := make([]byte, 0, len(data)-(end-start))
+	content = append(append(content, data[:start]...), data[end:]...)
+	si, err := newSignerInfo(s.Key, s.Certificate, content, oidData)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.Timestamper != nil {
+		token, err := s.Timestamper.Timestamp(digest(crypto.SHA256, si.Signature))
+		if err != nil {
+			return nil, 0, fmt.Errorf("timestamping: %w", err)
+		}
+		if _, _, err := verifyTimestamp(token, si.Signature); err != nil {
+			return nil, 0, err
+		}
+		attr, err := newAttribute(oidTimeStampToken, asn1.RawValue{FullBytes: token})
+		if err != nil {
+			return nil, 0, err
+		}
+		if si.UnsignedAttrs, err = attributeSet([]attribute{attr}, 1); err != nil {
+			return nil, 0, err
+		}
+	}
+	cms, err := marshalSignedData(si, append([]*x509.Certificate{s.Certificate}, s.Chain...), nil, oidData, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(cms) > size {
+		return nil, len(cms), nil
+	}
+	hex.Encode(data[start+1:], cms)
+	return data, 0, nil
+}
+
+// pdfDate formats a time as a PDF date.
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	if offset == 0 {
+		return t.Format("D:20060102150405Z")
+	}
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("%s%c%02d'%02d'", t.Format("D:20060102150405"), sign, offset/3600, offset%3600/60)
+}
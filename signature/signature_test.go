@@ -0,0 +1,396 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/wiederin/go-invoicer/internal/pdfupdate"
+	"github.com/wiederin/go-invoicer/invoice"
+	"github.com/wiederin/go-invoicer/render"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// testCertificate issues a certificate for the key, self-signed if parent
+// is nil.
+func testCertificate(t *testing.T, name string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer, usage ...x509.ExtKeyUsage) *x509.Certificate {
+	t.Helper()
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name, Organization: []string{"Supplier GmbH"}},
+		NotBefore:    date(2024, 1, 1),
+		NotAfter:     date(2034, 1, 1),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		ExtKeyUsage:  usage,
+	}
+	if slices.Contains(usage, x509.ExtKeyUsageTimeStamping) {
+		// RFC 3161 requires the extended key usage of TSAs to be critical.
+		value, err := asn1.Marshal([]asn1.ObjectIdentifier{{1, 3, 6, 1, 5, 5, 7, 3, 8}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tmpl.ExtKeyUsage = nil
+		tmpl.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 37}, Critical: true, Value: value}}
+	}
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return cert
+}
+
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return key
+}
+
+// testCA returns a root certificate and its key.
+func testCA(t *testing.T) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	key := testKey(t)
+	return testCertificate(t, "Test Root CA", key, nil, nil), key
+}
+
+func testPDF(t *testing.T, opts render.Options) []byte {
+	t.Helper()
+	inv, err := invoice.New().
+		Number("INV-100").
+		IssueDate(date(2025, 1, 15)).
+		DueDate(date(2025, 2, 14)).
+		Currency("EUR").
+		Supplier(invoice.Party{Name: "Supplier GmbH", Address: invoice.Address{City: "Berlin", Country: "Germany"}}).
+		Customer(invoice.Party{Name: "Customer AG", Address: invoice.Address{City: "Basel", Country: "Switzerland"}}).
+		AddItem(invoice.NewLineItem("Consulting", 1, invoice.NewMoney(100, "EUR"), 19)).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	renderer := render.NewSimpleRenderer()
+	renderer.Options = opts
+	pdf, err := renderer.RenderInvoice(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return pdf
+}
+
+func TestSignAndVerify(t *testing.T) {
+	root, rootKey := testCA(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	signer := &Signer{
+		Key:         key,
+		Certificate: testCertificate(t, "Jane Doe", key, root, rootKey),
+		Chain:       []*x509.Certificate{root},
+		Reason:      "Invoice issued",
+		Location:    "Zürich",
+		Time:        time.Date(2025, 1, 15, 10, 30, 0, 0, time.FixedZone("", 3600)),
+	}
+	pdf := testPDF(t, render.Options{})
+	signed, err := signer.Sign(pdf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(signed, pdf) {
+		t.Fatal("expected the signature to be appended as an incremental update")
+	}
+	for _, want := range []string{"/SubFilter /ETSI.CAdES.detached", "/AcroForm << /Fields [", "/SigFlags 3", "/Annots ["} {
+		if !bytes.Contains(signed, []byte(want)) {
+			t.Errorf("expected %s in the PDF", want)
+		}
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	sigs, err := Verify(signed, VerifyOptions{Roots: roots})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sigs) != 1 {
+		t.Fatalf("expected 1 signature, got %d", len(sigs))
+	}
+	sig := sigs[0]
+	if sig.Name != "Jane Doe" || sig.Reason != "Invoice issued" || sig.Location != "Zürich" {
+		t.Errorf("unexpected signature details: %q, %q, %q", sig.Name, sig.Reason, sig.Location)
+	}
+	if !sig.Time.Equal(signer.Time) {
+		t.Errorf("expected signing time %v, got %v", signer.Time, sig.Time)
+	}
+	if !sig.CoversDocument || !sig.Timestamp.IsZero() {
+		t.Errorf("expected an untimestamped signature of the whole document, got %+v", sig)
+	}
+	if !sig.Certificate.Equal(signer.Certificate) || len(sig.Chain) != 1 {
+		t.Errorf("expected the certificate and chain, got %v and %d", sig.Certificate.Subject, len(sig.Chain))
+	}
+
+	if _, err := Verify(signed, VerifyOptions{Roots: x509.NewCertPool()}); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("expected ErrUntrusted, got %v", err)
+	}
+	if _, err := Verify(signed, VerifyOptions{Roots: roots, Time: date(2040, 1, 1)}); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("expected ErrUntrusted for an expired certificate, got %v", err)
+	}
+	if _, err := Verify(pdf, VerifyOptions{}); !errors.Is(err, ErrNoSignature) {
+		t.Errorf("expected ErrNoSignature, got %v", err)
+	}
+
+	tampered := bytes.Clone(signed)
+	i := bytes.Index(tampered, []byte("/Author "))
+	tampered[i+len("/Author ")+8]++
+	if _, err := Verify(tampered, VerifyOptions{}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for a changed document, got %v", err)
+	}
+
+	appended := append(bytes.Clone(signed), "% comment\n"...)
+	sigs, err = Verify(appended, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sigs[0].CoversDocument {
+		t.Error("expected the signature not to cover data appended after signing")
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	root, rootKey := testCA(t)
+	tsaKey := testKey(t)
+	tsa := &StubTimestamper{
+		Key:         tsaKey,
+		Certificate: testCertificate(t, "Test TSA", tsaKey, root, rootKey, x509.ExtKeyUsageTimeStamping),
+		Time:        time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC),
+	}
+	key := testKey(t)
+	cert := testCertificate(t, "Jane Doe", key, root, rootKey)
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	pdf := testPDF(t, render.Options{})
+
+	server := httptest.NewServer(tsa)
+	defer server.Close()
+	for name, ts := range map[string]Timestamper{"stub": tsa, "http": NewTSAClient(server.URL)} {
+		signed, err := (&Signer{Key: key, Certificate: cert, Timestamper: ts}).Sign(pdf)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		sigs, err := Verify(signed, VerifyOptions{Roots: roots})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !sigs[0].Timestamp.Equal(tsa.Time) || sigs[0].TimestampAuthority.Subject.CommonName != "Test TSA" {
+			t.Errorf("%s: expected a timestamp at %v, got %v", name, tsa.Time, sigs[0].Timestamp)
+		}
+	}
+
+	// Tokens must be issued with a certificate for timestamping.
+	bad := &StubTimestamper{Key: key, Certificate: cert}
+	if _, err := (&Signer{Key: key, Certificate: cert, Timestamper: bad}).Sign(pdf); !errors.Is(err, ErrTimestamp) {
+		t.Errorf("expected ErrTimestamp, got %v", err)
+	}
+}
+
+// growingTimestamper issues tokens with ever larger certificates, so that
+// the space reserved for the signature is never enough.
+type growingTimestamper struct {
+	t       *testing.T
+	root    *x509.Certificate
+	rootKey crypto.Signer
+	key     crypto.Signer
+	calls   int
+}
+
+func (g *growingTimestamper) Timestamp(digest []byte) ([]byte, error) {
+	g.calls++
+	name := strings.Repeat("Test TSA ", 2000*g.calls)
+	cert := testCertificate(g.t, name, g.key, g.root, g.rootKey, x509.ExtKeyUsageTimeStamping)
+	return (&StubTimestamper{Key: g.key, Certificate: cert}).Timestamp(digest)
+}
+
+func TestSignNestedPageTree(t *testing.T) {
+	// The page of the rendered PDF is moved under an intermediate node.
+	u, err := pdfupdate.Parse(testPDF(t, render.Options{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pages, err := u.Pages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page, err := u.Object(pages[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	catalog, err := u.Object(u.Root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	root, _ := pdfupdate.Ref(pdfupdate.Dict(catalog)["Pages"])
+	node := u.Reserve()
+	u.Set(node, fmt.Sprintf("<< /Type /Pages /Parent %d 0 R /Kids [%d 0 R] /Count 1 >>", root, pages[0]))
+	u.Set(root, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 /MediaBox [0 0 595.28 841.89] >>", node))
+	u.Set(pages[0], strings.Replace(page, fmt.Sprintf("/Parent %d 0 R", root), fmt.Sprintf("/Parent %d 0 R", node), 1))
+
+	root2, rootKey := testCA(t)
+	key := testKey(t)
+	signer := &Signer{Key: key, Certificate: testCertificate(t, "Jane Doe", key, root2, rootKey)}
+	signed, err := signer.Sign(u.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root2)
+	if _, err := Verify(signed, VerifyOptions{Roots: roots}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u, err = pdfupdate.Parse(signed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj, _ := u.Object(node); strings.Contains(obj, "/Annots") {
+		t.Errorf("expected no annotations on the page tree node, got %s", obj)
+	}
+	if obj, _ := u.Object(pages[0]); !strings.Contains(obj, "/Annots") {
+		t.Errorf("expected the signature field on the page, got %s", obj)
+	}
+}
+
+func TestSignatureSize(t *testing.T) {
+	root, rootKey := testCA(t)
+	key := testKey(t)
+	cert := testCertificate(t, "Jane Doe", key, root, rootKey)
+	ts := &growingTimestamper{t: t, root: root, rootKey: rootKey, key: testKey(t)}
+	signed, err := (&Signer{Key: key, Certificate: cert, Timestamper: ts}).Sign(testPDF(t, render.Options{}))
+	if !errors.Is(err, ErrSignatureSize) || signed != nil {
+		t.Errorf("expected ErrSignatureSize and no PDF, got %v", err)
+	}
+	if ts.calls != 2 {
+		t.Errorf("expected two attempts, got %d", ts.calls)
+	}
+}
+
+func TestSignTwice(t *testing.T) {
+	root, rootKey := testCA(t)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ecKey := testKey(t)
+	first := &Signer{Key: ecKey, Certificate: testCertificate(t, "Jane Doe", ecKey, root, rootKey)}
+	second := &Signer{Key: edKey, Certificate: testCertificate(t, "John Roe", edKey, root, rootKey), Name: "Approval"}
+
+	// Tagged documents have been updated before they are signed.
+	signed, err := first.Sign(testPDF(t, render.Options{Tagged: true}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	signed, err = second.Sign(signed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sigs, err := Verify(signed, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sigs) != 2 {
+		t.Fatalf("expected 2 signatures, got %d", len(sigs))
+	}
+	if sigs[0].CoversDocument || !sigs[1].CoversDocument {
+		t.Error("expected only the second signature to cover the whole document")
+	}
+	if sigs[0].Name != "Jane Doe" || sigs[1].Name != "Approval" {
+		t.Errorf("expected the signatures in order, got %q and %q", sigs[0].Name, sigs[1].Name)
+	}
+	if !bytes.Contains(signed, []byte("/Fields [")) || bytes.Count(signed, []byte("/FT /Sig")) != 2 {
+		t.Error("expected two signature fields")
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	root, rootKey := testCA(t)
+	key := testKey(t)
+	cert := testCertificate(t, "Jane Doe", key, root, rootKey)
+	pdf := testPDF(t, render.Options{})
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var pemData []byte
+	pemData = append(pemData, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	pemData = append(pemData, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw})...)
+	pemData = append(pemData, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})...)
+	fromPEM, err := LoadPEM(pemData, pemData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fromPEM.Certificate.Equal(cert) || len(fromPEM.Chain) != 1 {
+		t.Errorf("expected the certificate and its chain, got %v", fromPEM.Certificate.Subject)
+	}
+
+	p12, err := pkcs12.Modern.Encode(key, cert, []*x509.Certificate{root}, "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fromP12, err := LoadPKCS12(p12, "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := LoadPKCS12(p12, "wrong"); err == nil {
+		t.Error("expected an error for a wrong password")
+	}
+
+	for _, s := range []*Signer{fromPEM, fromP12} {
+		signed, err := s.Sign(pdf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := Verify(signed, VerifyOptions{}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if _, err := LoadPEM(pemData[bytes.Index(pemData, []byte("-----BEGIN PRIVATE")):], pemData); !errors.Is(err, ErrNoCertificate) {
+		t.Errorf("expected ErrNoCertificate, got %v", err)
+	}
+	mismatched := &Signer{Key: testKey(t), Certificate: cert}
+	if _, err := mismatched.Sign(pdf); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("expected ErrKeyMismatch, got %v", err)
+	}
+}
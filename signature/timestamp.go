@@ -0,0 +1,257 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrTimestamp is returned if a timestamp cannot be obtained or is invalid.
+var ErrTimestamp = errors.New("invalid timestamp")
+
+// Timestamper obtains RFC 3161 timestamp tokens, which prove that a
+// signature existed at the time given by a timestamp authority.
+type Timestamper interface {
+	// Timestamp returns a DER-encoded TimeStampToken for a SHA-256 digest.
+	Timestamp(digest []byte) ([]byte, error)
+}
+
+// messageImprint is the digest a timestamp is issued for.
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// pkiStatusInfo is the status of a response, whose optional text and
+// failure information are not read.
+type pkiStatusInfo struct {
+	Status int
+}
+
+// tstInfo is the content of a timestamp token.
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Accuracy       accuracy  `asn1:"optional"`
+	Ordering       bool      `asn1:"optional"`
+	Nonce          *big.Int  `asn1:"optional"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// TSAClient requests timestamps from an RFC 3161 timestamp authority over
+// HTTP.
+type TSAClient struct {
+	HTTPClient *http.Client
+	URL        string
+	// Policy, if set, requests timestamps under a policy of the authority.
+	Policy asn1.ObjectIdentifier
+}
+
+// NewTSAClient creates a client of the timestamp authority at the URL.
+func NewTSAClient(url string) *TSAClient {
+	return &TSAClient{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		URL:        url,
+	}
+}
+
+// Timestamp requests a timestamp token for the digest.
+func (c *TSAClient) Timestamp(digest []byte) ([]byte, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	body, err := asn1.Marshal(timeStampReq{
+		Version:        1,
+		MessageImprint: messageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOID(crypto.SHA256)}, HashedMessage: digest},
+		ReqPolicy:      c.Policy,
+		Nonce:          nonce,
+		CertReq:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/timestamp-query")
+	req.Header.Set("Accept", "application/timestamp-reply")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: request failed: %w", ErrTimestamp, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: request failed: %s", ErrTimestamp, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: request failed: %w", ErrTimestamp, err)
+	}
+
+	var out timeStampResp
+	if _, err := asn1.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %w", ErrTimestamp, err)
+	}
+	// 0 is granted and 1 granted with modifications.
+	if out.Status.Status > 1 {
+		return nil, fmt.Errorf("%w: request rejected with status %d", ErrTimestamp, out.Status.Status)
+	}
+	token := out.TimeStampToken.FullBytes
+	info, _, err := verifyTimestamp(token, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return nil, fmt.Errorf("%w: token for another digest", ErrTimestamp)
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, fmt.Errorf("%w: nonce does not match", ErrTimestamp)
+	}
+	return token, nil
+}
+
+// verifyTimestamp checks the signature of a timestamp token and, if data
+// is not nil, that it was issued for data.
+func verifyTimestamp(token, data []byte) (*tstInfo, *x509.Certificate, error) {
+	v, err := verifySignedData(token, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrTimestamp, err)
+	}
+	if !v.contentType.Equal(oidTSTInfo) {
+		return nil, nil, fmt.Errorf("%w: content type %v", ErrTimestamp, v.contentType)
+	}
+	if !slices.Contains(v.certificate.ExtKeyUsage, x509.ExtKeyUsageTimeStamping) {
+		return nil, nil, fmt.Errorf("%w: certificate %q is not for timestamping", ErrTimestamp, v.certificate.Subject.CommonName)
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(v.content, &info); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrTimestamp, err)
+	}
+	if data != nil {
+		h, err := hashFor(info.MessageImprint.HashAlgorithm)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrTimestamp, err)
+		}
+		if !bytes.Equal(info.MessageImprint.HashedMessage, digest(h, data)) {
+			return nil, nil, fmt.Errorf("%w: token for other data", ErrTimestamp)
+		}
+	}
+	return &info, v.certificate, nil
+}
+
+// StubTimestamper is a Timestamper that issues tokens itself, for tests and
+// offline use. Its tokens are only trusted where its certificate is, which
+// must be for timestamping.
+type StubTimestamper struct {
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+	// Time is the time of the tokens; the current time if zero.
+	Time time.Time
+
+	mu     sync.Mutex
+	serial int64
+}
+
+// Timestamp issues a timestamp token for the digest.
+func (s *StubTimestamper) Timestamp(digest []byte) ([]byte, error) {
+	return s.issue(digest, nil)
+}
+
+// ServeHTTP answers RFC 3161 timestamp requests, so that the stub can stand
+// in for a timestamp authority.
+func (s *StubTimestamper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<16))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req timeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := timeStampResp{}
+	if h, err := hashFor(req.MessageImprint.HashAlgorithm); err != nil || h != crypto.SHA256 {
+		// 2 is rejection.
+		resp.Status.Status = 2
+	} else {
+		token, err := s.issue(req.MessageImprint.HashedMessage, req.Nonce)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.TimeStampToken = asn1.RawValue{FullBytes: token}
+	}
+	out, err := asn1.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(out)
+}
+
+// issue returns a timestamp token for the digest.
+func (s *StubTimestamper) issue(digest []byte, nonce *big.Int) ([]byte, error) {
+	s.mu.Lock()
+	s.serial++
+	serial := s.serial
+	s.mu.Unlock()
+
+	t := s.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	content, err := asn1.Marshal(tstInfo{
+		Version: 1,
+		// The policy is under the arc reserved for examples.
+		Policy:         asn1.ObjectIdentifier{2, 999, 1},
+		MessageImprint: messageImprint{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOID(crypto.SHA256)}, HashedMessage: digest},
+		SerialNumber:   big.NewInt(serial),
+		GenTime:        t.UTC().Truncate(time.Second),
+		Nonce:          nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	si, err := newSignerInfo(s.Key, s.Certificate, content, oidTSTInfo)
+	if err != nil {
+		return nil, err
+	}
+	return marshalSignedData(si, []*x509.Certificate{s.Certificate}, content, oidTSTInfo, true)
+}
@@ -0,0 +1,274 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+var (
+	// ErrNoSignature is returned for PDFs that are not signed.
+	ErrNoSignature = errors.New("no signature")
+	// ErrInvalidSignature is returned if a signature does not match the
+	// document or cannot be read.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrUntrusted is returned if a certificate does not chain up to a
+	// trusted root.
+	ErrUntrusted = errors.New("untrusted certificate")
+)
+
+var (
+	byteRangePattern = regexp.MustCompile(`/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]`)
+	subFilterPattern = regexp.MustCompile(`/SubFilter\s*/([A-Za-z0-9.]+)`)
+	datePattern      = regexp.MustCompile(`^D:(\d{14})(Z|([+-])(\d{2})'(\d{2})'?)?`)
+)
+
+// VerifyOptions configure the checks of Verify.
+type VerifyOptions struct {
+	// Roots are the trusted root certificates. If nil, only the integrity
+	// of the signatures is checked, not who made them.
+	Roots *x509.CertPool
+	// Time is when the certificates must have been valid. If zero, it is
+	// the time of the signature timestamp or, without one, the current
+	// time.
+	Time time.Time
+}
+
+// Signature is a verified signature of a PDF.
+type Signature struct {
+	// Certificate is the certificate of the signer and Chain the other
+	// certificates included in the signature.
+	Certificate *x509.Certificate
+	Chain       []*x509.Certificate
+	Name        string
+	Reason      string
+	Location    string
+	ContactInfo string
+	// Time is the signing time claimed by the signer.
+	Time time.Time
+	// Timestamp is the time of the signature timestamp, which is zero if
+	// there is none, and TimestampAuthority the certificate it was issued
+	// with.
+	Timestamp          time.Time
+	TimestampAuthority *x509.Certificate
+	// CoversDocument reports whether the signature covers the whole file.
+	// It does not if the file was updated after signing.
+	CoversDocument bool
+}
+
+// Verify checks the signatures of a PDF in the order they were added. It
+// fails if there is none or if any does not match the document.
+func Verify(pdf []byte, opts VerifyOptions) ([]Signature, error) {
+	matches := byteRangePattern.FindAllSubmatchIndex(pdf, -1)
+	if len(matches) == 0 {
+		return nil, ErrNoSignature
+	}
+	sigs := make([]Signature, 0, len(matches))
+	for i, m := range matches {
+		var r [4]int
+		for j := range r {
+			r[j], _ = strconv.Atoi(string(pdf[m[2+2*j]:m[3+2*j]]))
+		}
+		sig, err := verify(pdf, r, signatureDictionary(pdf, m[0]), opts)
+		if err != nil {
+			return nil, fmt.Errorf("signature %d: %w", i+1, err)
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs, nil
+}
+
+// verify checks the signature over a byte range of the PDF.
+func verify(pdf []byte, r [4]int, dict string, opts VerifyOptions) (Signature, error) {
+	if r[0] != 0 || r[1] < 2 || r[2] < r[1]+2 || r[2]+r[3] > len(pdf) {
+		return Signature{}, fmt.Errorf("%w: bad byte range %v", ErrInvalidSignature, r)
+	}
+	contents := pdf[r[1]:r[2]]
+	if contents[0] != '<' || contents[len(contents)-1] != '>' {
+		return Signature{}, fmt.Errorf("%w: byte range does not exclude the signature", ErrInvalidSignature)
+	}
+	der := make([]byte, hex.DecodedLen(len(contents)-2))
+	if _, err := hex.Decode(der, contents[1:len(contents)-1]); err != nil {
+		return Signature{}, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	signed := make([]byte, 0, r[1]+r[3])
+	signed = append(append(signed, pdf[:r[1]]...), pdf[r[2]:r[2]+r[3]]...)
+	v, err := verifySignedData(der, signed)
+	if err != nil {
+		return Signature{}, err
+	}
+	if !v.contentType.Equal(oidData) {
+		return Signature{}, fmt.Errorf("%w: content type %v", ErrInvalidSignature, v.contentType)
+	}
+	// CAdES signatures must identify the certificate of the signer.
+	if m := subFilterPattern.FindStringSubmatch(dict); m != nil && m[1] == "ETSI.CAdES.detached" {
+		var ess signingCertificateV2
+		if err := v.attribute(oidSigningCertificateV2, &ess); err != nil {
+			return Signature{}, err
+		}
+	}
+
+	sig := Signature{
+		Certificate:    v.certificate,
+		Name:           dictText(dict, "Name"),
+		Reason:         dictText(dict, "Reason"),
+		Location:       dictText(dict, "Location"),
+		ContactInfo:    dictText(dict, "ContactInfo"),
+		Time:           parsePDFDate(dictText(dict, "M")),
+		CoversDocument: r[2]+r[3] == len(pdf),
+	}
+	for _, c := range v.certificates {
+		if c != v.certificate {
+			sig.Chain = append(sig.Chain, c)
+		}
+	}
+	var token asn1.RawValue
+	if err := attributeValue(v.unsigned, oidTimeStampToken, &token); err == nil {
+		info, tsa, err := verifyTimestamp(token.FullBytes, v.signature)
+		if err != nil {
+			return Signature{}, err
+		}
+		sig.Timestamp, sig.TimestampAuthority = info.GenTime, tsa
+	} else if !errors.Is(err, errNoAttribute) {
+		return Signature{}, err
+	}
+
+	if opts.Roots != nil {
+		at := opts.Time
+		if at.IsZero() {
+			at = sig.Timestamp
+		}
+		if at.IsZero() {
+			at = time.Now()
+		}
+		intermediates := x509.NewCertPool()
+		for _, c := range sig.Chain {
+			intermediates.AddCert(c)
+		}
+		if _, err := sig.Certificate.Verify(x509.VerifyOptions{
+			Roots:         opts.Roots,
+			Intermediates: intermediates,
+			CurrentTime:   at,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			return Signature{}, fmt.Errorf("%w: %w", ErrUntrusted, err)
+		}
+		if sig.TimestampAuthority != nil {
+			if _, err := sig.TimestampAuthority.Verify(x509.VerifyOptions{
+				Roots:       opts.Roots,
+				CurrentTime: sig.Timestamp,
+				KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+			}); err != nil {
+				return Signature{}, fmt.Errorf("%w: timestamp: %w", ErrUntrusted, err)
+			}
+		}
+	}
+	return sig, nil
+}
+
+// signatureDictionary returns the text of the object around an offset.
+func signatureDictionary(pdf []byte, off int) string {
+	start := bytes.LastIndex(pdf[:off], []byte(" obj"))
+	end := bytes.Index(pdf[off:], []byte("endobj"))
+	if start < 0 || end < 0 {
+		return ""
+	}
+	return string(pdf[start : off+end])
+}
+
+// dictText returns a string entry of a dictionary, written as a literal or
+// hexadecimal string.
+func dictText(dict, key string) string {
+	_, rest, ok := strings.Cut(dict, "/"+key+" ")
+	if !ok {
+		_, rest, ok = strings.Cut(dict, "/"+key+"(")
+		rest = "(" + rest
+	}
+	if !ok || rest == "" {
+		return ""
+	}
+	switch rest[0] {
+	case '<':
+		s, _, _ := strings.Cut(rest[1:], ">")
+		b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+		if err != nil {
+			return ""
+		}
+		return decodeText(b)
+	case '(':
+		var b []byte
+		depth := 0
+		for i := 1; i < len(rest); i++ {
+			switch c := rest[i]; c {
+			case '\\':
+				if i++; i < len(rest) {
+					if e := strings.IndexByte("nrtbf", rest[i]); e >= 0 {
+						b = append(b, "\n\r\t\b\f"[e])
+					} else {
+						b = append(b, rest[i])
+					}
+				}
+			case '(':
+				depth++
+				b = append(b, c)
+			case ')':
+				if depth == 0 {
+					return decodeText(b)
+				}
+				depth--
+				b = append(b, c)
+			default:
+				b = append(b, c)
+			}
+		}
+	}
+	return ""
+}
+
+// decodeText decodes a PDF text string, which is UTF-16 with a byte order
+// mark or, for the Latin characters used here, PDFDocEncoding.
+func decodeText(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+// parsePDFDate parses a PDF date, returning the zero time if it is invalid.
+func parsePDFDate(s string) time.Time {
+	m := datePattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}
+	}
+	loc := time.UTC
+	if m[3] != "" {
+		h, _ := strconv.Atoi(m[4])
+		mins, _ := strconv.Atoi(m[5])
+		offset := h*3600 + mins*60
+		if m[3] == "-" {
+			offset = -offset
+		}
+		loc = time.FixedZone("", offset)
+	}
+	t, err := time.ParseInLocation("20060102150405", m[1], loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
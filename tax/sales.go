@@ -0,0 +1,326 @@
+package tax
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// Sales tax errors.
+var (
+	ErrUnknownJurisdiction = errors.New("no sales tax jurisdiction found for address")
+	ErrInvalidRateTable    = errors.New("invalid sales tax rate table")
+)
+
+// Jurisdiction holds the combined US sales tax rates for a tax region.
+// Rates are percentages, e.g. 6.25 for 6.25%.
+type Jurisdiction struct {
+	State       string          `json:"state"`
+	ZIPCode     string          `json:"zip_code"`
+	RegionName  string          `json:"region_name,omitempty"`
+	StateRate   decimal.Decimal `json:"state_rate"`
+	CountyRate  decimal.Decimal `json:"county_rate"`
+	CityRate    decimal.Decimal `json:"city_rate"`
+	SpecialRate decimal.Decimal `json:"special_rate"`
+}
+
+// CombinedRate returns the sum of the state, county, city and special
+// district rates.
+func (j Jurisdiction) CombinedRate() decimal.Decimal {
+	return j.StateRate.Add(j.CountyRate).Add(j.CityRate).Add(j.SpecialRate)
+}
+
+// LocalRate returns the combined county, city and special district rates.
+func (j Jurisdiction) LocalRate() decimal.Decimal {
+	return j.CountyRate.Add(j.CityRate).Add(j.SpecialRate)
+}
+
+// RateTable maps five-digit ZIP codes to sales tax jurisdictions.
+type RateTable struct {
+	jurisdictions map[string]Jurisdiction
+}
+
+// NewRateTable creates an empty sales tax rate table.
+func NewRateTable() *RateTable {
+	return &RateTable{jurisdictions: make(map[string]Jurisdiction)}
+}
+
+// Add adds or replaces the jurisdiction for its ZIP code.
+func (t *RateTable) Add(j Jurisdiction) *RateTable {
+	j.State = strings.ToUpper(strings.TrimSpace(j.State))
+	j.ZIPCode = normalizeZIP(j.ZIPCode)
+	t.jurisdictions[j.ZIPCode] = j
+	return t
+}
+
+// Len returns the number of jurisdictions in the table.
+func (t *RateTable) Len() int {
+	return len(t.jurisdictions)
+}
+
+// Lookup returns the jurisdiction for the given address by its ZIP code.
+func (t *RateTable) Lookup(addr invoice.Address) (Jurisdiction, error) {
+	j, ok := t.jurisdictions[normalizeZIP(addr.PostalCode)]
+	if !ok {
+		return Jurisdiction{}, fmt.Errorf("%w: %q", ErrUnknownJurisdiction, addr.PostalCode)
+	}
+	if addr.State != "" && !strings.EqualFold(addr.State, j.State) {
+		return Jurisdiction{}, fmt.Errorf("%w: ZIP %s is in %s, not %s", ErrUnknownJurisdiction, j.ZIPCode, j.State, addr.State)
+	}
+	return j, nil
+}
+
+// rateTableColumns lists accepted header names for each rate table column.
+var rateTableColumns = map[string][]string{
+	"state":    {"state"},
+	"zip":      {"zipcode", "zip", "zip_code", "postalcode"},
+	"region":   {"taxregionname", "region", "region_name"},
+	"state%":   {"staterate", "state_rate"},
+	"county%":  {"estimatedcountyrate", "countyrate", "county_rate"},
+	"city%":    {"estimatedcityrate", "cityrate", "city_rate"},
+	"special%": {"estimatedspecialrate", "specialrate", "special_rate"},
+}
+
+// LoadRateTableCSV reads a rate table in the ZIP-level CSV layout commonly
+// published for US sales tax (State, ZipCode, TaxRegionName, StateRate,
+// EstimatedCountyRate, EstimatedCityRate, EstimatedSpecialRate, ...).
+// Columns are matched by header name and rates are decimal fractions,
+// e.g. 0.0625 for 6.25%.
+func LoadRateTableCSV(r io.Reader) (*RateTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading header: %v", ErrInvalidRateTable, err)
+	}
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, aliases := range rateTableColumns {
+			for _, alias := range aliases {
+				if name == alias {
+					index[column] = i
+				}
+			}
+		}
+	}
+	for _, required := range []string{"state", "zip", "state%"} {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("%w: missing %s column", ErrInvalidRateTable, required)
+		}
+	}
+
+	table := NewRateTable()
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidRateTable, line, err)
+		}
+		field := func(column string) string {
+			i, ok := index[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		rate := func(column string) (decimal.Decimal, error) {
+			s := field(column)
+			if s == "" {
+				return decimal.Zero, nil
+			}
+			d, err := decimal.NewFromString(s)
+			if err != nil {
+				return decimal.Zero, fmt.Errorf("%w: line %d: invalid %s %q", ErrInvalidRateTable, line, column, s)
+			}
+			return d.Mul(decimal.NewFromInt(100)), nil
+		}
+
+		j := Jurisdiction{
+			State:      field("state"),
+			ZIPCode:    field("zip"),
+			RegionName: field("region"),
+		}
+		if j.State == "" || j.ZIPCode == "" {
+			return nil, fmt.Errorf("%w: line %d: state and ZIP code are required", ErrInvalidRateTable, line)
+		}
+		if j.StateRate, err = rate("state%"); err != nil {
+			return nil, err
+		}
+		if j.CountyRate, err = rate("county%"); err != nil {
+			return nil, err
+		}
+		if j.CityRate, err = rate("city%"); err != nil {
+			return nil, err
+		}
+		if j.SpecialRate, err = rate("special%"); err != nil {
+			return nil, err
+		}
+		table.Add(j)
+	}
+	return table, nil
+}
+
+func normalizeZIP(zip string) string {
+	zip = strings.TrimSpace(zip)
+	if i := strings.IndexByte(zip, '-'); i >= 0 {
+		zip = zip[:i]
+	}
+	if len(zip) < 5 {
+		zip = strings.Repeat("0", 5-len(zip)) + zip
+	}
+	return zip
+}
+
+// TaxabilityRule overrides how a product category is taxed in a state.
+type TaxabilityRule struct {
+	State    string `json:"state"`
+	Category string `json:"category"`
+	Exempt   bool   `json:"exempt,omitempty"`
+	// StateRate replaces the state portion of the combined rate when set,
+	// for categories taxed at a reduced state rate.
+	StateRate *decimal.Decimal `json:"state_rate,omitempty"`
+	// ExcludeLocal drops county, city and special district rates.
+	ExcludeLocal bool `json:"exclude_local,omitempty"`
+}
+
+// SalesTaxResult describes the rate resolved for a single line item.
+type SalesTaxResult struct {
+	Jurisdiction Jurisdiction    `json:"jurisdiction"`
+	Category     string          `json:"category,omitempty"`
+	Rate         decimal.Decimal `json:"rate"`
+	Exempt       bool            `json:"exempt"`
+	Reason       string          `json:"reason,omitempty"`
+	Certificate  string          `json:"certificate,omitempty"`
+}
+
+// SalesTaxEngine resolves US sales tax rates for invoice line items from a
+// rate table, the seller's nexus states, product taxability rules and the
+// customer's exemption certificates.
+type SalesTaxEngine struct {
+	rates      *RateTable
+	nexus      map[string]bool
+	taxability map[string]TaxabilityRule
+}
+
+// NewSalesTaxEngine creates a sales tax engine backed by the given rate table.
+// Tax is only collected in states added with AddNexus.
+func NewSalesTaxEngine(rates *RateTable) *SalesTaxEngine {
+	return &SalesTaxEngine{
+		rates:      rates,
+		nexus:      make(map[string]bool),
+		taxability: make(map[string]TaxabilityRule),
+	}
+}
+
+// AddNexus declares that the seller has nexus in the given states.
+func (e *SalesTaxEngine) AddNexus(states ...string) *SalesTaxEngine {
+	for _, s := range states {
+		e.nexus[strings.ToUpper(strings.TrimSpace(s))] = true
+	}
+	return e
+}
+
+// HasNexus returns true if the seller has nexus in the given state.
+func (e *SalesTaxEngine) HasNexus(state string) bool {
+	return e.nexus[strings.ToUpper(state)]
+}
+
+// AddTaxability adds a product taxability rule.
+func (e *SalesTaxEngine) AddTaxability(rule TaxabilityRule) *SalesTaxEngine {
+	rule.State = strings.ToUpper(rule.State)
+	e.taxability[rule.State+"|"+rule.Category] = rule
+	return e
+}
+
+// Resolve returns the sales tax rate for a product category shipped to the
+// customer on the given date.
+func (e *SalesTaxEngine) Resolve(customer invoice.Party, category string, on time.Time) (SalesTaxResult, error) {
+	result := SalesTaxResult{Category: category, Rate: decimal.Zero}
+
+	addr := customer.ShippingAddress()
+	if strings.TrimSpace(addr.Country) == "" {
+		return result, fmt.Errorf("%w: ship-to address has no country", ErrUnknownJurisdiction)
+	}
+	if !isUSCountry(addr.Country) {
+		result.Exempt = true
+		result.Reason = "ship-to address outside the United States"
+		return result, nil
+	}
+
+	j, err := e.rates.Lookup(addr)
+	if err != nil {
+		return result, err
+	}
+	result.Jurisdiction = j
+
+	if !e.HasNexus(j.State) {
+		result.Exempt = true
+		result.Reason = "no nexus in " + j.State
+		return result, nil
+	}
+	if cert, ok := customer.Exemption(j.State, category, on); ok {
+		result.Exempt = true
+		result.Reason = "exemption certificate"
+		if cert.Reason != "" {
+			result.Reason += ": " + cert.Reason
+		}
+		result.Certificate = cert.CertificateID
+		return result, nil
+	}
+
+	stateRate, localRate := j.StateRate, j.LocalRate()
+	if rule, ok := e.taxability[j.State+"|"+category]; ok && category != "" {
+		if rule.Exempt {
+			result.Exempt = true
+			result.Reason = category + " is exempt in " + j.State
+			return result, nil
+		}
+		if rule.StateRate != nil {
+			stateRate = *rule.StateRate
+		}
+		if rule.ExcludeLocal {
+			localRate = decimal.Zero
+		}
+	}
+	result.Rate = stateRate.Add(localRate)
+	return result, nil
+}
+
+// Apply resolves the sales tax rate for every line item of the invoice and
+// sets each item's TaxRate accordingly. The invoice issue date is used to
+// check exemption certificate validity. If any item cannot be resolved,
+// the invoice is left unchanged.
+func (e *SalesTaxEngine) Apply(inv *invoice.Invoice) ([]SalesTaxResult, error) {
+	results := make([]SalesTaxResult, len(inv.LineItems))
+	for i, item := range inv.LineItems {
+		result, err := e.Resolve(inv.Customer, item.TaxCategory, inv.IssueDate)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	for i, result := range results {
+		inv.LineItems[i].TaxRate = result.Rate
+	}
+	return results, nil
+}
+
+func isUSCountry(country string) bool {
+	switch strings.ToUpper(strings.TrimSpace(country)) {
+	case "US", "USA", "UNITED STATES", "UNITED STATES OF AMERICA":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package tax
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+const testRates = `State,ZipCode,TaxRegionName,EstimatedCombinedRate,StateRate,EstimatedCountyRate,EstimatedCityRate,EstimatedSpecialRate,RiskLevel
+CA,90001,LOS ANGELES,0.0950,0.0600,0.0025,0.0000,0.0325,1
+NY,10001,NEW YORK CITY,0.08875,0.0400,0.0000,0.04500,0.00375,1
+PA,19103,PHILADELPHIA,0.0800,0.0600,0.0000,0.0200,0.0000,1
+`
+
+func testEngine(t *testing.T) *SalesTaxEngine {
+	t.Helper()
+	table, err := LoadRateTableCSV(strings.NewReader(testRates))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if table.Len() != 3 {
+		t.Fatalf("expected 3 jurisdictions, got %d", table.Len())
+	}
+	return NewSalesTaxEngine(table).
+		AddNexus("CA", "PA").
+		AddTaxability(TaxabilityRule{State: "PA", Category: "clothing", Exempt: true})
+}
+
+func customerIn(zip, state string) invoice.Party {
+	return invoice.Party{
+		Name:    "Customer",
+		Address: invoice.Address{Street: "1 Main St", City: "X", State: state, PostalCode: zip, Country: "US"},
+	}
+}
+
+func TestSalesTaxCombinedRate(t *testing.T) {
+	result, err := testEngine(t).Resolve(customerIn("90001", "CA"), "", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Rate.Equal(decimal.NewFromFloat(9.5)) {
+		t.Errorf("expected rate 9.5, got %s", result.Rate)
+	}
+}
+
+func TestSalesTaxNexusAndTaxability(t *testing.T) {
+	engine := testEngine(t)
+
+	result, _ := engine.Resolve(customerIn("10001", "NY"), "", time.Now())
+	if !result.Exempt || !result.Rate.IsZero() {
+		t.Errorf("expected no tax without nexus, got %s", result.Rate)
+	}
+
+	result, _ = engine.Resolve(customerIn("19103", "PA"), "clothing", time.Now())
+	if !result.Exempt {
+		t.Errorf("expected clothing to be exempt in PA, got %s", result.Rate)
+	}
+
+	result, _ = engine.Resolve(customerIn("19103", "PA"), "electronics", time.Now())
+	if !result.Rate.Equal(decimal.NewFromInt(8)) {
+		t.Errorf("expected rate 8, got %s", result.Rate)
+	}
+}
+
+func TestSalesTaxShipToAndExemption(t *testing.T) {
+	engine := testEngine(t)
+	customer := customerIn("10001", "NY")
+	customer.ShipTo = &invoice.Address{Street: "2 Ave", City: "LA", State: "CA", PostalCode: "90001-1234", Country: "US"}
+
+	result, err := engine.Resolve(customer, "", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Jurisdiction.State != "CA" {
+		t.Errorf("expected ship-to state CA, got %s", result.Jurisdiction.State)
+	}
+
+	customer.TaxExemptions = []invoice.TaxExemption{{
+		CertificateID: "CA-RESALE-1",
+		Jurisdiction:  "CA",
+		Reason:        "resale",
+		ExpiresOn:     time.Now().AddDate(1, 0, 0),
+	}}
+	result, _ = engine.Resolve(customer, "", time.Now())
+	if !result.Exempt || result.Certificate != "CA-RESALE-1" {
+		t.Errorf("expected exemption certificate to apply, got %+v", result)
+	}
+
+	result, _ = engine.Resolve(customer, "", time.Now().AddDate(2, 0, 0))
+	if result.Exempt {
+		t.Error("expected expired exemption certificate to be ignored")
+	}
+}
+
+func TestSalesTaxUnknownZIP(t *testing.T) {
+	_, err := testEngine(t).Resolve(customerIn("99999", "AK"), "", time.Now())
+	if !errors.Is(err, ErrUnknownJurisdiction) {
+		t.Errorf("expected ErrUnknownJurisdiction, got %v", err)
+	}
+}
+
+func TestSalesTaxNoCountry(t *testing.T) {
+	customer := customerIn("90001", "CA")
+	customer.Address.Country = ""
+	_, err := testEngine(t).Resolve(customer, "", time.Now())
+	if !errors.Is(err, ErrUnknownJurisdiction) {
+		t.Errorf("expected ErrUnknownJurisdiction for an address without country, got %v", err)
+	}
+
+	inv := &invoice.Invoice{
+		IssueDate: time.Now(),
+		Currency:  "USD",
+		Customer:  customer,
+		LineItems: []invoice.LineItem{invoice.NewLineItem("Laptop", 1, invoice.NewMoney(1000, "USD"), 5)},
+	}
+	if _, err := testEngine(t).Apply(inv); err == nil {
+		t.Fatal("expected an error")
+	}
+	if !inv.LineItems[0].TaxRate.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("expected the tax rate to be left unchanged, got %s", inv.LineItems[0].TaxRate)
+	}
+}
+
+func TestSalesTaxApply(t *testing.T) {
+	inv := &invoice.Invoice{
+		IssueDate: time.Now(),
+		Currency:  "USD",
+		Customer:  customerIn("19103", "PA"),
+		LineItems: []invoice.LineItem{
+			invoice.NewLineItem("Laptop", 1, invoice.NewMoney(1000, "USD"), 0),
+			invoice.NewLineItem("Jacket", 1, invoice.NewMoney(100, "USD"), 0).WithTaxCategory("clothing"),
+		},
+	}
+	if _, err := testEngine(t).Apply(inv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tax := inv.TotalTax(); tax.Float64() != 80 {
+		t.Errorf("expected tax 80, got %v", tax.Float64())
+	}
+}
@@ -2,7 +2,11 @@
 // for invoice generation across different jurisdictions.
 package tax
 
-import "github.com/shopspring/decimal"
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
 
 // Rate represents a tax rate with a name, percentage, and category.
 type Rate struct {
@@ -71,6 +75,25 @@ func GetRate(code string) (Rate, bool) {
 	return rate, ok
 }
 
+// CategoryFor returns the category of a tax rate percentage in the given
+// country by matching it against CommonRates. Unknown non-zero rates are
+// treated as standard rates.
+func CategoryFor(countryCode string, percentage decimal.Decimal) Category {
+	prefix := strings.ToUpper(countryCode)
+	if prefix == "GB" {
+		prefix = "UK"
+	}
+	for code, rate := range CommonRates {
+		if strings.HasPrefix(code, prefix+"_") && rate.Percentage.Equal(percentage) {
+			return rate.Category
+		}
+	}
+	if percentage.IsZero() {
+		return CategoryZero
+	}
+	return CategoryStandard
+}
+
 // Calculator accumulates multiple tax rates and calculates combined taxes.
 type Calculator struct {
 	rates []Rate
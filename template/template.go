@@ -16,6 +16,7 @@ import (
 	"golang.org/x/text/language"
 
 	"github.com/shopspring/decimal"
+	"github.com/wiederin/go-invoicer/banking"
 	"github.com/wiederin/go-invoicer/currency"
 )
 
@@ -116,6 +117,8 @@ func (m *Manager) defaultFuncMap() template.FuncMap {
 		"formatMoney": func(amount decimal.Decimal, currencyCode string) string {
 			return currency.FormatSimple(amount, currencyCode)
 		},
+		"formatMoneyLocale": currency.FormatLocale,
+		"amountInWords":     currency.Spell,
 		"formatDate": func(t time.Time, layout string) string {
 			if layout == "" {
 				layout = "2006-01-02"
@@ -125,6 +128,19 @@ func (m *Manager) defaultFuncMap() template.FuncMap {
 		"formatDateLong": func(t time.Time) string {
 			return t.Format("January 2, 2006")
 		},
+		"formatIBAN": banking.FormatIBAN,
+		"isQRIBAN":   banking.IsQRIBAN,
+		"validIBAN": func(s string) bool {
+			return banking.ValidateIBAN(s) == nil
+		},
+		"validBIC": func(s string) bool {
+			return banking.ValidateBIC(s) == nil
+		},
+		// imageSrc marks an image source, such as a data URI, as safe to use
+		// in src attributes.
+		"imageSrc": func(src string) template.URL {
+			return template.URL(src)
+		},
 		"upper": strings.ToUpper,
 		"lower": strings.ToLower,
 		"title": func(s string) string {
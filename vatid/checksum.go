@@ -0,0 +1,605 @@
+package vatid
+
+import (
+	"strconv"
+	"strings"
+)
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func digits(s string) []int {
+	d := make([]int, len(s))
+	for i := range s {
+		d[i] = int(s[i] - '0')
+	}
+	return d
+}
+
+// weightedSum returns the sum of d[i]*weights[i] over the weights.
+func weightedSum(d []int, weights ...int) int {
+	sum := 0
+	for i, w := range weights {
+		sum += d[i] * w
+	}
+	return sum
+}
+
+// mod97 returns the remainder of a long decimal string modulo 97.
+func mod97(s string) int {
+	r := 0
+	for i := range s {
+		r = (r*10 + int(s[i]-'0')) % 97
+	}
+	return r
+}
+
+// luhnValid implements the Luhn mod 10 algorithm.
+func luhnValid(s string) bool {
+	sum := 0
+	for i, d := range digits(s) {
+		if (len(s)-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// mod1110Valid implements ISO 7064 MOD 11,10.
+func mod1110Valid(s string) bool {
+	d := digits(s)
+	p := 10
+	for _, v := range d[:len(d)-1] {
+		s := (v + p) % 10
+		if s == 0 {
+			s = 10
+		}
+		p = (2 * s) % 11
+	}
+	check := 11 - p
+	if check == 10 {
+		check = 0
+	}
+	return check == d[len(d)-1]
+}
+
+func validateAT(n string) error {
+	if len(n) != 9 || n[0] != 'U' || !isDigits(n[1:]) {
+		return ErrInvalidFormat
+	}
+	d := digits(n[1:])
+	sum := 0
+	for i := 0; i < 7; i++ {
+		p := d[i] * (1 + i%2)
+		sum += p/10 + p%10
+	}
+	if (10-(sum+4)%10)%10 != d[7] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateBE(n string) error {
+	if len(n) == 9 {
+		n = "0" + n
+	}
+	if len(n) != 10 || !isDigits(n) || (n[0] != '0' && n[0] != '1') {
+		return ErrInvalidFormat
+	}
+	base, _ := strconv.Atoi(n[:8])
+	check, _ := strconv.Atoi(n[8:])
+	if 97-base%97 != check {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateBG(n string) error {
+	if (len(n) != 9 && len(n) != 10) || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	if len(n) == 9 {
+		c := weightedSum(d, 1, 2, 3, 4, 5, 6, 7, 8) % 11
+		if c == 10 {
+			c = weightedSum(d, 3, 4, 5, 6, 7, 8, 9, 10) % 11 % 10
+		}
+		if c != d[8] {
+			return ErrInvalidChecksum
+		}
+		return nil
+	}
+	// Ten-digit numbers belong to individuals (EGN), foreigners or other
+	// entities, each with its own check digit scheme.
+	if c := weightedSum(d, 2, 4, 8, 5, 10, 9, 7, 3, 6) % 11 % 10; c == d[9] {
+		return nil
+	}
+	if c := weightedSum(d, 21, 19, 17, 13, 11, 9, 7, 3, 1) % 10; c == d[9] {
+		return nil
+	}
+	c := 11 - weightedSum(d, 4, 3, 2, 7, 6, 5, 4, 3, 2)%11
+	if c == 11 {
+		c = 0
+	}
+	if c != 10 && c == d[9] {
+		return nil
+	}
+	return ErrInvalidChecksum
+}
+
+func validateCY(n string) error {
+	if len(n) != 9 || !isDigits(n[:8]) || n[8] < 'A' || n[8] > 'Z' {
+		return ErrInvalidFormat
+	}
+	if !strings.ContainsRune("013459", rune(n[0])) || strings.HasPrefix(n, "12") {
+		return ErrInvalidFormat
+	}
+	odd := [10]int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21}
+	sum := 0
+	for i, v := range digits(n[:8]) {
+		if i%2 == 0 {
+			sum += odd[v]
+		} else {
+			sum += v
+		}
+	}
+	if byte('A'+sum%26) != n[8] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateCZ(n string) error {
+	if len(n) < 8 || len(n) > 10 || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	switch len(n) {
+	case 8:
+		if d[0] == 9 {
+			return ErrInvalidFormat
+		}
+		if (11-weightedSum(d, 8, 7, 6, 5, 4, 3, 2)%11)%10 != d[7] {
+			return ErrInvalidChecksum
+		}
+	case 10:
+		// Birth numbers of individuals issued since 1954.
+		v, _ := strconv.ParseInt(n, 10, 64)
+		if v%11 != 0 && !((v/10)%11 == 10 && d[9] == 0) {
+			return ErrInvalidChecksum
+		}
+	}
+	// Nine-digit numbers are either special individual numbers or birth
+	// numbers issued before 1954, which carry no check digit.
+	return nil
+}
+
+func validateDE(n string) error {
+	if len(n) != 9 || !isDigits(n) || n[0] == '0' {
+		return ErrInvalidFormat
+	}
+	if !mod1110Valid(n) {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateDK(n string) error {
+	if len(n) != 8 || !isDigits(n) || n[0] == '0' {
+		return ErrInvalidFormat
+	}
+	if weightedSum(digits(n), 2, 7, 6, 5, 4, 3, 2, 1)%11 != 0 {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateEE(n string) error {
+	if len(n) != 9 || !isDigits(n) || !strings.HasPrefix(n, "10") {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	if (10-weightedSum(d, 3, 7, 1, 3, 7, 1, 3, 7)%10)%10 != d[8] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateEL(n string) error {
+	if len(n) == 8 {
+		n = "0" + n
+	}
+	if len(n) != 9 || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	if weightedSum(d, 256, 128, 64, 32, 16, 8, 4, 2)%11%10 != d[8] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateES(n string) error {
+	if len(n) != 9 {
+		return ErrInvalidFormat
+	}
+	const dniLetters = "TRWAGMYFPDXBNJZSQVHLCKE"
+	first, middle, last := n[0], n[1:8], n[8]
+	if !isDigits(middle) {
+		return ErrInvalidFormat
+	}
+	switch {
+	case first >= '0' && first <= '9':
+		// DNI of Spanish nationals.
+		if !isDigits(n[:8]) || last < 'A' || last > 'Z' {
+			return ErrInvalidFormat
+		}
+		v, _ := strconv.Atoi(n[:8])
+		if dniLetters[v%23] != last {
+			return ErrInvalidChecksum
+		}
+	case first == 'X' || first == 'Y' || first == 'Z':
+		// NIE of foreign residents.
+		v, _ := strconv.Atoi(string('0'+first-'X') + middle)
+		if dniLetters[v%23] != last {
+			return ErrInvalidChecksum
+		}
+	case first == 'K' || first == 'L' || first == 'M':
+		v, _ := strconv.Atoi(middle)
+		if dniLetters[v%23] != last {
+			return ErrInvalidChecksum
+		}
+	case strings.IndexByte("ABCDEFGHJNPQRSUVW", first) >= 0:
+		// CIF of legal entities.
+		sum := 0
+		for i, v := range digits(middle) {
+			if i%2 == 0 {
+				v *= 2
+				v = v/10 + v%10
+			}
+			sum += v
+		}
+		c := (10 - sum%10) % 10
+		digit, letter := byte('0'+c), "JABCDEFGHI"[c]
+		switch {
+		case strings.IndexByte("ABEH", first) >= 0:
+			if last != digit {
+				return ErrInvalidChecksum
+			}
+		case strings.IndexByte("NPQRSW", first) >= 0:
+			if last != letter {
+				return ErrInvalidChecksum
+			}
+		default:
+			if last != digit && last != letter {
+				return ErrInvalidChecksum
+			}
+		}
+	default:
+		return ErrInvalidFormat
+	}
+	return nil
+}
+
+func validateFI(n string) error {
+	if len(n) != 8 || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	r := weightedSum(d, 7, 9, 10, 5, 8, 4, 2) % 11
+	if r == 1 {
+		return ErrInvalidChecksum
+	}
+	c := 0
+	if r != 0 {
+		c = 11 - r
+	}
+	if c != d[7] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateFR(n string) error {
+	if len(n) != 11 || !isDigits(n[2:]) {
+		return ErrInvalidFormat
+	}
+	siren, _ := strconv.ParseInt(n[2:], 10, 64)
+	if isDigits(n[:2]) {
+		key, _ := strconv.ParseInt(n[:2], 10, 64)
+		if (12+3*(siren%97))%97 != key {
+			return ErrInvalidChecksum
+		}
+		return nil
+	}
+	// Alphanumeric keys used for newer registrations.
+	const alphabet = "0123456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+	a, b := strings.IndexByte(alphabet, n[0]), strings.IndexByte(alphabet, n[1])
+	if a < 0 || b < 0 {
+		return ErrInvalidFormat
+	}
+	var check int64
+	if a < 10 {
+		check = int64(a*24 + b - 10)
+	} else {
+		check = int64(a*34 + b - 100)
+	}
+	if (siren+1+check/11)%11 != check%11 {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateHR(n string) error {
+	if len(n) != 11 || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	if !mod1110Valid(n) {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateHU(n string) error {
+	if len(n) != 8 || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	if (10-weightedSum(d, 9, 7, 3, 1, 9, 7, 3)%10)%10 != d[7] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateIE(n string) error {
+	// Old format: digit, letter or symbol, five digits, check letter.
+	if len(n) == 8 && isDigits(n[:1]) && !isDigits(n[1:2]) && isDigits(n[2:7]) {
+		n = "0" + n[2:7] + n[:1] + n[7:]
+	}
+	if (len(n) != 8 && len(n) != 9) || !isDigits(n[:7]) || n[7] < 'A' || n[7] > 'W' {
+		return ErrInvalidFormat
+	}
+	sum := weightedSum(digits(n[:7]), 8, 7, 6, 5, 4, 3, 2)
+	if len(n) == 9 {
+		i := strings.IndexByte("WABCDEFGHI", n[8])
+		if i < 0 {
+			return ErrInvalidFormat
+		}
+		sum += 9 * i
+	}
+	if "WABCDEFGHIJKLMNOPQRSTUV"[sum%23] != n[7] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateIT(n string) error {
+	if len(n) != 11 || !isDigits(n) || n[:7] == "0000000" {
+		return ErrInvalidFormat
+	}
+	office, _ := strconv.Atoi(n[7:10])
+	if (office < 1 || office > 100) && office != 120 && office != 121 && office != 888 && office != 999 {
+		return ErrInvalidFormat
+	}
+	if !luhnValid(n) {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateLT(n string) error {
+	if (len(n) != 9 && len(n) != 12) || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	if d[len(d)-2] != 1 {
+		return ErrInvalidFormat
+	}
+	body := d[:len(d)-1]
+	sum := 0
+	for i, v := range body {
+		sum += v * (1 + i%9)
+	}
+	c := sum % 11
+	if c == 10 {
+		sum = 0
+		for i, v := range body {
+			sum += v * (1 + (i+2)%9)
+		}
+		c = sum % 11 % 10
+	}
+	if c != d[len(d)-1] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateLU(n string) error {
+	if len(n) != 8 || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	base, _ := strconv.Atoi(n[:6])
+	check, _ := strconv.Atoi(n[6:])
+	if base%89 != check {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateLV(n string) error {
+	if len(n) != 11 || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	if d[0] > 3 {
+		// Legal entities.
+		if weightedSum(d, 9, 1, 4, 8, 3, 10, 2, 5, 7, 6, 1)%11 != 3 {
+			return ErrInvalidChecksum
+		}
+		return nil
+	}
+	if strings.HasPrefix(n, "32") {
+		// Personal codes issued since 2017 carry no check digit.
+		return nil
+	}
+	if (1+weightedSum(d, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9))%11%10 != d[10] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateMT(n string) error {
+	if len(n) != 8 || !isDigits(n) || n[0] == '0' {
+		return ErrInvalidFormat
+	}
+	if weightedSum(digits(n), 3, 4, 6, 7, 8, 9, 10, 1)%37 != 0 {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateNL(n string) error {
+	if len(n) != 12 || !isDigits(n[:9]) || n[9] != 'B' || !isDigits(n[10:]) {
+		return ErrInvalidFormat
+	}
+	// Numbers issued since 2020 use ISO 7064 MOD 97-10 over the full ID
+	// with letters mapped to numbers (N=23, L=21, B=11).
+	if mod97("2321"+n[:9]+"11"+n[10:]) == 1 {
+		return nil
+	}
+	d := digits(n[:9])
+	c := weightedSum(d, 9, 8, 7, 6, 5, 4, 3, 2) % 11
+	if c == 10 || c != d[8] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validatePL(n string) error {
+	if len(n) != 10 || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	c := weightedSum(d, 6, 5, 7, 2, 3, 4, 5, 6, 7) % 11
+	if c == 10 || c != d[9] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validatePT(n string) error {
+	if len(n) != 9 || !isDigits(n) || n[0] == '0' {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	c := 11 - weightedSum(d, 9, 8, 7, 6, 5, 4, 3, 2)%11
+	if c >= 10 {
+		c = 0
+	}
+	if c != d[8] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateRO(n string) error {
+	if len(n) < 2 || len(n) > 10 || !isDigits(n) || n[0] == '0' {
+		return ErrInvalidFormat
+	}
+	d := digits(strings.Repeat("0", 10-len(n)) + n)
+	c := weightedSum(d, 7, 5, 3, 2, 1, 7, 5, 3, 2) * 10 % 11 % 10
+	if c != d[9] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateSE(n string) error {
+	if len(n) != 12 || !isDigits(n) || !strings.HasSuffix(n, "01") {
+		return ErrInvalidFormat
+	}
+	if !luhnValid(n[:10]) {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateSI(n string) error {
+	if len(n) != 8 || !isDigits(n) || n[0] == '0' {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	c := 11 - weightedSum(d, 8, 7, 6, 5, 4, 3, 2)%11
+	if c == 10 {
+		c = 0
+	}
+	if c == 11 || c != d[7] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateSK(n string) error {
+	if len(n) != 10 || !isDigits(n) || n[0] == '0' || strings.IndexByte("234789", n[2]) < 0 {
+		return ErrInvalidFormat
+	}
+	v, _ := strconv.ParseInt(n, 10, 64)
+	if v%11 != 0 {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+func validateGB(n string) error {
+	switch {
+	case len(n) == 5 && strings.HasPrefix(n, "GD") && isDigits(n[2:]):
+		// Government departments.
+		if v, _ := strconv.Atoi(n[2:]); v >= 500 {
+			return ErrInvalidFormat
+		}
+		return nil
+	case len(n) == 5 && strings.HasPrefix(n, "HA") && isDigits(n[2:]):
+		// Health authorities.
+		if v, _ := strconv.Atoi(n[2:]); v < 500 {
+			return ErrInvalidFormat
+		}
+		return nil
+	case (len(n) == 9 || len(n) == 12) && isDigits(n):
+		// Standard and branch numbers. The check digits satisfy either the
+		// original mod 97 scheme or the 9755 scheme (offset 55).
+		sum := weightedSum(digits(n), 8, 7, 6, 5, 4, 3, 2, 10, 1) % 97
+		if sum != 0 && sum != 42 {
+			return ErrInvalidChecksum
+		}
+		return nil
+	}
+	return ErrInvalidFormat
+}
+
+func validateCHE(n string) error {
+	if len(n) != 9 || !isDigits(n) {
+		return ErrInvalidFormat
+	}
+	d := digits(n)
+	c := 11 - weightedSum(d, 5, 4, 3, 2, 7, 6, 5, 4)%11
+	if c == 11 {
+		c = 0
+	}
+	if c == 10 || c != d[8] {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package vatid normalizes and validates VAT identification numbers for all
+// EU member states, Switzerland (CHE-UID) and the United Kingdom (GB VRN),
+// including country-specific check digits.
+package vatid
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// Validation errors returned by Parse and Validate.
+var (
+	ErrEmpty              = errors.New("VAT ID is empty")
+	ErrUnsupportedCountry = errors.New("unsupported VAT ID country prefix")
+	ErrInvalidFormat      = errors.New("invalid VAT ID format")
+	ErrInvalidChecksum    = errors.New("invalid VAT ID check digits")
+)
+
+// ID is a normalized VAT identification number split into its country
+// prefix and national number.
+type ID struct {
+	Country string `json:"country"`
+	Number  string `json:"number"`
+}
+
+// String returns the ID in its compact form, e.g. "DE136695976".
+func (id ID) String() string {
+	return id.Country + id.Number
+}
+
+// Format returns the ID in its conventional display form. Swiss UIDs are
+// grouped as "CHE-123.456.789"; other IDs are returned in compact form.
+func (id ID) Format() string {
+	if id.Country == "CHE" && len(id.Number) == 9 {
+		return fmt.Sprintf("CHE-%s.%s.%s", id.Number[0:3], id.Number[3:6], id.Number[6:9])
+	}
+	return id.String()
+}
+
+// IsEU returns true if the ID is issued by an EU member state, including
+// Northern Ireland (XI) for goods.
+func (id ID) IsEU() bool {
+	return id.Country != "CHE" && id.Country != "GB"
+}
+
+// validators maps VAT ID country prefixes to their number validators.
+var validators = map[string]func(string) error{
+	"AT":  validateAT,
+	"BE":  validateBE,
+	"BG":  validateBG,
+	"CY":  validateCY,
+	"CZ":  validateCZ,
+	"DE":  validateDE,
+	"DK":  validateDK,
+	"EE":  validateEE,
+	"EL":  validateEL,
+	"ES":  validateES,
+	"FI":  validateFI,
+	"FR":  validateFR,
+	"HR":  validateHR,
+	"HU":  validateHU,
+	"IE":  validateIE,
+	"IT":  validateIT,
+	"LT":  validateLT,
+	"LU":  validateLU,
+	"LV":  validateLV,
+	"MT":  validateMT,
+	"NL":  validateNL,
+	"PL":  validatePL,
+	"PT":  validatePT,
+	"RO":  validateRO,
+	"SE":  validateSE,
+	"SI":  validateSI,
+	"SK":  validateSK,
+	"XI":  validateGB,
+	"GB":  validateGB,
+	"CHE": validateCHE,
+}
+
+// Countries returns the supported VAT ID country prefixes.
+func Countries() []string {
+	countries := make([]string, 0, len(validators))
+	for c := range validators {
+		countries = append(countries, c)
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+// Normalize upper-cases the VAT ID and strips spaces, punctuation and
+// Swiss register suffixes such as "MWST". The Greek ISO prefix "GR" is
+// replaced with the VAT prefix "EL".
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '+', r == '*':
+			b.WriteRune(r)
+		}
+	}
+	n := b.String()
+	if strings.HasPrefix(n, "CHE") {
+		for _, suffix := range []string{"MWST", "TVA", "IVA"} {
+			n = strings.TrimSuffix(n, suffix)
+		}
+	}
+	if strings.HasPrefix(n, "GR") {
+		n = "EL" + n[2:]
+	}
+	return n
+}
+
+// Parse normalizes and validates a VAT ID.
+func Parse(s string) (ID, error) {
+	n := Normalize(s)
+	if n == "" {
+		return ID{}, ErrEmpty
+	}
+	country := ""
+	switch {
+	case strings.HasPrefix(n, "CHE"):
+		country = "CHE"
+	case len(n) >= 2:
+		country = n[:2]
+	}
+	validate, ok := validators[country]
+	if !ok {
+		return ID{}, fmt.Errorf("%w: %q", ErrUnsupportedCountry, s)
+	}
+	id := ID{Country: country, Number: n[len(country):]}
+	if err := validate(id.Number); err != nil {
+		return ID{}, fmt.Errorf("%w: %s", err, id)
+	}
+	return id, nil
+}
+
+// Validate returns an error if the VAT ID is malformed or its check digits
+// do not match.
+func Validate(s string) error {
+	_, err := Parse(s)
+	return err
+}
+
+// IsValid returns true if the VAT ID is well-formed with matching check digits.
+func IsValid(s string) bool {
+	return Validate(s) == nil
+}
+
+// PartyRule is an invoice.PartyRule that validates the party's VAT ID when
+// one is set.
+func PartyRule(p invoice.Party) error {
+	if p.VATID == "" {
+		return nil
+	}
+	if err := Validate(p.VATID); err != nil {
+		return fmt.Errorf("%s: %w", p.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package vatid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+func TestValidIDs(t *testing.T) {
+	valid := []string{
+		"ATU13585627",
+		"BE 0403.019.261",
+		"BG175074752",
+		"CY10259033P",
+		"CZ25123891",
+		"DE 136 695 976",
+		"DK13585628",
+		"EE100931558",
+		"EL094259216",
+		"GR094259216",
+		"ESA13585625",
+		"ES54362315K",
+		"ESX5253868R",
+		"FI20774740",
+		"FR40303265045",
+		"HR33392005961",
+		"HU12892312",
+		"IE6433435F",
+		"IE8Z49289F",
+		"IT00743110157",
+		"LT119511515",
+		"LU15027442",
+		"LV40003521600",
+		"MT11679112",
+		"NL004495445B01",
+		"PL8567346215",
+		"PT501964843",
+		"RO18547290",
+		"SE123456789701",
+		"SI50223054",
+		"SK2022749619",
+		"GB980780684",
+		"XI980780684",
+		"CHE-100.155.212 MWST",
+	}
+	for _, s := range valid {
+		if err := Validate(s); err != nil {
+			t.Errorf("expected %q to be valid, got %v", s, err)
+		}
+	}
+}
+
+func TestInvalidIDs(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr error
+	}{
+		{"", ErrEmpty},
+		{"US123456789", ErrUnsupportedCountry},
+		{"DE12345", ErrInvalidFormat},
+		{"DE136695977", ErrInvalidChecksum},
+		{"ATU13585626", ErrInvalidChecksum},
+		{"FR41303265045", ErrInvalidChecksum},
+		{"NL004495446B01", ErrInvalidChecksum},
+		{"GB980780685", ErrInvalidChecksum},
+		{"CHE-100.155.213", ErrInvalidChecksum},
+		{"IT00743110158", ErrInvalidChecksum},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.id); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Validate(%q): expected %v, got %v", tt.id, tt.wantErr, err)
+		}
+	}
+}
+
+func TestParseAndFormat(t *testing.T) {
+	id, err := Parse("che 100 155 212 tva")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.Country != "CHE" || id.Number != "100155212" {
+		t.Errorf("unexpected ID %+v", id)
+	}
+	if id.Format() != "CHE-100.155.212" {
+		t.Errorf("expected CHE-100.155.212, got %s", id.Format())
+	}
+	if Normalize("gr 094 259 216") != "EL094259216" {
+		t.Errorf("expected GR prefix to be normalized to EL")
+	}
+}
+
+func TestPartyRule(t *testing.T) {
+	_, err := invoice.New().
+		Number("INV-001").
+		IssueDate(testDate).
+		DueDate(testDate.AddDate(0, 0, 30)).
+		Currency("EUR").
+		Supplier(invoice.Party{Name: "S", VATID: "DE136695977"}).
+		Customer(invoice.Party{Name: "C"}).
+		AddItem(invoice.NewLineItem("X", 1, invoice.NewMoney(100, "EUR"), 19)).
+		PartyRules(PartyRule).
+		Build()
+	if !errors.Is(err, ErrInvalidChecksum) {
+		t.Errorf("expected ErrInvalidChecksum, got %v", err)
+	}
+}
+
+func TestOnlineRuleWithStub(t *testing.T) {
+	checker := NewStubChecker("DE136695976")
+	rule := OnlineRule(context.Background(), checker)
+
+	if err := rule(invoice.Party{Name: "A", VATID: "DE 136695976"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := rule(invoice.Party{Name: "B", VATID: "ATU13585627"}); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("expected ErrNotRegistered, got %v", err)
+	}
+}
+
+var testDate = time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
@@ -0,0 +1,170 @@
+package vatid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wiederin/go-invoicer/invoice"
+)
+
+// ErrNotRegistered is returned by online rules when the VAT ID is
+// syntactically valid but not registered.
+var ErrNotRegistered = errors.New("VAT ID is not registered")
+
+// CheckResult is the outcome of an online VAT ID registration check.
+type CheckResult struct {
+	ID          ID        `json:"id"`
+	Valid       bool      `json:"valid"`
+	Name        string    `json:"name,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	RequestDate time.Time `json:"request_date"`
+}
+
+// Checker checks whether a VAT ID is registered with the issuing authority.
+type Checker interface {
+	Check(ctx context.Context, id ID) (CheckResult, error)
+}
+
+// DefaultVIESEndpoint is the REST endpoint of the EU VIES service.
+const DefaultVIESEndpoint = "https://ec.europa.eu/taxation_customs/vies/rest-api/check-vat-number"
+
+// VIESClient checks EU VAT IDs against the European Commission's VIES service.
+type VIESClient struct {
+	HTTPClient *http.Client
+	Endpoint   string
+}
+
+// NewVIESClient creates a VIES client using the public endpoint.
+func NewVIESClient() *VIESClient {
+	return &VIESClient{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Endpoint:   DefaultVIESEndpoint,
+	}
+}
+
+type viesRequest struct {
+	CountryCode string `json:"countryCode"`
+	VATNumber   string `json:"vatNumber"`
+}
+
+type viesResponse struct {
+	Valid         bool      `json:"valid"`
+	Name          string    `json:"name"`
+	Address       string    `json:"address"`
+	RequestDate   time.Time `json:"requestDate"`
+	ErrorWrappers []struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	} `json:"errorWrappers"`
+}
+
+// Check queries VIES for the given ID. Only EU IDs can be checked.
+func (c *VIESClient) Check(ctx context.Context, id ID) (CheckResult, error) {
+	if !id.IsEU() {
+		return CheckResult{}, fmt.Errorf("%w: VIES does not cover %s", ErrUnsupportedCountry, id.Country)
+	}
+	body, err := json.Marshal(viesRequest{CountryCode: id.Country, VATNumber: id.Number})
+	if err != nil {
+		return CheckResult{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return CheckResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return CheckResult{}, fmt.Errorf("VIES request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return CheckResult{}, fmt.Errorf("VIES request failed: %s", resp.Status)
+	}
+
+	var out viesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return CheckResult{}, fmt.Errorf("invalid VIES response: %w", err)
+	}
+	if len(out.ErrorWrappers) > 0 {
+		return CheckResult{}, fmt.Errorf("VIES error: %s", out.ErrorWrappers[0].Error)
+	}
+	return CheckResult{
+		ID:          id,
+		Valid:       out.Valid,
+		Name:        out.Name,
+		Address:     out.Address,
+		RequestDate: out.RequestDate,
+	}, nil
+}
+
+// StubChecker is an in-memory Checker for tests and offline use. IDs that
+// were not registered are reported as not valid.
+type StubChecker struct {
+	mu      sync.RWMutex
+	results map[string]CheckResult
+}
+
+// NewStubChecker creates a stub checker that reports the given IDs as valid.
+func NewStubChecker(validIDs ...string) *StubChecker {
+	s := &StubChecker{results: make(map[string]CheckResult)}
+	for _, v := range validIDs {
+		s.Register(CheckResult{ID: ID{Number: v}, Valid: true})
+	}
+	return s
+}
+
+// Register stores the result returned for its ID.
+func (s *StubChecker) Register(result CheckResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[Normalize(result.ID.String())] = result
+}
+
+// Check returns the registered result for the ID.
+func (s *StubChecker) Check(_ context.Context, id ID) (CheckResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result, ok := s.results[id.String()]
+	if !ok {
+		return CheckResult{ID: id, RequestDate: time.Now()}, nil
+	}
+	result.ID = id
+	if result.RequestDate.IsZero() {
+		result.RequestDate = time.Now()
+	}
+	return result, nil
+}
+
+// OnlineRule returns an invoice.PartyRule that validates the party's VAT ID
+// offline and then confirms its registration with the checker. Non-EU IDs
+// are only validated offline when the checker is VIES-backed.
+func OnlineRule(ctx context.Context, checker Checker) invoice.PartyRule {
+	return func(p invoice.Party) error {
+		if p.VATID == "" {
+			return nil
+		}
+		id, err := Parse(p.VATID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		if _, ok := checker.(*VIESClient); ok && !id.IsEU() {
+			return nil
+		}
+		result, err := checker.Check(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+		if !result.Valid {
+			return fmt.Errorf("%s: %w: %s", p.Name, ErrNotRegistered, id)
+		}
+		return nil
+	}
+}